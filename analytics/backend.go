@@ -0,0 +1,168 @@
+package analytics
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/go-utils/v2/analytics"
+	"github.com/bitrise-io/go-utils/v2/env"
+	utilslog "github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-utils/v2/retryhttp"
+)
+
+const (
+	// BackendsEnvKey is the comma separated list of the backends receiving the analytics events.
+	BackendsEnvKey = "BITRISE_ANALYTICS_BACKENDS"
+	// FileEnvKey is the path of the NDJSON file the `file` backend appends the events to.
+	FileEnvKey = "BITRISE_ANALYTICS_FILE"
+	// WebhookURLEnvKey is the URL the `webhook` backend posts the events to.
+	WebhookURLEnvKey = "BITRISE_ANALYTICS_WEBHOOK_URL"
+
+	BackendBitrise = "bitrise"
+	BackendFile    = "file"
+	BackendWebhook = "webhook"
+
+	clientTimeout      = 30 * time.Second
+	trackerWaitTimeout = 30 * time.Second
+)
+
+// BackendConfig selects the backends receiving the analytics events.
+type BackendConfig struct {
+	Backends   []string
+	File       string
+	WebhookURL string
+}
+
+// ReadBackendConfig reads the backend config from the environment, falling back to the agent config.
+// If no backend is selected explicitly, the configured local sinks are used,
+// and the events are only sent to Bitrise if no local sink is configured.
+func ReadBackendConfig(envRepository env.Repository, agentConfig *configs.AgentConfig) BackendConfig {
+	var config BackendConfig
+	if agentConfig != nil {
+		config = BackendConfig{
+			Backends:   agentConfig.Analytics.Backends,
+			File:       agentConfig.Analytics.File,
+			WebhookURL: agentConfig.Analytics.WebhookURL,
+		}
+	}
+
+	if backends := envRepository.Get(BackendsEnvKey); backends != "" {
+		config.Backends = nil
+		for _, backend := range strings.Split(backends, ",") {
+			if backend = strings.TrimSpace(backend); backend != "" {
+				config.Backends = append(config.Backends, backend)
+			}
+		}
+	}
+	if file := envRepository.Get(FileEnvKey); file != "" {
+		config.File = file
+	}
+	if webhookURL := envRepository.Get(WebhookURLEnvKey); webhookURL != "" {
+		config.WebhookURL = webhookURL
+	}
+
+	if len(config.Backends) == 0 {
+		if config.File != "" {
+			config.Backends = append(config.Backends, BackendFile)
+		}
+		if config.WebhookURL != "" {
+			config.Backends = append(config.Backends, BackendWebhook)
+		}
+		if len(config.Backends) == 0 {
+			config.Backends = []string{BackendBitrise}
+		}
+	}
+
+	return config
+}
+
+// NewClient creates the client sending the events to the selected backends.
+func NewClient(config BackendConfig, logger utilslog.Logger) (analytics.Client, error) {
+	var clients []analytics.Client
+	for _, backend := range config.Backends {
+		switch backend {
+		case BackendBitrise:
+			clients = append(clients, analytics.NewDefaultClient(logger, clientTimeout))
+		case BackendFile:
+			if config.File == "" {
+				return nil, fmt.Errorf("%s backend selected, but %s is not set", BackendFile, FileEnvKey)
+			}
+			clients = append(clients, newFileClient(config.File, logger))
+		case BackendWebhook:
+			if config.WebhookURL == "" {
+				return nil, fmt.Errorf("%s backend selected, but %s is not set", BackendWebhook, WebhookURLEnvKey)
+			}
+			httpClient := retryhttp.NewClient(logger).StandardClient()
+			httpClient.Timeout = clientTimeout
+			clients = append(clients, analytics.NewClient(httpClient, config.WebhookURL, logger, clientTimeout))
+		default:
+			return nil, fmt.Errorf("unknown analytics backend (%s): accepted values are %s, %s and %s", backend, BackendBitrise, BackendFile, BackendWebhook)
+		}
+	}
+
+	if len(clients) == 1 {
+		return clients[0], nil
+	}
+	return multiClient(clients), nil
+}
+
+// fileClient appends every event as a JSON line to the file.
+type fileClient struct {
+	mu     *sync.Mutex
+	path   string
+	logger utilslog.Logger
+}
+
+func newFileClient(path string, logger utilslog.Logger) analytics.Client {
+	return fileClient{mu: &sync.Mutex{}, path: path, logger: logger}
+}
+
+// Send ...
+func (c fileClient) Send(buffer *bytes.Buffer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		c.logger.Debugf("Couldn't create analytics file dir: %s", err)
+		return
+	}
+
+	file, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		c.logger.Debugf("Couldn't open analytics file: %s", err)
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			c.logger.Debugf("Couldn't close analytics file: %s", err)
+		}
+	}()
+
+	// The events are newline terminated JSON objects
+	if _, err := file.Write(buffer.Bytes()); err != nil {
+		c.logger.Debugf("Couldn't write analytics event: %s", err)
+	}
+}
+
+// multiClient sends every event to each of its clients.
+type multiClient []analytics.Client
+
+// Send ...
+func (c multiClient) Send(buffer *bytes.Buffer) {
+	event := buffer.Bytes()
+	for _, client := range c {
+		client.Send(bytes.NewBuffer(append([]byte{}, event...)))
+	}
+}
+
+// noopClient drops the events, used if the backends are misconfigured.
+type noopClient struct{}
+
+// Send ...
+func (noopClient) Send(*bytes.Buffer) {}
@@ -0,0 +1,134 @@
+package analytics
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/log"
+	"github.com/bitrise-io/go-utils/v2/analytics"
+	"github.com/bitrise-io/go-utils/v2/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBackendConfig(t *testing.T) {
+	agentConfig := &configs.AgentConfig{Analytics: configs.AgentAnalytics{
+		Backends: []string{BackendBitrise, BackendFile},
+		File:     "/agent/events.ndjson",
+	}}
+
+	tests := []struct {
+		name        string
+		envs        map[string]string
+		agentConfig *configs.AgentConfig
+		want        BackendConfig
+	}{
+		{
+			name: "Defaults to bitrise",
+			want: BackendConfig{Backends: []string{BackendBitrise}},
+		},
+		{
+			name: "Configured local sinks are used instead of bitrise",
+			envs: map[string]string{FileEnvKey: "/tmp/events.ndjson", WebhookURLEnvKey: "http://localhost:8080"},
+			want: BackendConfig{Backends: []string{BackendFile, BackendWebhook}, File: "/tmp/events.ndjson", WebhookURL: "http://localhost:8080"},
+		},
+		{
+			name: "Backends are selected explicitly",
+			envs: map[string]string{BackendsEnvKey: "bitrise, webhook", WebhookURLEnvKey: "http://localhost:8080"},
+			want: BackendConfig{Backends: []string{BackendBitrise, BackendWebhook}, WebhookURL: "http://localhost:8080"},
+		},
+		{
+			name:        "Agent config",
+			agentConfig: agentConfig,
+			want:        BackendConfig{Backends: []string{BackendBitrise, BackendFile}, File: "/agent/events.ndjson"},
+		},
+		{
+			name:        "Envs take precedence over the agent config",
+			envs:        map[string]string{BackendsEnvKey: "file", FileEnvKey: "/tmp/events.ndjson"},
+			agentConfig: agentConfig,
+			want:        BackendConfig{Backends: []string{BackendFile}, File: "/tmp/events.ndjson"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{BackendsEnvKey, FileEnvKey, WebhookURLEnvKey} {
+				t.Setenv(key, tt.envs[key])
+			}
+
+			require.Equal(t, tt.want, ReadBackendConfig(env.NewRepository(), tt.agentConfig))
+		})
+	}
+}
+
+func TestNewClient_Errors(t *testing.T) {
+	logger := log.NewUtilsLogAdapter()
+
+	_, err := NewClient(BackendConfig{Backends: []string{BackendFile}}, &logger)
+	require.EqualError(t, err, "file backend selected, but BITRISE_ANALYTICS_FILE is not set")
+
+	_, err = NewClient(BackendConfig{Backends: []string{BackendWebhook}}, &logger)
+	require.EqualError(t, err, "webhook backend selected, but BITRISE_ANALYTICS_WEBHOOK_URL is not set")
+
+	_, err = NewClient(BackendConfig{Backends: []string{"kafka"}}, &logger)
+	require.EqualError(t, err, "unknown analytics backend (kafka): accepted values are bitrise, file and webhook")
+}
+
+func TestNewClient_FileAndWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var webhookEvents []map[string]interface{}
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &event))
+
+		mu.Lock()
+		defer mu.Unlock()
+		webhookEvents = append(webhookEvents, event)
+	}))
+	defer webhook.Close()
+
+	file := filepath.Join(t.TempDir(), "analytics", "events.ndjson")
+	logger := log.NewUtilsLogAdapter()
+	client, err := NewClient(BackendConfig{Backends: []string{BackendFile, BackendWebhook}, File: file, WebhookURL: webhook.URL}, &logger)
+	require.NoError(t, err)
+
+	tracker := analytics.NewTracker(client, 10*time.Second)
+	tracker.Enqueue(workflowStartedEventName, analytics.Properties{workflowNameProperty: "primary"})
+	tracker.Enqueue(cliWarningEventName, analytics.Properties{messageProperty: "warning"})
+	tracker.Wait()
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, f.Close())
+	}()
+
+	var fileEvents []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		fileEvents = append(fileEvents, event)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.ElementsMatch(t, []string{workflowStartedEventName, cliWarningEventName}, eventNames(fileEvents))
+	require.ElementsMatch(t, []string{workflowStartedEventName, cliWarningEventName}, eventNames(webhookEvents))
+}
+
+func eventNames(events []map[string]interface{}) []string {
+	var names []string
+	for _, event := range events {
+		names = append(names, event["event_name"].(string))
+	}
+	return names
+}
@@ -71,6 +71,8 @@ const (
 	runIfValue                     = "run_if"
 	customTimeoutValue             = "timeout"
 	noOutputTimeoutValue           = "no_output_timeout"
+	runTimeoutValue                = "run_timeout"
+	buildAbortedValue              = "build_aborted"
 	ToolSnapshotEndOfWorkflowValue = "end_of_workflow"
 
 	buildSlugEnvKey       = "BITRISE_BUILD_SLUG"
@@ -133,11 +135,27 @@ func NewDefaultTracker() Tracker {
 	stateChecker := NewStateChecker(envRepository)
 
 	logger := log.NewUtilsLogAdapter()
-	tracker := analytics.NewDefaultTracker(&logger)
+	client, err := NewClient(ReadBackendConfig(envRepository, readAgentConfig()), &logger)
+	if err != nil {
+		logger.Warnf("Analytics events are not sent: %s", err)
+		client = noopClient{}
+	}
+	tracker := analytics.NewTracker(client, trackerWaitTimeout)
 
 	return NewTracker(tracker, envRepository, stateChecker, &logger)
 }
 
+func readAgentConfig() *configs.AgentConfig {
+	if !configs.HasAgentConfig() {
+		return nil
+	}
+	config, err := configs.ReadAgentConfig(configs.GetAgentConfigPath())
+	if err != nil {
+		return nil
+	}
+	return &config
+}
+
 // SendWorkflowStarted sends `workflow_started` events. `parent_step_execution_id` can be used to filter those
 // Bitrise CLI events that were started as part of a step (like script).
 func (t tracker) SendWorkflowStarted(properties analytics.Properties, name string, title string) {
@@ -343,6 +361,16 @@ func mapStepResultToEvent(result StepResult) (string, analytics.Properties, erro
 		if result.NoOutputTimeout >= 0 {
 			extraProperties[timeoutProperty] = int64(result.NoOutputTimeout.Seconds())
 		}
+	case models.StepRunStatusAbortedWithRunTimeout:
+		eventName = stepAbortedEventName
+		extraProperties = analytics.Properties{reasonProperty: runTimeoutValue}
+
+		if result.Timeout >= 0 {
+			extraProperties[timeoutProperty] = int64(result.Timeout.Seconds())
+		}
+	case models.StepRunStatusAborted:
+		eventName = stepAbortedEventName
+		extraProperties = analytics.Properties{reasonProperty: buildAbortedValue}
 	case models.StepRunStatusCodePreparationFailed:
 		eventName = stepPreparationFailedEventName
 		extraProperties = prepareStartProperties(result.Info)
@@ -101,7 +101,7 @@ func getRunningStepFooterMainSection(stepRunResult models.StepRunResultsModel) s
 	case models.StepRunStatusCodeFailed, models.StepRunStatusCodePreparationFailed:
 		icon = "x"
 		coloringFunc = colorstring.Red
-	case models.StepRunStatusAbortedWithCustomTimeout, models.StepRunStatusAbortedWithNoOutputTimeout:
+	case models.StepRunStatusAbortedWithCustomTimeout, models.StepRunStatusAbortedWithNoOutputTimeout, models.StepRunStatusAbortedWithRunTimeout, models.StepRunStatusAborted:
 		icon = "/"
 		coloringFunc = colorstring.Red
 	case models.StepRunStatusCodeFailedSkippable:
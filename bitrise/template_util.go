@@ -3,6 +3,7 @@ package bitrise
 import (
 	"bytes"
 	"errors"
+	"io"
 	"os"
 	"strings"
 	"text/template"
@@ -45,12 +46,7 @@ func createTemplateDataModel(isCI, isPR bool, buildResults models.BuildRunResult
 	}
 }
 
-// EvaluateTemplateToString ...
-func EvaluateTemplateToString(expStr string, isCI, isPR bool, buildResults models.BuildRunResultsModel, envList envmanModels.EnvsJSONListModel) (string, error) {
-	if expStr == "" {
-		return "", errors.New("EvaluateTemplateToBool: Invalid, empty input: expStr")
-	}
-
+func parseTemplate(expStr string, envList envmanModels.EnvsJSONListModel) (*template.Template, error) {
 	if !strings.Contains(expStr, "{{") {
 		expStr = "{{" + expStr + "}}"
 	}
@@ -67,8 +63,31 @@ func EvaluateTemplateToString(expStr string, isCI, isPR bool, buildResults model
 		},
 	}
 
-	tmpl := template.New("EvaluateTemplateToBool").Funcs(templateFuncMap)
-	tmpl, err := tmpl.Parse(expStr)
+	return template.New("EvaluateTemplateToBool").Funcs(templateFuncMap).Parse(expStr)
+}
+
+// CheckTemplate checks the syntax, the referenced fields and the function calls of a run_if expression,
+// by evaluating it with an empty build result.
+func CheckTemplate(expStr string) error {
+	if expStr == "" {
+		return errors.New("CheckTemplate: Invalid, empty input: expStr")
+	}
+
+	tmpl, err := parseTemplate(expStr, nil)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(io.Discard, createTemplateDataModel(false, false, models.BuildRunResultsModel{}))
+}
+
+// EvaluateTemplateToString ...
+func EvaluateTemplateToString(expStr string, isCI, isPR bool, buildResults models.BuildRunResultsModel, envList envmanModels.EnvsJSONListModel) (string, error) {
+	if expStr == "" {
+		return "", errors.New("EvaluateTemplateToBool: Invalid, empty input: expStr")
+	}
+
+	tmpl, err := parseTemplate(expStr, envList)
 	if err != nil {
 		return "", err
 	}
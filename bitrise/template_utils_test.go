@@ -398,3 +398,28 @@ mode
 	require.Equal(t, nil, err)
 	require.Equal(t, true, (strings.Contains(value, "This is") && strings.Contains(value, "value in case of not IsCI") && strings.Contains(value, "mode")))
 }
+
+func TestCheckTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		expStr  string
+		wantErr string
+	}{
+		{name: "Field", expStr: ".IsCI"},
+		{name: "Function", expStr: `{{enveq "BRANCH" "main" | and .IsPR}}`},
+		{name: "Syntax error", expStr: "{{.IsCI", wantErr: `template: EvaluateTemplateToBool:1: unclosed action`},
+		{name: "Unknown field", expStr: ".IsCII", wantErr: `template: EvaluateTemplateToBool:1:2: executing "EvaluateTemplateToBool" at <.IsCII>: can't evaluate field IsCII in type bitrise.TemplateDataModel`},
+		{name: "Unknown function", expStr: `getenvs "A"`, wantErr: `template: EvaluateTemplateToBool:1: function "getenvs" not defined`},
+		{name: "Empty", expStr: "", wantErr: "CheckTemplate: Invalid, empty input: expStr"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTemplate(tt.expStr)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
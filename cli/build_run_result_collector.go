@@ -41,32 +41,13 @@ func (r buildRunResultCollector) registerStepRunResults(
 	isLastStep bool,
 	printStepHeader bool,
 	redactedStepInputs map[string]string,
-	properties coreanalytics.Properties) {
+	attempts []models.StepRunAttemptModel,
+	logPath string,
+	properties coreanalytics.Properties) models.StepRunResultsModel {
 
 	stepRuntime := time.Since(stepStartTime)
 
-	timeout, noOutputTimeout := time.Duration(-1), time.Duration(-1)
-	if status == models.StepRunStatusCodeFailed {
-		// Forward the status of a Step or a wrapped bitrise process.
-		switch exitCode {
-		case exitcode.CLIAbortedWithCustomTimeout:
-			status = models.StepRunStatusAbortedWithCustomTimeout
-		case exitcode.CLIAbortedWithNoOutputTimeout:
-			status = models.StepRunStatusAbortedWithNoOutputTimeout
-		}
-
-		var timeoutErr timeoutcmd.TimeoutError
-		if ok := errors.As(err, &timeoutErr); ok {
-			status = models.StepRunStatusAbortedWithCustomTimeout
-			timeout = timeoutErr.Timeout
-		}
-
-		var noOutputTimeoutErr timeoutcmd.NoOutputTimeoutError
-		if ok := errors.As(err, &noOutputTimeoutErr); ok {
-			status = models.StepRunStatusAbortedWithNoOutputTimeout
-			noOutputTimeout = noOutputTimeoutErr.Timeout
-		}
-	}
+	status, timeout, noOutputTimeout := stepRunStatusFromError(status, exitCode, err)
 
 	stepInfoCopy := stepmanModels.StepInfoModel{
 		Library:         stepInfoPtr.Library,
@@ -102,6 +83,8 @@ func (r buildRunResultCollector) registerStepRunResults(
 		ErrorStr:   errStr,
 		ExitCode:   exitCode,
 		StartTime:  stepStartTime,
+		Attempts:   attempts,
+		LogPath:    logPath,
 
 		Timeout:         timeout,
 		NoOutputTimeout: noOutputTimeout,
@@ -116,7 +99,69 @@ func (r buildRunResultCollector) registerStepRunResults(
 		Runtime:         stepRuntime,
 	})
 
-	switch status {
+	if !appendStepRunResults(buildRunResults, stepResults) {
+		return stepResults
+	}
+
+	logStepFinished(r.logger, stepResults, stepExecutionId, isLastStep)
+
+	return stepResults
+}
+
+// registerResumedStepRunResults registers the results of a step completed by the resumed build, without running it again.
+func (r buildRunResultCollector) registerResumedStepRunResults(buildRunResults *models.BuildRunResultsModel, stepResults models.StepRunResultsModel) models.StepRunResultsModel {
+	stepResults.Idx = buildRunResults.ResultsCount()
+	if appendStepRunResults(buildRunResults, stepResults) {
+		title := pointers.StringWithDefault(stepResults.StepInfo.Step.Title, stepResults.StepInfo.ID)
+		r.logger.Printf("Skipping Step (%s), it was completed by the resumed build (%s)", title, stepResults.Status.String())
+	}
+	return stepResults
+}
+
+// stepRunStatusFromError refines the failed status of a step with the timeout reasons.
+func stepRunStatusFromError(status models.StepRunStatus, exitCode int, err error) (models.StepRunStatus, time.Duration, time.Duration) {
+	timeout, noOutputTimeout := time.Duration(-1), time.Duration(-1)
+
+	// The workflow or build timeout and the build cancellation abort skippable steps too.
+	var runTimeoutErr runTimeoutError
+	if ok := errors.As(err, &runTimeoutErr); ok {
+		return models.StepRunStatusAbortedWithRunTimeout, runTimeoutErr.timeout, noOutputTimeout
+	}
+
+	var abortedErr timeoutcmd.AbortedError
+	if ok := errors.As(err, &abortedErr); ok {
+		return models.StepRunStatusAborted, timeout, noOutputTimeout
+	}
+
+	if status != models.StepRunStatusCodeFailed {
+		return status, timeout, noOutputTimeout
+	}
+
+	// Forward the status of a Step or a wrapped bitrise process.
+	switch exitCode {
+	case exitcode.CLIAbortedWithCustomTimeout:
+		status = models.StepRunStatusAbortedWithCustomTimeout
+	case exitcode.CLIAbortedWithNoOutputTimeout:
+		status = models.StepRunStatusAbortedWithNoOutputTimeout
+	}
+
+	var timeoutErr timeoutcmd.TimeoutError
+	if ok := errors.As(err, &timeoutErr); ok {
+		status = models.StepRunStatusAbortedWithCustomTimeout
+		timeout = timeoutErr.Timeout
+	}
+
+	var noOutputTimeoutErr timeoutcmd.NoOutputTimeoutError
+	if ok := errors.As(err, &noOutputTimeoutErr); ok {
+		status = models.StepRunStatusAbortedWithNoOutputTimeout
+		noOutputTimeout = noOutputTimeoutErr.Timeout
+	}
+
+	return status, timeout, noOutputTimeout
+}
+
+func appendStepRunResults(buildRunResults *models.BuildRunResultsModel, stepResults models.StepRunResultsModel) bool {
+	switch stepResults.Status {
 	case models.StepRunStatusCodeSuccess:
 		buildRunResults.SuccessSteps = append(buildRunResults.SuccessSteps, stepResults)
 	case models.StepRunStatusCodePreparationFailed:
@@ -125,22 +170,21 @@ func (r buildRunResultCollector) registerStepRunResults(
 		buildRunResults.FailedSteps = append(buildRunResults.FailedSteps, stepResults)
 	case models.StepRunStatusCodeFailedSkippable:
 		buildRunResults.FailedSkippableSteps = append(buildRunResults.FailedSkippableSteps, stepResults)
-	case models.StepRunStatusAbortedWithCustomTimeout, models.StepRunStatusAbortedWithNoOutputTimeout:
+	case models.StepRunStatusAbortedWithCustomTimeout, models.StepRunStatusAbortedWithNoOutputTimeout, models.StepRunStatusAbortedWithRunTimeout, models.StepRunStatusAborted:
 		buildRunResults.FailedSteps = append(buildRunResults.FailedSteps, stepResults)
 	case models.StepRunStatusCodeSkipped:
 		buildRunResults.SkippedSteps = append(buildRunResults.SkippedSteps, stepResults)
 	case models.StepRunStatusCodeSkippedWithRunIf:
 		buildRunResults.SkippedSteps = append(buildRunResults.SkippedSteps, stepResults)
 	default:
-		return
+		return false
 	}
-
-	logStepFinished(stepResults, stepExecutionId, isLastStep)
+	return true
 }
 
-func logStepFinished(stepResults models.StepRunResultsModel, stepExecutionID string, isLastStep bool) {
+func logStepFinished(logger log.Logger, stepResults models.StepRunResultsModel, stepExecutionID string, isLastStep bool) {
 	params := stepFinishedParamsFromResults(stepResults, stepExecutionID, isLastStep)
-	log.PrintStepFinishedEvent(params)
+	logger.PrintStepFinishedEvent(params)
 }
 
 func stepFinishedParamsFromResults(results models.StepRunResultsModel, stepExecutionID string, isLastStep bool) log.StepFinishedParams {
@@ -188,11 +232,21 @@ func stepFinishedParamsFromResults(results models.StepRunResultsModel, stepExecu
 		Update:        stepUpdate,
 		Deprecation:   stepDeprecation,
 		LastStep:      isLastStep,
+		LogPath:       results.LogPath,
 	}
 
 	statusReason, stepErrors := results.StatusReasonAndErrors()
 	params.StatusReason = statusReason
 	params.Errors = stepErrors
 
+	for _, attempt := range results.Attempts {
+		params.Attempts = append(params.Attempts, log.StepAttempt{
+			Status:   attempt.Status.String(),
+			ExitCode: attempt.ExitCode,
+			Error:    attempt.ErrorStr,
+			RunTime:  attempt.RunTime.Milliseconds(),
+		})
+	}
+
 	return params
 }
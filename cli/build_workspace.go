@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/tools"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/command"
+)
+
+// buildWorkspace holds the working paths of a single build.
+// Builds running in parallel (workflows of a DAG pipeline) can't share the process level paths of the configs package,
+// so each of them gets its own workspace under the bitrise work dir.
+type buildWorkspace struct {
+	workDir             string
+	stepsDir            string
+	inputEnvstorePath   string
+	outputEnvstorePath  string
+	formattedOutputPath string
+
+	// isolated workspaces expose the build level envs to the steps instead of setting them on the process
+	isolated            bool
+	triggeredWorkflowID string
+	triggeredTitle      string
+}
+
+func defaultBuildWorkspace() buildWorkspace {
+	return buildWorkspace{
+		workDir:             configs.BitriseWorkDirPath,
+		stepsDir:            configs.BitriseWorkStepsDirPath,
+		inputEnvstorePath:   configs.InputEnvstorePath,
+		outputEnvstorePath:  configs.OutputEnvstorePath,
+		formattedOutputPath: configs.FormattedOutputPath,
+	}
+}
+
+func newIsolatedBuildWorkspace(workflowID, workflowTitle string) (buildWorkspace, error) {
+	workDir, err := os.MkdirTemp(configs.BitriseWorkDirPath, fmt.Sprintf("workflow-%s-", workflowID))
+	if err != nil {
+		return buildWorkspace{}, fmt.Errorf("failed to create work dir: %w", err)
+	}
+
+	workspace := buildWorkspace{
+		workDir:             workDir,
+		stepsDir:            filepath.Join(workDir, "step_src"),
+		inputEnvstorePath:   filepath.Join(workDir, "input_envstore.yml"),
+		outputEnvstorePath:  filepath.Join(workDir, "output_envstore.yml"),
+		formattedOutputPath: filepath.Join(workDir, "formatted_output.md"),
+		isolated:            true,
+		triggeredWorkflowID: workflowID,
+		triggeredTitle:      workflowTitle,
+	}
+
+	if err := os.MkdirAll(workspace.stepsDir, 0755); err != nil {
+		return buildWorkspace{}, fmt.Errorf("failed to create step source dir: %w", err)
+	}
+	if err := tools.EnvmanInit(workspace.outputEnvstorePath, false); err != nil {
+		return buildWorkspace{}, fmt.Errorf("failed to run envman init: %w", err)
+	}
+
+	return workspace, nil
+}
+
+// cleanupStepWorkDir is the workspace aware version of bitrise.CleanupStepWorkDir.
+func (w buildWorkspace) cleanupStepWorkDir() error {
+	if !w.isolated {
+		return bitrise.CleanupStepWorkDir()
+	}
+
+	if err := command.RemoveFile(filepath.Join(w.workDir, "current_step.yml")); err != nil {
+		return fmt.Errorf("Failed to remove step yml: %s", err)
+	}
+	if err := command.RemoveDir(w.stepsDir); err != nil {
+		return fmt.Errorf("Failed to remove step work dir: %s", err)
+	}
+	return nil
+}
+
+// stepEnvironments returns the build level envs, which are set on the process for the default workspace.
+func (w buildWorkspace) stepEnvironments(isBuildFailed bool) []envmanModels.EnvironmentItemModel {
+	if !w.isolated {
+		return nil
+	}
+
+	environments := []envmanModels.EnvironmentItemModel{
+		{configs.EnvstorePathEnvKey: w.outputEnvstorePath},
+		{configs.FormattedOutputPathEnvKey: w.formattedOutputPath},
+		{"BITRISE_TRIGGERED_WORKFLOW_ID": w.triggeredWorkflowID},
+		{"BITRISE_TRIGGERED_WORKFLOW_TITLE": w.triggeredTitle},
+	}
+	return append(environments, bitrise.BuildFailedEnvs(isBuildFailed)...)
+}
+
+func (w buildWorkspace) remove() error {
+	if !w.isolated {
+		return nil
+	}
+	return os.RemoveAll(w.workDir)
+}
@@ -7,13 +7,32 @@ const (
 	JSONParamsKey       = "json-params"
 	JSONParamsBase64Key = "json-params-base64"
 
-	WorkflowKey = "workflow"
+	WorkflowKey    = "workflow"
+	PipelineKey    = "pipeline"
+	MaxParallelKey = "max-parallel"
+	ResumeKey      = "resume"
+	DryRunKey      = "dry-run"
+	TimeoutKey     = "timeout"
+
+	CancelGracePeriodKey = "cancel-grace-period"
+	StepLogDirKey        = "step-log-dir"
+	ReportKey            = "report"
+	OTLPEndpointKey      = "otlp-endpoint"
 
 	PatternKey        = "pattern"
 	PushBranchKey     = "push-branch"
 	PRSourceBranchKey = "pr-source-branch"
 	PRTargetBranchKey = "pr-target-branch"
 	PRReadyStateKey   = "pr-ready-state"
+	CommitMessageKey  = "commit-message"
+	ChangedFilesKey   = "changed-files"
+	PRLabelsKey       = "pr-labels"
+	PRCommentKey      = "pr-comment"
+	WebhookPayloadKey = "webhook-payload"
+	ProviderKey       = "provider"
+	FromGitKey        = "from-git"
+	BaseRefKey        = "base-ref"
+	ExplainKey        = "explain"
 
 	ConfigKey      = "config"
 	InventoryKey   = "inventory"
@@ -63,7 +82,16 @@ var (
 				cli.StringFlag{Name: PRTargetBranchKey, Usage: "Git pull request target branch name."},
 				cli.StringFlag{Name: PRReadyStateKey, Usage: "Git pull request ready state. Options: ready_for_review draft converted_to_ready_for_review"},
 				cli.StringFlag{Name: TagKey, Usage: "Git tag name."},
+				cli.StringFlag{Name: CommitMessageKey, Usage: "Git commit message."},
+				cli.StringSliceFlag{Name: ChangedFilesKey, Usage: "Path of a file changed by the push or pull request. Can be specified multiple times."},
+				cli.StringSliceFlag{Name: PRLabelsKey, Usage: "Git pull request label. Can be specified multiple times."},
+				cli.StringFlag{Name: PRCommentKey, Usage: "Git pull request comment."},
+				cli.StringFlag{Name: WebhookPayloadKey, Usage: "Path of a git provider webhook payload to derive the trigger params from. Trigger param flags take precedence. GitHub issue_comment payloads don't contain the pull request branches, set them with the --" + PRSourceBranchKey + " and --" + PRTargetBranchKey + " flags."},
+				cli.StringFlag{Name: ProviderKey, Usage: "Git provider of the webhook payload. Options: github gitlab bitbucket"},
+				cli.BoolFlag{Name: FromGitKey, Usage: "Derive the trigger params from the git repository of the current directory. Trigger param flags take precedence."},
+				cli.StringFlag{Name: BaseRefKey, Usage: "Git ref to diff HEAD against for the changed files, used with --from-git. Defaults to the parent of HEAD."},
 
+				cli.BoolFlag{Name: ExplainKey, Usage: "Print every evaluated trigger item and why it matched or didn't match."},
 				cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: json, yml."},
 
 				// cli params used in CI mode
@@ -115,5 +143,8 @@ var (
 		pluginCommand,
 		envmanCommand,
 		mergeConfigCommand,
+		migrateCommand,
+		schemaCommand,
+		lintCommand,
 	}
 )
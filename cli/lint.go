@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configlint"
+	"github.com/bitrise-io/bitrise/configmerge"
+	"github.com/bitrise-io/bitrise/log"
+	"github.com/bitrise-io/bitrise/output"
+	stepmanCLI "github.com/bitrise-io/stepman/cli"
+	"github.com/bitrise-io/stepman/stepman"
+	"github.com/urfave/cli"
+)
+
+const (
+	fixKey                = "fix"
+	lintConfigKey         = "lint-config"
+	defaultLintConfigName = ".bitrise-lint.yml"
+	formatSARIF           = "sarif"
+)
+
+var lintCommand = cli.Command{
+	Name:      "lint",
+	Usage:     "Checks the bitrise.yml against a set of conventions, the rules can be configured in a .bitrise-lint.yml.",
+	ArgsUsage: "args[0]: By default, the command lints the bitrise.yml in the current directory, custom path can be specified as an argument.",
+	Action:    lint,
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: OuputFormatKey, Usage: "Output format. Accepted: raw (default), json, sarif."},
+		cli.BoolFlag{Name: fixKey, Usage: "Fix the issues of the fixable rules in place."},
+		cli.StringFlag{Name: lintConfigKey, Usage: "Path of the lint config, defaults to the " + defaultLintConfigName + " next to the bitrise.yml."},
+		cli.StringFlag{Name: InventoryKey, Usage: "Path of the secrets (inventory) file, used to check the referenced secrets."},
+		cli.BoolFlag{Name: pinStepVersionsKey, Usage: "Resolve the latest major version of the not pinned steps from the StepLib when fixing them."},
+	},
+}
+
+func lint(c *cli.Context) error {
+	configPth := "bitrise.yml"
+	if c.Args().Present() {
+		configPth = c.Args().First()
+	}
+
+	format := c.String(OuputFormatKey)
+	switch format {
+	case "":
+		format = output.FormatRaw
+	case output.FormatRaw, output.FormatJSON, formatSARIF:
+	default:
+		return fmt.Errorf("invalid format (%s), valid formats are: raw, json and sarif", format)
+	}
+
+	opts, err := lintOptions(c, configPth)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(configPth)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	content, err := os.ReadFile(configPth)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	isModularConfig, err := configmerge.IsModularConfig(configPth)
+	if err != nil {
+		return fmt.Errorf("failed to check if the config is modular: %w", err)
+	}
+	if isModularConfig {
+		if opts.Fix {
+			return errors.New("fixing modular configs is not supported, fix the config modules one by one")
+		}
+
+		merger, err := createDefaultMerger()
+		if err != nil {
+			return fmt.Errorf("failed to create config module merger: %w", err)
+		}
+		if err := useConfigLock(merger, configPth); err != nil {
+			return err
+		}
+		mergedConfigContent, _, err := merger.MergeConfig(configPth)
+		if err != nil {
+			return fmt.Errorf("failed to merge config (%s): %w", configPth, err)
+		}
+		content = []byte(mergedConfigContent)
+	}
+
+	if _, _, err := bitrise.ConfigModelFromYAMLBytes(content); err != nil {
+		return fmt.Errorf("config (%s) is not valid: %w", configPth, err)
+	}
+
+	result, err := configlint.Lint(content, opts)
+	if err != nil {
+		return fmt.Errorf("failed to lint config: %w", err)
+	}
+	if isModularConfig {
+		// The positions point to the merged config
+		for i := range result.Issues {
+			result.Issues[i].Line, result.Issues[i].Column = 0, 0
+		}
+	}
+
+	if opts.Fix && string(result.Content) != string(content) {
+		if err := os.WriteFile(configPth, result.Content, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+	}
+
+	if err := printLintResult(result, configPth, format); err != nil {
+		return err
+	}
+
+	if result.HasErrors() {
+		os.Exit(1)
+	}
+	return nil
+}
+
+func lintOptions(c *cli.Context, configPth string) (configlint.Options, error) {
+	opts := configlint.Options{Fix: c.Bool(fixKey), StepInputResolver: stepLibStepInputResolver{}}
+	if c.Bool(pinStepVersionsKey) {
+		opts.StepVersionResolver = stepLibStepVersionResolver{}
+	}
+
+	lintConfigPth := c.String(lintConfigKey)
+	if lintConfigPth == "" {
+		defaultPth := filepath.Join(filepath.Dir(configPth), defaultLintConfigName)
+		if _, err := os.Stat(defaultPth); err == nil {
+			lintConfigPth = defaultPth
+		}
+	}
+	if lintConfigPth != "" {
+		config, err := configlint.ReadConfig(lintConfigPth)
+		if err != nil {
+			return configlint.Options{}, err
+		}
+		opts.Config = config
+	}
+
+	inventoryPth, err := GetInventoryFilePath(c.String(InventoryKey))
+	if err != nil {
+		return configlint.Options{}, fmt.Errorf("failed to get secrets path: %w", err)
+	}
+	if inventoryPth != "" {
+		inventory, err := CreateInventoryFromCLIParams("", inventoryPth)
+		if err != nil {
+			return configlint.Options{}, fmt.Errorf("failed to read secrets: %w", err)
+		}
+
+		opts.Secrets = []string{}
+		for _, env := range inventory {
+			key, _, err := env.GetKeyValuePair()
+			if err != nil {
+				return configlint.Options{}, fmt.Errorf("invalid secret: %w", err)
+			}
+			opts.Secrets = append(opts.Secrets, key)
+		}
+	}
+
+	return opts, nil
+}
+
+// stepLibStepInputResolver resolves the step inputs from the local copy of the StepLib, it doesn't set up missing StepLibs.
+type stepLibStepInputResolver struct{}
+
+// SensitiveInputs ...
+func (stepLibStepInputResolver) SensitiveInputs(stepLibSource, stepID, version string) ([]string, error) {
+	if exist, err := stepman.RootExistForLibrary(stepLibSource); err != nil {
+		return nil, err
+	} else if !exist {
+		return nil, fmt.Errorf("StepLib (%s) is not set up", stepLibSource)
+	}
+
+	// The step.yml is only used to find more issues, the logs of the lookup would mix into the lint output
+	loggerOpts := log.GetGlobalLoggerOpts()
+	loggerOpts.Writer = io.Discard
+	stepInfo, err := stepmanCLI.QueryStepInfoFromLibrary(stepLibSource, stepID, version, log.NewLogger(loggerOpts))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, input := range stepInfo.Step.Inputs {
+		key, _, err := input.GetKeyValuePair()
+		if err != nil {
+			return nil, err
+		}
+		inputOpts, err := input.GetOptions()
+		if err != nil {
+			return nil, err
+		}
+		if inputOpts.IsSensitive != nil && *inputOpts.IsSensitive {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func printLintResult(result configlint.Result, configPth, format string) error {
+	switch format {
+	case output.FormatJSON:
+		content, err := json.Marshal(map[string]interface{}{"issues": result.Issues})
+		if err != nil {
+			return fmt.Errorf("failed to serialize issues: %w", err)
+		}
+		log.Print(string(content))
+	case formatSARIF:
+		uri := configPth
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, configPth); err == nil && filepath.IsAbs(configPth) {
+				uri = rel
+			}
+		}
+		content, err := configlint.SARIF(result.Issues, filepath.ToSlash(uri))
+		if err != nil {
+			return fmt.Errorf("failed to serialize issues: %w", err)
+		}
+		log.Print(string(content))
+	default:
+		fixed := 0
+		for _, issue := range result.Issues {
+			if issue.Fixed {
+				fixed++
+				continue
+			}
+			log.Print(formatLintIssue(configPth, issue))
+		}
+
+		remaining := len(result.Issues) - fixed
+		if fixed > 0 {
+			log.Donef("%d issue(s) fixed", fixed)
+		}
+		if remaining > 0 {
+			log.Warnf("%d issue(s) found in %s", remaining, configPth)
+		} else if fixed == 0 {
+			log.Donef("No issues found in %s", configPth)
+		}
+	}
+	return nil
+}
+
+func formatLintIssue(configPth string, issue configlint.Issue) string {
+	location := configPth
+	if issue.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", configPth, issue.Line, issue.Column)
+	}
+	message := issue.Message
+	if issue.Path != "" {
+		message = issue.Path + ": " + message
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", location, issue.Severity, message, issue.Rule)
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/bitrise-io/bitrise/configmerge"
+	"github.com/bitrise-io/bitrise/configs"
 	"github.com/bitrise-io/bitrise/log"
 	"github.com/bitrise-io/bitrise/models"
 	"github.com/bitrise-io/go-utils/fileutil"
@@ -23,9 +24,27 @@ var mergeConfigCommand = cli.Command{
 			Name:  "output, o",
 			Usage: "Output directory for the merged config file (bitrise.yml) and related config file tree (config_tree.json).",
 		},
+		cli.BoolFlag{
+			Name:  lockKey,
+			Usage: "Write the resolved commit and content hash of the includes to " + configmerge.LockFileName + ", an existing lock file is honoured.",
+		},
+		cli.BoolFlag{
+			Name:  updateLockKey,
+			Usage: "Resolve the includes ignoring the existing " + configmerge.LockFileName + " and update it.",
+		},
+		cli.StringFlag{
+			Name:  explainKey,
+			Usage: "Print which config file and line set the value at the given path of the merged config (like workflows.primary.envs[0]), instead of the merged config.",
+		},
 	},
 }
 
+const (
+	lockKey       = "lock"
+	updateLockKey = "update-lock"
+	explainKey    = "explain"
+)
+
 func mergeConfig(c *cli.Context) error {
 	var configPth string
 	if c.Args().Present() {
@@ -39,11 +58,33 @@ func mergeConfig(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	if !c.Bool(updateLockKey) {
+		if err := useConfigLock(merger, configPth); err != nil {
+			return err
+		}
+	}
 	mergedConfigContent, configFileTree, err := merger.MergeConfig(configPth)
 	if err != nil {
 		return fmt.Errorf("failed to merge config: %w", err)
 	}
 
+	if c.Bool(lockKey) || c.Bool(updateLockKey) {
+		lockPth := configmerge.LockPath(configPth)
+		if err := merger.ResolvedLock().Write(lockPth); err != nil {
+			return fmt.Errorf("failed to write lock file: %w", err)
+		}
+		log.Donef("Lock file written to %s", lockPth)
+	}
+
+	if explainPth := c.String(explainKey); explainPth != "" {
+		explanation, err := explainConfigPath(merger.Provenance(), explainPth)
+		if err != nil {
+			return err
+		}
+		log.Print(explanation)
+		return nil
+	}
+
 	if outputDir == "" {
 		if err := printOutputFiles(mergedConfigContent, *configFileTree); err != nil {
 			return fmt.Errorf("failed to print output files: %w", err)
@@ -60,7 +101,7 @@ func mergeConfig(c *cli.Context) error {
 func createDefaultMerger() (*configmerge.Merger, error) {
 	opts := log.GetGlobalLoggerOpts()
 	logger := log.NewLogger(opts)
-	configReader, err := configmerge.NewConfigReader(logger)
+	configReader, err := configmerge.NewConfigReader(configs.GetConfigIncludeCacheDirPath(), isSteplibOfflineMode(), logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create config module reader: %w", err)
 	}
@@ -68,6 +109,32 @@ func createDefaultMerger() (*configmerge.Merger, error) {
 	return &merger, nil
 }
 
+// useConfigLock makes the merger honour the lock file of the config, if there is one.
+func useConfigLock(merger *configmerge.Merger, configPth string) error {
+	lock, err := configmerge.ReadLock(configmerge.LockPath(configPth))
+	if err != nil {
+		return fmt.Errorf("failed to read lock file: %w", err)
+	}
+	if lock != nil {
+		merger.UseLock(*lock)
+	}
+	return nil
+}
+
+// explainConfigPath describes the origin of the value at the given path of the merged config.
+func explainConfigPath(provenance models.Provenance, pth string) (string, error) {
+	origin, ok := provenance[pth]
+	if !ok {
+		return "", fmt.Errorf("path (%s) is not found in the merged config", pth)
+	}
+
+	explanation := fmt.Sprintf("%s is set by %s", pth, origin)
+	for _, overridden := range origin.Overrides {
+		explanation += fmt.Sprintf("\n  overrides %s", overridden)
+	}
+	return explanation, nil
+}
+
 func printOutputFiles(mergedConfigContent string, configFileTree models.ConfigFileTreeModel) error {
 	log.Printf("config tree:")
 	configTreeBytes, err := json.MarshalIndent(configFileTree, "", "\t")
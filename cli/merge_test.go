@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainConfigPath(t *testing.T) {
+	provenance := models.Provenance{
+		"workflows.primary.envs[0].NAME": {File: "bitrise.yml", Line: 12, Overrides: []models.ConfigOrigin{{File: "repo:https://github.com/bitrise-io/modules.git,envs.yml@branch:main", Line: 3}}},
+	}
+
+	explanation, err := explainConfigPath(provenance, "workflows.primary.envs[0].NAME")
+	require.NoError(t, err)
+	require.Equal(t, "workflows.primary.envs[0].NAME is set by bitrise.yml:12\n"+
+		"  overrides repo:https://github.com/bitrise-io/modules.git,envs.yml@branch:main:3", explanation)
+
+	_, err = explainConfigPath(provenance, "workflows.primary.envs[1]")
+	require.EqualError(t, err, "path (workflows.primary.envs[1]) is not found in the merged config")
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configmerge"
+	"github.com/bitrise-io/bitrise/configmigrate"
+	"github.com/bitrise-io/bitrise/log"
+	stepmanCLI "github.com/bitrise-io/stepman/cli"
+	"github.com/urfave/cli"
+)
+
+const pinStepVersionsKey = "pin-step-versions"
+
+var migrateCommand = cli.Command{
+	Name:      "migrate",
+	Usage:     "Rewrites the deprecated constructs of a bitrise.yml in place, preserving its comments and key order.",
+	ArgsUsage: "args[0]: By default, the command migrates the bitrise.yml in the current directory, custom path can be specified as an argument.",
+	Action:    migrate,
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: pinStepVersionsKey, Usage: "Pin the StepLib steps without a fixed version (e.g. git-clone@8) to their latest matching version."},
+		cli.BoolFlag{Name: DryRunKey, Usage: "Print the migrated config instead of writing it."},
+	},
+}
+
+func migrate(c *cli.Context) error {
+	configPth := "bitrise.yml"
+	if c.Args().Present() {
+		configPth = c.Args().First()
+	}
+
+	info, err := os.Stat(configPth)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	content, err := os.ReadFile(configPth)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var opts configmigrate.Options
+	if c.Bool(pinStepVersionsKey) {
+		opts.StepVersionResolver = stepLibStepVersionResolver{}
+	}
+
+	result, err := configmigrate.Migrate(content, opts)
+	if err != nil {
+		return fmt.Errorf("failed to migrate config: %w", err)
+	}
+
+	isModularConfig, err := configmerge.IsModularConfig(configPth)
+	if err != nil {
+		return fmt.Errorf("failed to check if the config is modular: %w", err)
+	}
+	if isModularConfig {
+		log.Warnf("The config includes other config modules, validate the merged config with 'bitrise validate'.")
+	} else {
+		_, warnings, err := bitrise.ConfigModelFromYAMLBytes(result.Content)
+		for _, warning := range warnings {
+			log.Warnf("warning: %s", warning)
+		}
+		if err != nil {
+			return fmt.Errorf("migrated config is not valid: %w", err)
+		}
+	}
+
+	if len(result.Changes) == 0 {
+		log.Donef("Config (%s) is up to date", configPth)
+		return nil
+	}
+
+	log.Infof("Changes:")
+	for _, change := range result.Changes {
+		log.Printf("- %s", change)
+	}
+
+	if c.Bool(DryRunKey) {
+		log.Print()
+		log.Print(string(result.Content))
+		return nil
+	}
+
+	if err := os.WriteFile(configPth, result.Content, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	log.Donef("Config (%s) migrated", configPth)
+
+	return nil
+}
+
+// stepLibStepVersionResolver resolves the step versions from the local copy of the StepLib.
+type stepLibStepVersionResolver struct{}
+
+// ResolveVersion ...
+func (stepLibStepVersionResolver) ResolveVersion(stepLibSource, stepID, versionConstraint string) (string, error) {
+	logger := log.NewLogger(log.GetGlobalLoggerOpts())
+	if err := stepmanCLI.Setup(stepLibSource, "", logger); err != nil {
+		return "", fmt.Errorf("failed to setup StepLib (%s): %w", stepLibSource, err)
+	}
+
+	stepInfo, err := stepmanCLI.QueryStepInfoFromLibrary(stepLibSource, stepID, versionConstraint, logger)
+	if err != nil {
+		return "", err
+	}
+	return stepInfo.Version, nil
+}
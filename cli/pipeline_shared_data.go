@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/log"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/tools"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pointers"
+)
+
+const sharedDataFileName = "shared_data.json"
+
+// workflowSharedData is the stored form of the outputs shared by a workflow.
+type workflowSharedData struct {
+	Envs map[string]string `json:"envs,omitempty"`
+	// Paths maps the env keys to the stored files, relative to the workflow's shared data dir.
+	Paths map[string]string `json:"paths,omitempty"`
+}
+
+// pipelineSharedDataStore is the local equivalent of the pipeline intermediate files:
+// it stores the envs and files shared by the workflows of a pipeline run, and restores them for the dependent workflows.
+type pipelineSharedDataStore struct {
+	dir string
+}
+
+func newPipelineSharedDataStore(pipelineID string) (pipelineSharedDataStore, error) {
+	dir, err := os.MkdirTemp(configs.BitriseWorkDirPath, fmt.Sprintf("pipeline-%s-", pipelineID))
+	if err != nil {
+		return pipelineSharedDataStore{}, fmt.Errorf("failed to create pipeline shared data dir: %w", err)
+	}
+	return pipelineSharedDataStore{dir: dir}, nil
+}
+
+func (s pipelineSharedDataStore) remove() error {
+	return os.RemoveAll(s.dir)
+}
+
+// save stores the shared envs and files of the workflow, based on the environments it finished with.
+func (s pipelineSharedDataStore) save(workflowID string, share models.WorkflowShareModel, environments []envmanModels.EnvironmentItemModel) error {
+	envs, err := tools.ExpandEnvItems(environments, os.Environ())
+	if err != nil {
+		return fmt.Errorf("failed to expand envs: %w", err)
+	}
+
+	workflowDir := filepath.Join(s.dir, "workflows", workflowID)
+	if err := os.RemoveAll(workflowDir); err != nil {
+		return err
+	}
+
+	data := workflowSharedData{Envs: map[string]string{}, Paths: map[string]string{}}
+
+	for _, key := range share.Envs {
+		value, ok := envs[key]
+		if !ok {
+			log.Warnf("Shared env (%s) of workflow (%s) is not set", key, workflowID)
+			continue
+		}
+		data.Envs[key] = value
+	}
+
+	for _, key := range share.Paths {
+		pth := envs[key]
+		if pth == "" {
+			log.Warnf("Shared path env (%s) of workflow (%s) is not set", key, workflowID)
+			continue
+		}
+
+		relPth := filepath.Join("files", key, filepath.Base(pth))
+		if err := copyPath(pth, filepath.Join(workflowDir, relPth)); err != nil {
+			return fmt.Errorf("failed to store shared path (%s): %w", key, err)
+		}
+		data.Paths[key] = relPth
+	}
+
+	content, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(workflowDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(workflowDir, sharedDataFileName), content, 0644)
+}
+
+// restore returns the envs shared by the upstream workflows, in the order of the workflows.
+// Shared files are copied to a dir owned by the restoring workflow, the returned envs point to the copies.
+func (s pipelineSharedDataStore) restore(workflowID string, upstreamWorkflowIDs []string) ([]envmanModels.EnvironmentItemModel, error) {
+	var environments []envmanModels.EnvironmentItemModel
+
+	for _, upstreamWorkflowID := range upstreamWorkflowIDs {
+		upstreamDir := filepath.Join(s.dir, "workflows", upstreamWorkflowID)
+
+		content, err := os.ReadFile(filepath.Join(upstreamDir, sharedDataFileName))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var data workflowSharedData
+		if err := json.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse shared data of workflow (%s): %w", upstreamWorkflowID, err)
+		}
+
+		for _, key := range sortedKeys(data.Envs) {
+			environments = append(environments, newSharedEnvironment(key, data.Envs[key]))
+		}
+
+		for _, key := range sortedKeys(data.Paths) {
+			restoredPth := filepath.Join(s.dir, "restored", workflowID, upstreamWorkflowID, data.Paths[key])
+			if err := os.RemoveAll(restoredPth); err != nil {
+				return nil, err
+			}
+			if err := copyPath(filepath.Join(upstreamDir, data.Paths[key]), restoredPth); err != nil {
+				return nil, fmt.Errorf("failed to restore shared path (%s) of workflow (%s): %w", key, upstreamWorkflowID, err)
+			}
+			environments = append(environments, newSharedEnvironment(key, restoredPth))
+		}
+	}
+
+	return environments, nil
+}
+
+func newSharedEnvironment(key, value string) envmanModels.EnvironmentItemModel {
+	// shared values are already expanded
+	return envmanModels.EnvironmentItemModel{
+		key:    value,
+		"opts": envmanModels.EnvironmentItemOptionsModel{IsExpand: pointers.NewBoolPtr(false)},
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// copyPath copies a file or a directory recursively, keeping the file modes.
+func copyPath(src, dst string) error {
+	return filepath.WalkDir(src, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, pth)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", pth)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		in, err := os.Open(pth)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := in.Close(); err != nil {
+				log.Warnf("Failed to close %s: %s", pth, err)
+			}
+		}()
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			_ = out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
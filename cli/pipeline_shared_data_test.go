@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelineSharedDataStore(t *testing.T) {
+	require.NoError(t, configs.InitPaths())
+
+	store, err := newPipelineSharedDataStore("test")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, store.remove())
+	}()
+
+	outputDir := t.TempDir()
+	ipaPth := filepath.Join(outputDir, "app.ipa")
+	require.NoError(t, os.WriteFile(ipaPth, []byte("ipa"), 0644))
+	reportsDir := filepath.Join(outputDir, "reports")
+	require.NoError(t, os.MkdirAll(filepath.Join(reportsDir, "unit"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(reportsDir, "unit", "report.xml"), []byte("<xml/>"), 0644))
+
+	share := models.WorkflowShareModel{
+		Envs:  []string{"BUILD_NUMBER", "NOT_SET"},
+		Paths: []string{"IPA_PATH", "REPORTS_DIR"},
+	}
+	environments := []envmanModels.EnvironmentItemModel{
+		{"BUILD_NUMBER": "1"},
+		{"OUTPUT_DIR": outputDir},
+		{"IPA_PATH": "$OUTPUT_DIR/app.ipa", "opts": envmanModels.EnvironmentItemOptionsModel{IsExpand: pointers.NewBoolPtr(true)}},
+		{"REPORTS_DIR": reportsDir},
+		{"BUILD_NUMBER": "2"},
+		{"NOT_SHARED": "value"},
+	}
+	require.NoError(t, store.save("build", share, environments))
+
+	restored, err := store.restore("deploy", []string{"skipped", "build"})
+	require.NoError(t, err)
+
+	restoredEnvs := map[string]string{}
+	for _, env := range restored {
+		key, value, err := env.GetKeyValuePair()
+		require.NoError(t, err)
+		restoredEnvs[key] = value
+	}
+	require.Equal(t, 3, len(restoredEnvs))
+	require.Equal(t, "2", restoredEnvs["BUILD_NUMBER"])
+
+	content, err := os.ReadFile(restoredEnvs["IPA_PATH"])
+	require.NoError(t, err)
+	require.Equal(t, "ipa", string(content))
+	require.NotEqual(t, ipaPth, restoredEnvs["IPA_PATH"])
+
+	content, err = os.ReadFile(filepath.Join(restoredEnvs["REPORTS_DIR"], "unit", "report.xml"))
+	require.NoError(t, err)
+	require.Equal(t, "<xml/>", string(content))
+}
+
+func TestUpstreamWorkflowIDs(t *testing.T) {
+	dagStage := models.PipelineStageRunPlan{Workflows: []models.PipelineWorkflowRunPlan{
+		{WorkflowID: "a"},
+		{WorkflowID: "b", DependsOn: []string{"a"}},
+		{WorkflowID: "c", DependsOn: []string{"a"}},
+		{WorkflowID: "d", DependsOn: []string{"b"}},
+	}}
+	require.Equal(t, []string{"a", "b"}, upstreamWorkflowIDs(dagStage, "d", models.PipelineRunResultsModel{}))
+	require.Equal(t, []string(nil), upstreamWorkflowIDs(dagStage, "a", models.PipelineRunResultsModel{}))
+
+	stage := models.PipelineStageRunPlan{StageID: "s2", Workflows: []models.PipelineWorkflowRunPlan{{WorkflowID: "c"}}}
+	previousResults := models.PipelineRunResultsModel{Stages: []models.StageRunResultsModel{
+		{StageID: "s1", Workflows: []models.WorkflowRunResultsModel{{WorkflowID: "a"}, {WorkflowID: "b"}}},
+	}}
+	require.Equal(t, []string{"a", "b"}, upstreamWorkflowIDs(stage, "c", previousResults))
+}
@@ -1,11 +1,11 @@
 package cli
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"runtime"
 	"sort"
 	"strings"
 	"syscall"
@@ -15,10 +15,14 @@ import (
 	"github.com/bitrise-io/bitrise/bitrise"
 	"github.com/bitrise-io/bitrise/cli/docker"
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
 	"github.com/bitrise-io/bitrise/log"
 	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
 	"github.com/bitrise-io/bitrise/plugins"
+	"github.com/bitrise-io/bitrise/report"
 	"github.com/bitrise-io/bitrise/tools"
+	"github.com/bitrise-io/bitrise/tracing"
 	"github.com/bitrise-io/bitrise/version"
 	envmanModels "github.com/bitrise-io/envman/models"
 	"github.com/bitrise-io/go-utils/colorstring"
@@ -39,6 +43,8 @@ const (
 )
 
 var errWorkflowNotSpecified = errors.New("workflow not specified")
+var errWorkflowAndPipelineSpecified = errors.New("both workflow and pipeline specified")
+var errResumePipelineSpecified = errors.New("resuming a pipeline is not supported")
 var errUtilityWorkflowSpecified = errors.New("utility workflow specified")
 var errWorkflowRunFailed = errors.New("workflow run failed")
 
@@ -46,17 +52,46 @@ type RunConfig struct {
 	Modes    models.WorkflowRunModes
 	Config   models.BitriseDataModel
 	Workflow string
+	Pipeline string
 	Secrets  []envmanModels.EnvironmentItemModel
+
+	// MaxParallel limits the number of concurrently running workflows of a DAG pipeline.
+	MaxParallel int
+	// Resume restarts the previous failed build of the workflow from the failed step.
+	Resume bool
+	// DryRun prints the resolved execution plan instead of running it, in the DryRunFormat output format.
+	DryRun       bool
+	DryRunFormat string
+	// Timeout limits the run time of the whole build, 0 means no limit.
+	Timeout time.Duration
+	// CancelGracePeriod is the time the running step has to exit after the build is cancelled, before it gets killed.
+	CancelGracePeriod time.Duration
+	// StepLogDir is the dir of the step log files, defaults to $BITRISE_DEPLOY_DIR/logs.
+	StepLogDir string
+	// Reports are exported from the results of the finished build.
+	Reports []report.Target
+	// OTLPEndpoint is the OpenTelemetry collector endpoint the trace of the build is exported to, tracing is disabled if empty.
+	OTLPEndpoint string
 }
 
 var runCommand = cli.Command{
 	Name:    "run",
 	Aliases: []string{"r"},
-	Usage:   "Runs a specified Workflow.",
+	Usage:   "Runs a specified Workflow or Pipeline.",
 	Action:  run,
 	Flags: []cli.Flag{
 		// cli params
 		cli.StringFlag{Name: WorkflowKey, Usage: "workflow id to run."},
+		cli.StringFlag{Name: PipelineKey, Usage: "pipeline id to run."},
+		cli.BoolFlag{Name: ResumeKey, Usage: "Resume the previous failed build of the workflow from the failed step."},
+		cli.BoolFlag{Name: DryRunKey, Usage: "Print the resolved execution plan without running it."},
+		cli.StringFlag{Name: OuputFormatKey, Usage: "Output format of the dry run. Accepted: raw (default), json, yml."},
+		cli.IntFlag{Name: TimeoutKey, Usage: "Timeout of the build in seconds, the running step is aborted when it expires. Can also be set with the " + configs.BuildTimeoutEnvKey + " env."},
+		cli.IntFlag{Name: CancelGracePeriodKey, Usage: "Seconds the running step has to exit after the build is cancelled, before it gets killed. Can also be set with the " + configs.CancelGracePeriodEnvKey + " env. Defaults to 10."},
+		cli.StringFlag{Name: StepLogDirKey, Usage: "Directory of the step log files. Can also be set with the " + configs.StepLogDirEnvKey + " env. Defaults to $" + configs.BitriseDeployDirEnvKey + "/logs."},
+		cli.StringSliceFlag{Name: ReportKey, Usage: "Export a report of the build, in <format>=<path> form. Accepted formats: junit, json. Can be specified multiple times."},
+		cli.StringFlag{Name: OTLPEndpointKey, Usage: "OpenTelemetry collector endpoint (OTLP/HTTP) to export the trace of the build to. Can also be set with the " + configs.OTLPEndpointEnvKey + " env."},
+		cli.IntFlag{Name: MaxParallelKey, Usage: "Maximum number of workflows of a DAG pipeline running in parallel. Defaults to the number of CPUs."},
 		cli.StringFlag{Name: ConfigKey + ", " + configShortKey, Usage: "Path where the workflow config file is located."},
 		cli.StringFlag{Name: InventoryKey + ", " + inventoryShortKey, Usage: "Path of the inventory file."},
 		cli.BoolFlag{Name: secretFilteringFlag, Usage: "Hide secret values from the log."},
@@ -76,10 +111,6 @@ func run(c *cli.Context) error {
 	signalInterruptChan := make(chan os.Signal, 1)
 	signal.Notify(signalInterruptChan, syscall.SIGINT, syscall.SIGTERM)
 
-	shouldWaitForCleanup := false
-	cleanupSynchronCtx, cleanupSynchronCancelFunc := context.WithCancel(context.Background())
-	defer cleanupSynchronCancelFunc()
-
 	config, err := processArgs(c)
 	if err != nil {
 		if err == errWorkflowNotSpecified {
@@ -87,6 +118,10 @@ func run(c *cli.Context) error {
 				printAvailableWorkflows(config.Config)
 			}
 			failf("No workflow specified")
+		} else if err == errWorkflowAndPipelineSpecified {
+			failf("Either a workflow or a pipeline can be specified, not both")
+		} else if err == errResumePipelineSpecified {
+			failf("Only workflow builds can be resumed, pipelines are not supported")
 		} else if err == errUtilityWorkflowSpecified {
 			printAboutUtilityWorkflowsText()
 			failf("Utility workflows can't be triggered directly")
@@ -109,19 +144,20 @@ func run(c *cli.Context) error {
 
 	go func() {
 		<-signalInterruptChan
-		shouldWaitForCleanup = true
 		log.Info("Cancelling bitrise run...")
-		if err := runner.dockerManager.DestroyAllContainers(); err != nil {
-			log.Warnf("Failed to destroy all containers: %s", err)
-		}
-		cleanupSynchronCancelFunc()
+		runner.cancellation.cancel()
+
+		<-signalInterruptChan
+		log.Warn("Cancelling bitrise run without waiting for the steps...")
+		runner.destroyAllContainers()
+		os.Exit(exitcode.CLIFailed)
 	}()
 
 	exitCode, err := runner.RunWorkflowsWithSetupAndCheckForUpdate()
+	if runner.cancellation.isCancelled() {
+		runner.destroyAllContainers()
+	}
 	if err != nil {
-		if shouldWaitForCleanup {
-			<-cleanupSynchronCtx.Done()
-		}
 		if err == errWorkflowRunFailed {
 			msg := createWorkflowRunStatusMessage(exitCode)
 			printWorkflowRunStatusMessage(msg)
@@ -133,10 +169,6 @@ func run(c *cli.Context) error {
 	msg := createWorkflowRunStatusMessage(0)
 	printWorkflowRunStatusMessage(msg)
 
-	if shouldWaitForCleanup {
-		<-cleanupSynchronCtx.Done()
-	}
-
 	os.Exit(0)
 
 	return nil
@@ -179,6 +211,21 @@ type WorkflowRunner struct {
 	// agentConfig is only non-nil if the CLI is configured to run in agent mode
 	agentConfig   *configs.AgentConfig
 	dockerManager DockerManager
+
+	// workspace is only non-nil if the build runs in parallel with other builds
+	workspace *buildWorkspace
+	// sharedData is only non-nil while running a pipeline
+	sharedData *pipelineSharedDataStore
+	// runState is only non-nil while running a workflow build, which can be resumed
+	runState *runStateRecorder
+	// timeout is only non-nil if the build or the running workflow has a timeout
+	timeout *runTimeout
+	// cancellation is shared by every copy of the runner
+	cancellation *buildCancellation
+	// stepLogDir is only set while running a build, which writes step log files
+	stepLogDir string
+	// trace is only non-nil while running a build, which is exported as an OpenTelemetry trace
+	trace *tracing.BuildTrace
 }
 
 func NewWorkflowRunner(config RunConfig, agentConfig *configs.AgentConfig) WorkflowRunner {
@@ -189,16 +236,35 @@ func NewWorkflowRunner(config RunConfig, agentConfig *configs.AgentConfig) Workf
 		config:        config,
 		dockerManager: docker.NewContainerManager(logger, stepSecretValues),
 		agentConfig:   agentConfig,
+		cancellation:  newBuildCancellation(config.CancelGracePeriod),
 	}
 }
 
-func (r WorkflowRunner) RunWorkflowsWithSetupAndCheckForUpdate() (int, error) {
-	if r.config.Workflow == "" {
-		return 1, errWorkflowNotSpecified
+func (r WorkflowRunner) destroyAllContainers() {
+	if err := r.dockerManager.DestroyAllContainers(); err != nil {
+		log.Warnf("Failed to destroy all containers: %s", err)
 	}
-	_, exist := r.config.Config.Workflows[r.config.Workflow]
-	if !exist {
-		return 1, fmt.Errorf("specified Workflow (%s) does not exist", r.config.Workflow)
+}
+
+func (r WorkflowRunner) currentWorkspace() buildWorkspace {
+	if r.workspace != nil {
+		return *r.workspace
+	}
+	return defaultBuildWorkspace()
+}
+
+func (r WorkflowRunner) RunWorkflowsWithSetupAndCheckForUpdate() (int, error) {
+	if r.config.Pipeline != "" {
+		if _, exist := r.config.Config.Pipelines[r.config.Pipeline]; !exist {
+			return 1, fmt.Errorf("specified Pipeline (%s) does not exist", r.config.Pipeline)
+		}
+	} else {
+		if r.config.Workflow == "" {
+			return 1, errWorkflowNotSpecified
+		}
+		if _, exist := r.config.Config.Workflows[r.config.Workflow]; !exist {
+			return 1, fmt.Errorf("specified Workflow (%s) does not exist", r.config.Workflow)
+		}
 	}
 
 	if err := bitrise.RunSetupIfNeeded(r.logger); err != nil {
@@ -222,10 +288,30 @@ func (r WorkflowRunner) RunWorkflowsWithSetupAndCheckForUpdate() (int, error) {
 		}()
 	}
 
-	if buildRunResults, err := r.runWorkflows(globalTracker); err != nil {
-		return 1, fmt.Errorf("failed to run workflow: %s", err)
-	} else if buildRunResults.IsBuildFailed() {
-		return buildRunResults.ExitCode(), errWorkflowRunFailed
+	if r.config.DryRun {
+		return r.dryRun()
+	}
+
+	r.timeout = newBuildTimeout(r.config.Timeout)
+
+	if r.config.Pipeline != "" {
+		pipelineRunResults, err := r.runPipeline(globalTracker)
+		if err != nil {
+			return 1, fmt.Errorf("failed to run pipeline: %s", err)
+		}
+		r.writeReports(pipelineRunResults.BuildRunResults())
+		if pipelineRunResults.IsBuildFailed() {
+			return pipelineRunResults.ExitCode(), errWorkflowRunFailed
+		}
+	} else {
+		buildRunResults, err := r.runWorkflows(globalTracker)
+		if err != nil {
+			return 1, fmt.Errorf("failed to run workflow: %s", err)
+		}
+		r.writeReports([]models.BuildRunResultsModel{buildRunResults})
+		if buildRunResults.IsBuildFailed() {
+			return buildRunResults.ExitCode(), errWorkflowRunFailed
+		}
 	}
 
 	if err := checkUpdate(); err != nil {
@@ -235,47 +321,45 @@ func (r WorkflowRunner) RunWorkflowsWithSetupAndCheckForUpdate() (int, error) {
 	return 0, nil
 }
 
+func (r WorkflowRunner) writeReports(builds []models.BuildRunResultsModel) {
+	for _, target := range r.config.Reports {
+		if err := report.Write(target, builds); err != nil {
+			log.Errorf("Failed to write %s report: %s", target.Format, err)
+			continue
+		}
+		log.Printf("%s report written to: %s", target.Format, target.Path)
+	}
+}
+
 func (r WorkflowRunner) runWorkflows(tracker analytics.Tracker) (models.BuildRunResultsModel, error) {
-	startTime := time.Now()
+	if err := r.prepareRun(); err != nil {
+		return models.BuildRunResultsModel{}, err
+	}
+
+	buildRunResults, _, err := r.runBuild(r.config.Workflow, nil, tracker)
+	return buildRunResults, err
+}
 
+// prepareRun sets up the process level state shared by every build of the invocation.
+func (r WorkflowRunner) prepareRun() error {
 	// Register run modes
 	if err := registerRunModes(r.config.Modes); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to register workflow run modes: %w", err)
-	}
-
-	targetWorkflow := r.config.Config.Workflows[r.config.Workflow]
-	if targetWorkflow.Title == "" {
-		targetWorkflow.Title = r.config.Workflow
+		return fmt.Errorf("failed to register workflow run modes: %w", err)
 	}
 
 	// Envman setup
 	if err := os.Setenv(configs.EnvstorePathEnvKey, configs.OutputEnvstorePath); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to set %s env: %w", configs.EnvstorePathEnvKey, err)
+		return fmt.Errorf("failed to set %s env: %w", configs.EnvstorePathEnvKey, err)
 	}
 
 	if err := os.Setenv(configs.FormattedOutputPathEnvKey, configs.FormattedOutputPath); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to set %s env: %w", configs.FormattedOutputPathEnvKey, err)
+		return fmt.Errorf("failed to set %s env: %w", configs.FormattedOutputPathEnvKey, err)
 	}
 
 	if err := tools.EnvmanInit(configs.OutputEnvstorePath, false); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to run envman init: %w", err)
+		return fmt.Errorf("failed to run envman init: %w", err)
 	}
 
-	// App level environment
-	environments := append(r.config.Secrets, r.config.Config.App.Environments...)
-
-	if err := os.Setenv("BITRISE_TRIGGERED_WORKFLOW_ID", r.config.Workflow); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to set BITRISE_TRIGGERED_WORKFLOW_ID env: %w", err)
-	}
-	if err := os.Setenv("BITRISE_TRIGGERED_WORKFLOW_TITLE", targetWorkflow.Title); err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to set BITRISE_TRIGGERED_WORKFLOW_TITLE env: %w", err)
-	}
-	if err := bitrise.SetBuildFailedEnv(false); err != nil {
-		log.Error("Failed to set Build Status envs")
-	}
-
-	environments = append(environments, targetWorkflow.Environments...)
-
 	// Bootstrap Toolkits
 	for _, aToolkit := range toolkits.AllSupportedToolkits(r.logger) {
 		toolkitName := aToolkit.ToolkitName()
@@ -284,11 +368,45 @@ func (r WorkflowRunner) runWorkflows(tracker analytics.Tracker) (models.BuildRun
 			// the toolkit's `PrepareForStepRun` can bootstrap for itself later if required
 			// or if the system installed version is not sufficient
 			if err := aToolkit.Bootstrap(); err != nil {
-				return models.BuildRunResultsModel{}, fmt.Errorf("failed to bootstrap %s toolkit: %w", toolkitName, err)
+				return fmt.Errorf("failed to bootstrap %s toolkit: %w", toolkitName, err)
 			}
 		}
 	}
 
+	return nil
+}
+
+// runBuild runs the given workflow together with its before and after run workflows.
+// The pipeline environments (shared by the upstream workflows of a pipeline) are added after the app level environment,
+// the returned environments contain the step outputs as well.
+func (r WorkflowRunner) runBuild(workflowID string, pipelineEnvironments []envmanModels.EnvironmentItemModel, tracker analytics.Tracker) (models.BuildRunResultsModel, []envmanModels.EnvironmentItemModel, error) {
+	startTime := time.Now()
+
+	targetWorkflow := r.config.Config.Workflows[workflowID]
+	if targetWorkflow.Title == "" {
+		targetWorkflow.Title = workflowID
+	}
+
+	// App level environment
+	environments := append([]envmanModels.EnvironmentItemModel{}, r.config.Secrets...)
+	environments = append(environments, r.config.Config.App.Environments...)
+	environments = append(environments, pipelineEnvironments...)
+
+	// Builds running in their own workspace pass these envs to the steps directly
+	if r.workspace == nil {
+		if err := os.Setenv("BITRISE_TRIGGERED_WORKFLOW_ID", workflowID); err != nil {
+			return models.BuildRunResultsModel{}, nil, fmt.Errorf("failed to set BITRISE_TRIGGERED_WORKFLOW_ID env: %w", err)
+		}
+		if err := os.Setenv("BITRISE_TRIGGERED_WORKFLOW_TITLE", targetWorkflow.Title); err != nil {
+			return models.BuildRunResultsModel{}, nil, fmt.Errorf("failed to set BITRISE_TRIGGERED_WORKFLOW_TITLE env: %w", err)
+		}
+		if err := bitrise.SetBuildFailedEnv(false); err != nil {
+			log.Error("Failed to set Build Status envs")
+		}
+	}
+
+	environments = append(environments, targetWorkflow.Environments...)
+
 	// Trigger WillStartRun
 	buildRunStartModel := models.BuildRunStartModel{
 		EventName:   string(plugins.WillStartRun),
@@ -301,23 +419,38 @@ func (r WorkflowRunner) runWorkflows(tracker analytics.Tracker) (models.BuildRun
 
 	// Prepare workflow run parameters
 	buildRunResults := models.BuildRunResultsModel{
-		WorkflowID:     r.config.Workflow,
+		WorkflowID:     workflowID,
 		StartTime:      startTime,
 		StepmanUpdates: map[string]int{},
 		ProjectType:    r.config.Config.ProjectType,
 	}
 
-	plan, err := createWorkflowRunPlan(r.config.Modes, r.config.Workflow, r.config.Config.Workflows, r.config.Config.StepBundles, func() string { return uuid.Must(uuid.NewV4()).String() })
+	plan, err := createWorkflowRunPlan(r.config.Modes, workflowID, r.config.Config.Workflows, r.config.Config.StepBundles, func() string { return uuid.Must(uuid.NewV4()).String() })
 	if err != nil {
-		return models.BuildRunResultsModel{}, fmt.Errorf("failed to create workflow execution plan: %w", err)
+		return models.BuildRunResultsModel{}, nil, fmt.Errorf("failed to create workflow execution plan: %w", err)
 	}
 	if len(plan.ExecutionPlan) < 1 {
-		return models.BuildRunResultsModel{}, fmt.Errorf("execution plan doesn't have any workflow to run")
+		return models.BuildRunResultsModel{}, nil, fmt.Errorf("execution plan doesn't have any workflow to run")
 	}
 
-	buildIDProperties := coreanalytics.Properties{analytics.BuildExecutionID: uuid.Must(uuid.NewV4()).String()}
+	// Pipeline workflows are not resumable
+	if r.sharedData == nil {
+		runState, err := r.startRunStateRecording(workflowID, plan)
+		if err != nil {
+			return models.BuildRunResultsModel{}, nil, fmt.Errorf("failed to resume build: %w", err)
+		}
+		r.runState = runState
+	}
+
+	r.stepLogDir = r.buildStepLogDir(workflowID)
 
-	log.PrintBitriseStartedEvent(plan)
+	buildExecutionID := uuid.Must(uuid.NewV4()).String()
+	buildIDProperties := coreanalytics.Properties{analytics.BuildExecutionID: buildExecutionID}
+	if r.config.OTLPEndpoint != "" {
+		r.trace = tracing.NewBuildTrace(buildExecutionID, workflowID, startTime)
+	}
+
+	r.logger.PrintBitriseStartedEvent(plan)
 
 	if tracker.IsTracking() {
 		log.Print()
@@ -337,14 +470,47 @@ func (r WorkflowRunner) runWorkflows(tracker analytics.Tracker) (models.BuildRun
 
 	// Build finished
 	bitrise.PrintSummary(buildRunResults)
+	r.runState.finish(buildRunResults)
+	r.exportTrace(r.trace.Finish(time.Now(), buildRunResults.BuildRunStatus()))
 
 	// Trigger WorkflowRunDidFinish
 	buildRunResults.EventName = string(plugins.DidFinishRun)
+	buildRunResults.Status = buildRunResults.BuildRunStatus()
 	if err := plugins.TriggerEvent(plugins.DidFinishRun, buildRunResults); err != nil {
 		log.Warnf("Failed to trigger WorkflowRunDidFinish: %s", err)
 	}
 
-	return buildRunResults, nil
+	return buildRunResults, environments, nil
+}
+
+func (r WorkflowRunner) exportTrace(spans []tracing.Span) {
+	if len(spans) == 0 {
+		return
+	}
+	if err := tracing.NewExporter(r.config.OTLPEndpoint).Export(spans); err != nil {
+		log.Warnf("Failed to export the trace of the build: %s", err)
+	}
+}
+
+func (r WorkflowRunner) startRunStateRecording(workflowID string, plan models.WorkflowRunPlan) (*runStateRecorder, error) {
+	pth := runStatePath(workflowID)
+
+	var previousState *models.WorkflowRunStateModel
+	if r.config.Resume {
+		state, err := readRunState(pth)
+		if err != nil {
+			return nil, err
+		}
+
+		if state == nil {
+			log.Warnf("No failed build of workflow (%s) found to resume, running it from the beginning", workflowID)
+		} else {
+			log.Infof("Resuming the failed build of workflow (%s), %d completed step(s) will be skipped", workflowID, len(state.CompletedSteps))
+		}
+		previousState = state
+	}
+
+	return newRunStateRecorder(pth, workflowID, plan, previousState)
 }
 
 func (r WorkflowRunner) ContainerDefinition(id string) *models.Container {
@@ -371,6 +537,21 @@ func processArgs(c *cli.Context) (*RunConfig, error) {
 	if workflowToRunID == "" && len(c.Args()) > 0 {
 		workflowToRunID = c.Args()[0]
 	}
+	pipelineToRunID := c.String(PipelineKey)
+	maxParallel := c.Int(MaxParallelKey)
+	if maxParallel < 0 {
+		return nil, fmt.Errorf("invalid %s value (%d): must be a positive number", MaxParallelKey, maxParallel)
+	}
+	if maxParallel == 0 {
+		maxParallel = runtime.NumCPU()
+	}
+	dryRunFormat := c.String(OuputFormatKey)
+	if dryRunFormat == "" {
+		dryRunFormat = output.FormatRaw
+	}
+	if dryRunFormat != output.FormatRaw && dryRunFormat != output.FormatJSON && dryRunFormat != output.FormatYML {
+		return nil, fmt.Errorf("invalid %s value (%s): accepted values are %s, %s and %s", OuputFormatKey, dryRunFormat, output.FormatRaw, output.FormatJSON, output.FormatYML)
+	}
 
 	var prGlobalFlagPtr *bool
 	if c.GlobalIsSet(PRKey) {
@@ -408,7 +589,7 @@ func processArgs(c *cli.Context) (*RunConfig, error) {
 	jsonParamsBase64 := c.String(JSONParamsBase64Key)
 
 	runParams, err := parseRunParams(
-		workflowToRunID,
+		workflowToRunID, pipelineToRunID,
 		bitriseConfigPath, bitriseConfigBase64Data,
 		inventoryPath, inventoryBase64Data,
 		jsonParams, jsonParamsBase64)
@@ -416,9 +597,15 @@ func processArgs(c *cli.Context) (*RunConfig, error) {
 		return nil, fmt.Errorf("failed to parse command params: %s", err)
 	}
 
-	if runParams.WorkflowToRunID == "" {
+	if runParams.WorkflowToRunID == "" && runParams.PipelineToRunID == "" {
 		return nil, errWorkflowNotSpecified
 	}
+	if runParams.WorkflowToRunID != "" && runParams.PipelineToRunID != "" {
+		return nil, errWorkflowAndPipelineSpecified
+	}
+	if runParams.PipelineToRunID != "" && c.Bool(ResumeKey) {
+		return nil, errResumePipelineSpecified
+	}
 	if strings.HasPrefix(runParams.WorkflowToRunID, "_") {
 		return nil, errUtilityWorkflowSpecified
 	}
@@ -458,6 +645,31 @@ func processArgs(c *cli.Context) (*RunConfig, error) {
 
 	isSteplibOfflineMode := isSteplibOfflineMode()
 	noOutputTimeout := readNoOutputTimoutConfiguration(inventoryEnvironments)
+	buildTimeout, err := readBuildTimeoutConfiguration(c.Int(TimeoutKey), inventoryEnvironments)
+	if err != nil {
+		return nil, err
+	}
+	cancelGracePeriod, err := readCancelGracePeriodConfiguration(c.Int(CancelGracePeriodKey), inventoryEnvironments)
+	if err != nil {
+		return nil, err
+	}
+	stepLogDir, err := readStepLogDirConfiguration(c.String(StepLogDirKey), inventoryEnvironments)
+	if err != nil {
+		return nil, err
+	}
+	otlpEndpoint, err := readOTLPEndpointConfiguration(c.String(OTLPEndpointKey), inventoryEnvironments)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []report.Target
+	for _, value := range c.StringSlice(ReportKey) {
+		target, err := report.ParseTarget(value)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, target)
+	}
 
 	return &RunConfig{
 		Modes: models.WorkflowRunModes{
@@ -469,9 +681,20 @@ func processArgs(c *cli.Context) (*RunConfig, error) {
 			SecretEnvsFilteringMode: enabledEnvsFiltering,
 			IsSteplibOfflineMode:    isSteplibOfflineMode,
 		},
-		Config:   bitriseConfig,
-		Workflow: runParams.WorkflowToRunID,
-		Secrets:  inventoryEnvironments,
+		Config:       bitriseConfig,
+		Workflow:     runParams.WorkflowToRunID,
+		Pipeline:     runParams.PipelineToRunID,
+		Secrets:      inventoryEnvironments,
+		MaxParallel:  maxParallel,
+		Resume:       c.Bool(ResumeKey),
+		DryRun:       c.Bool(DryRunKey),
+		DryRunFormat: dryRunFormat,
+		Timeout:      buildTimeout,
+
+		CancelGracePeriod: cancelGracePeriod,
+		StepLogDir:        stepLogDir,
+		Reports:           reports,
+		OTLPEndpoint:      otlpEndpoint,
 	}, nil
 }
 
@@ -521,6 +744,7 @@ func createWorkflowRunPlan(modes models.WorkflowRunModes, targetWorkflow string,
 					UUID:   uuidProvider(),
 					StepID: stepID,
 					Step:   *step,
+					Retry:  stepListItem.GetRetry(),
 				})
 			} else if t == models.StepListItemTypeWith {
 				with, err := stepListItem.GetWith()
@@ -540,6 +764,7 @@ func createWorkflowRunPlan(modes models.WorkflowRunModes, targetWorkflow string,
 						UUID:          uuidProvider(),
 						StepID:        stepID,
 						Step:          step,
+						Retry:         stepListStepItem[stepID].Retry,
 						WithGroupUUID: groupID,
 						ContainerID:   with.ContainerID,
 						ServiceIDs:    with.ServiceIDs,
@@ -570,6 +795,7 @@ func createWorkflowRunPlan(modes models.WorkflowRunModes, targetWorkflow string,
 						UUID:           uuidProvider(),
 						StepID:         stepID,
 						Step:           step,
+						Retry:          stepListStepItem[stepID].Retry,
 						StepBundleUUID: bundleUUID,
 					}
 
@@ -631,6 +857,24 @@ func walkWorkflows(workflowID string, workflows map[string]models.WorkflowModel,
 }
 
 func printAvailableWorkflows(config models.BitriseDataModel) {
+	pipelineNames := []string{}
+	for pipelineName := range config.Pipelines {
+		pipelineNames = append(pipelineNames, pipelineName)
+	}
+	sort.Strings(pipelineNames)
+
+	if len(pipelineNames) > 0 {
+		log.Print("The following pipelines are available:")
+		for _, pipelineName := range pipelineNames {
+			log.Print(" * " + pipelineName)
+		}
+
+		log.Print()
+		log.Print("You can run a selected pipeline with:")
+		log.Print("$ bitrise run --pipeline PIPELINE-ID")
+		log.Print()
+	}
+
 	workflowNames := []string{}
 	utilityWorkflowNames := []string{}
 
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const defaultCancelGracePeriod = 10 * time.Second
+
+var errBuildCancelled = errors.New("the build was cancelled")
+
+// buildCancellation is shared by the copies of a WorkflowRunner, it is cancelled when the bitrise process receives SIGINT or SIGTERM.
+// The running step is terminated, the remaining steps are aborted, except the ones marked as is_always_run.
+type buildCancellation struct {
+	once        sync.Once
+	done        chan struct{}
+	gracePeriod time.Duration
+}
+
+func newBuildCancellation(gracePeriod time.Duration) *buildCancellation {
+	return &buildCancellation{done: make(chan struct{}), gracePeriod: gracePeriod}
+}
+
+func (c *buildCancellation) cancel() {
+	if c == nil {
+		return
+	}
+	c.once.Do(func() { close(c.done) })
+}
+
+func (c *buildCancellation) isCancelled() bool {
+	if c == nil {
+		return false
+	}
+
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/stepruncmd/timeoutcmd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCancellation(t *testing.T) {
+	var noCancellation *buildCancellation
+	require.False(t, noCancellation.isCancelled())
+	noCancellation.cancel()
+
+	cancellation := newBuildCancellation(time.Second)
+	require.False(t, cancellation.isCancelled())
+
+	cancellation.cancel()
+	cancellation.cancel()
+	require.True(t, cancellation.isCancelled())
+
+	t.Log("copies of the runner share the cancellation")
+	{
+		runner := NewWorkflowRunner(RunConfig{}, nil)
+		runnerCopy := runner
+		runner.cancellation.cancel()
+		require.True(t, runnerCopy.cancellation.isCancelled())
+	}
+}
+
+func TestStepRunStatusFromError_Aborted(t *testing.T) {
+	err := fmt.Errorf("executing command failed: %w", timeoutcmd.NewAbortedError())
+
+	status, _, _ := stepRunStatusFromError(models.StepRunStatusCodeFailed, 1, err)
+	require.Equal(t, models.StepRunStatusAborted, status)
+
+	status, _, _ = stepRunStatusFromError(models.StepRunStatusCodeFailedSkippable, 1, err)
+	require.Equal(t, models.StepRunStatusAborted, status)
+}
+
+func TestReadCancelGracePeriodConfiguration(t *testing.T) {
+	t.Setenv(configs.CancelGracePeriodEnvKey, "")
+
+	gracePeriod, err := readCancelGracePeriodConfiguration(0, nil)
+	require.NoError(t, err)
+	require.Equal(t, defaultCancelGracePeriod, gracePeriod)
+
+	t.Setenv(configs.CancelGracePeriodEnvKey, "0")
+	gracePeriod, err = readCancelGracePeriodConfiguration(0, nil)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), gracePeriod)
+
+	gracePeriod, err = readCancelGracePeriodConfiguration(30, nil)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, gracePeriod)
+}
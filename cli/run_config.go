@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"time"
@@ -49,3 +50,78 @@ func readNoOutputTimoutConfiguration(inventoryEnvironments []envmanModels.Enviro
 
 	return time.Duration(timeout) * time.Second
 }
+
+// getConfigurationValue returns the value of the configuration env from the inventory, or from the environment if it is not in the inventory.
+func getConfigurationValue(key string, inventoryEnvironments []envmanModels.EnvironmentItemModel) (string, error) {
+	for _, env := range inventoryEnvironments {
+		envKey, value, err := env.GetKeyValuePair()
+		if err != nil {
+			return "", err
+		}
+
+		if envKey == key && value != "" {
+			return value, nil
+		}
+	}
+
+	return os.Getenv(key), nil
+}
+
+// readSecondsConfiguration returns the duration set by the flag (in seconds) or the configuration env, if neither is set it returns defaultValue.
+func readSecondsConfiguration(flagKey string, flagValue int, envKey string, defaultValue time.Duration, inventoryEnvironments []envmanModels.EnvironmentItemModel) (time.Duration, error) {
+	if flagValue < 0 {
+		return 0, fmt.Errorf("invalid %s value (%d): can't be negative", flagKey, flagValue)
+	}
+	if flagValue > 0 {
+		return time.Duration(flagValue) * time.Second, nil
+	}
+
+	envVal, err := getConfigurationValue(envKey, inventoryEnvironments)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read value of %s: %w", envKey, err)
+	}
+	if envVal == "" {
+		return defaultValue, nil
+	}
+
+	seconds, err := strconv.ParseInt(envVal, 10, 0)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid configuration environment variable value $%s=%s", envKey, envVal)
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// readBuildTimeoutConfiguration returns the build timeout set by the --timeout flag or the BITRISE_BUILD_TIMEOUT env,
+// 0 means no timeout.
+func readBuildTimeoutConfiguration(timeoutFlag int, inventoryEnvironments []envmanModels.EnvironmentItemModel) (time.Duration, error) {
+	return readSecondsConfiguration(TimeoutKey, timeoutFlag, configs.BuildTimeoutEnvKey, 0, inventoryEnvironments)
+}
+
+// readCancelGracePeriodConfiguration returns the grace period set by the --cancel-grace-period flag or the BITRISE_CANCEL_GRACE_PERIOD env.
+func readCancelGracePeriodConfiguration(gracePeriodFlag int, inventoryEnvironments []envmanModels.EnvironmentItemModel) (time.Duration, error) {
+	return readSecondsConfiguration(CancelGracePeriodKey, gracePeriodFlag, configs.CancelGracePeriodEnvKey, defaultCancelGracePeriod, inventoryEnvironments)
+}
+
+// readStringConfiguration returns the value set by the flag or the configuration env.
+func readStringConfiguration(flagValue string, envKey string, inventoryEnvironments []envmanModels.EnvironmentItemModel) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+
+	value, err := getConfigurationValue(envKey, inventoryEnvironments)
+	if err != nil {
+		return "", fmt.Errorf("failed to read value of %s: %w", envKey, err)
+	}
+	return value, nil
+}
+
+// readStepLogDirConfiguration returns the step log dir set by the --step-log-dir flag or the BITRISE_STEP_LOG_DIR env.
+func readStepLogDirConfiguration(stepLogDirFlag string, inventoryEnvironments []envmanModels.EnvironmentItemModel) (string, error) {
+	return readStringConfiguration(stepLogDirFlag, configs.StepLogDirEnvKey, inventoryEnvironments)
+}
+
+// readOTLPEndpointConfiguration returns the OpenTelemetry collector endpoint set by the --otlp-endpoint flag or the OTEL_EXPORTER_OTLP_ENDPOINT env.
+func readOTLPEndpointConfiguration(otlpEndpointFlag string, inventoryEnvironments []envmanModels.EnvironmentItemModel) (string, error) {
+	return readStringConfiguration(otlpEndpointFlag, configs.OTLPEndpointEnvKey, inventoryEnvironments)
+}
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/log"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/tools"
+	"github.com/bitrise-io/envman/env"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/colorstring"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/bitrise-io/stepman/toolkits"
+	"github.com/gofrs/uuid"
+)
+
+// DryRunOutputModel is the resolved execution plan of a workflow or pipeline run.
+type DryRunOutputModel struct {
+	PipelineID string             `json:"pipeline_id,omitempty" yaml:"pipeline_id,omitempty"`
+	Builds     []DryRunBuildModel `json:"builds" yaml:"builds"`
+}
+
+// DryRunBuildModel is the resolved execution plan of a triggered workflow, including its before and after run workflows.
+type DryRunBuildModel struct {
+	WorkflowID string                `json:"workflow_id" yaml:"workflow_id"`
+	Workflows  []DryRunWorkflowModel `json:"workflows" yaml:"workflows"`
+}
+
+// DryRunWorkflowModel ...
+type DryRunWorkflowModel struct {
+	WorkflowID string            `json:"workflow_id" yaml:"workflow_id"`
+	Title      string            `json:"title" yaml:"title"`
+	Steps      []DryRunStepModel `json:"steps" yaml:"steps"`
+}
+
+// DryRunStepModel holds the step properties resolved from the step.yml and the config.
+type DryRunStepModel struct {
+	StepID          string                 `json:"step_id" yaml:"step_id"`
+	Title           string                 `json:"title,omitempty" yaml:"title,omitempty"`
+	ID              string                 `json:"id,omitempty" yaml:"id,omitempty"`
+	Library         string                 `json:"library,omitempty" yaml:"library,omitempty"`
+	Version         string                 `json:"version,omitempty" yaml:"version,omitempty"`
+	Toolkit         string                 `json:"toolkit,omitempty" yaml:"toolkit,omitempty"`
+	Timeout         int                    `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	NoOutputTimeout int                    `json:"no_output_timeout,omitempty" yaml:"no_output_timeout,omitempty"`
+	RunIf           string                 `json:"run_if,omitempty" yaml:"run_if,omitempty"`
+	IsAlwaysRun     bool                   `json:"is_always_run,omitempty" yaml:"is_always_run,omitempty"`
+	ContainerID     string                 `json:"container,omitempty" yaml:"container,omitempty"`
+	ServiceIDs      []string               `json:"services,omitempty" yaml:"services,omitempty"`
+	Inputs          map[string]string      `json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	Retry           *models.StepRetryModel `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Error           string                 `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// dryRun resolves the steps of the workflow or pipeline to run and prints the plan without running anything.
+func (r WorkflowRunner) dryRun() (int, error) {
+	var workflowIDs []string
+	if r.config.Pipeline != "" {
+		pipelinePlan, err := createPipelineRunPlan(r.config.Pipeline, r.config.Config)
+		if err != nil {
+			return 1, fmt.Errorf("failed to create pipeline execution plan: %w", err)
+		}
+		for _, stagePlan := range pipelinePlan.Stages {
+			for _, workflowPlan := range stagePlan.Workflows {
+				workflowIDs = append(workflowIDs, workflowPlan.WorkflowID)
+			}
+		}
+	} else {
+		workflowIDs = []string{r.config.Workflow}
+	}
+
+	dryRunOutput := DryRunOutputModel{PipelineID: r.config.Pipeline}
+	failedStepCount := 0
+	for _, workflowID := range workflowIDs {
+		build, err := r.explainBuild(workflowID)
+		if err != nil {
+			return 1, err
+		}
+
+		for _, workflow := range build.Workflows {
+			for _, step := range workflow.Steps {
+				if step.Error != "" {
+					failedStepCount++
+				}
+			}
+		}
+		dryRunOutput.Builds = append(dryRunOutput.Builds, build)
+	}
+
+	if r.config.DryRunFormat == output.FormatRaw || r.config.DryRunFormat == "" {
+		log.Print(dryRunOutput.String())
+	} else {
+		output.Print(dryRunOutput, r.config.DryRunFormat)
+	}
+
+	if failedStepCount > 0 {
+		return 1, fmt.Errorf("failed to resolve %d step(s)", failedStepCount)
+	}
+	return 0, nil
+}
+
+// explainBuild resolves the steps of the workflow's execution plan, the same way as the build would do it.
+func (r WorkflowRunner) explainBuild(workflowID string) (DryRunBuildModel, error) {
+	plan, err := createWorkflowRunPlan(r.config.Modes, workflowID, r.config.Config.Workflows, r.config.Config.StepBundles, func() string { return uuid.Must(uuid.NewV4()).String() })
+	if err != nil {
+		return DryRunBuildModel{}, fmt.Errorf("failed to create workflow execution plan: %w", err)
+	}
+
+	_, secretValues := tools.GetSecretKeysAndValues(r.config.Secrets)
+
+	environments := append([]envmanModels.EnvironmentItemModel{}, r.config.Secrets...)
+	environments = append(environments, r.config.Config.App.Environments...)
+	environments = append(environments, r.config.Config.Workflows[workflowID].Environments...)
+
+	buildRunResults := models.BuildRunResultsModel{StepmanUpdates: map[string]int{}}
+	build := DryRunBuildModel{WorkflowID: workflowID}
+
+	for _, workflowPlan := range plan.ExecutionPlan {
+		environments = append(environments, r.config.Config.Workflows[workflowPlan.WorkflowID].Environments...)
+
+		workflow := DryRunWorkflowModel{WorkflowID: workflowPlan.WorkflowID, Title: workflowPlan.WorkflowTitle}
+		var bundleEnvironments []envmanModels.EnvironmentItemModel
+		for _, stepPlan := range workflowPlan.Steps {
+			stepEnvironments := environments
+			if stepPlan.StepBundleUUID != "" {
+				if len(stepPlan.StepBundleEnvs) > 0 {
+					bundleEnvironments = append(append([]envmanModels.EnvironmentItemModel{}, environments...), stepPlan.StepBundleEnvs...)
+				}
+				stepEnvironments = bundleEnvironments
+			}
+
+			step := r.explainStep(stepPlan, r.config.Config.DefaultStepLibSource, buildRunResults, workflowPlan.IsSteplibOfflineMode, stepEnvironments, secretValues)
+			workflow.Steps = append(workflow.Steps, step)
+		}
+
+		build.Workflows = append(build.Workflows, workflow)
+	}
+
+	return build, nil
+}
+
+func (r WorkflowRunner) explainStep(
+	stepPlan models.StepExecutionPlan,
+	defaultStepLibSource string,
+	buildRunResults models.BuildRunResultsModel,
+	isStepLibOfflineMode bool,
+	environments []envmanModels.EnvironmentItemModel,
+	secretValues []string,
+) DryRunStepModel {
+	step := DryRunStepModel{
+		StepID:      stepPlan.StepID,
+		ContainerID: stepPlan.ContainerID,
+		ServiceIDs:  stepPlan.ServiceIDs,
+	}
+
+	activateResult := r.activateStep(stepPlan.Step, stepPlan.StepID, defaultStepLibSource, buildRunResults, isStepLibOfflineMode)
+	stepInfo := activateResult.StepInfoPtr
+	step.ID = stepInfo.ID
+	step.Library = stepInfo.Library
+	step.Version = stepInfo.Version
+	if stepInfo.Step.Title != nil {
+		step.Title = *stepInfo.Step.Title
+	}
+	if activateResult.Err != nil {
+		step.Error = activateResult.Err.Error()
+		return step
+	}
+
+	mergedStep := activateResult.Step
+	if mergedStep.Timeout != nil {
+		step.Timeout = *mergedStep.Timeout
+	}
+	if mergedStep.NoOutputTimeout != nil {
+		step.NoOutputTimeout = *mergedStep.NoOutputTimeout
+	}
+	if mergedStep.RunIf != nil {
+		step.RunIf = *mergedStep.RunIf
+	}
+	if mergedStep.IsAlwaysRun != nil {
+		step.IsAlwaysRun = *mergedStep.IsAlwaysRun
+	}
+	step.Toolkit = toolkits.ToolkitForStep(mergedStep, r.logger).ToolkitName()
+
+	step.Retry = stepPlan.Retry
+
+	inputs, err := r.explainStepInputs(mergedStep, buildRunResults, environments, secretValues)
+	if err != nil {
+		step.Error = err.Error()
+		return step
+	}
+	step.Inputs = inputs
+
+	return step
+}
+
+// explainStepInputs returns the step inputs expanded with the environment known before the build,
+// the outputs of the previous steps are not available. Secrets and sensitive inputs are redacted.
+func (r WorkflowRunner) explainStepInputs(
+	step stepmanModels.StepModel,
+	buildRunResults models.BuildRunResultsModel,
+	environments []envmanModels.EnvironmentItemModel,
+	secretValues []string,
+) (map[string]string, error) {
+	stepDeclaredEnvironments, expandedStepEnvironment, _, err := prepareStepEnvironment(prepareStepInputParams{
+		environment:       append([]envmanModels.EnvironmentItemModel{}, environments...),
+		inputs:            step.Inputs,
+		buildRunResults:   buildRunResults,
+		isCIMode:          r.config.Modes.CIMode,
+		isPullRequestMode: r.config.Modes.PRMode,
+	}, &env.DefaultEnvironmentSource{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare step inputs: %w", err)
+	}
+
+	sensitiveEnvs, err := getSensitiveEnvs(stepDeclaredEnvironments, expandedStepEnvironment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sensitive inputs: %w", err)
+	}
+	_, sensitiveValues := tools.GetSecretKeysAndValues(sensitiveEnvs)
+
+	redactedInputs, _, err := redactStepInputs(expandedStepEnvironment, step.Inputs, append(append([]string{}, secretValues...), sensitiveValues...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to redact step inputs: %w", err)
+	}
+	return redactedInputs, nil
+}
+
+// String ...
+func (o DryRunOutputModel) String() string {
+	var b strings.Builder
+	if o.PipelineID != "" {
+		fmt.Fprintf(&b, "Pipeline: %s\n\n", colorstring.Green(o.PipelineID))
+	}
+
+	for _, build := range o.Builds {
+		for _, workflow := range build.Workflows {
+			fmt.Fprintf(&b, "⚡️ %s", colorstring.Green(workflow.WorkflowID))
+			if workflow.WorkflowID != build.WorkflowID {
+				fmt.Fprintf(&b, " (run by %s)", build.WorkflowID)
+			}
+			b.WriteString("\n")
+
+			if len(workflow.Steps) == 0 {
+				b.WriteString("  no steps\n")
+			}
+			for idx, step := range workflow.Steps {
+				fmt.Fprintf(&b, "  %d. %s\n", idx+1, colorstring.Blue(step.Title))
+				writeDryRunProperty(&b, "Step", step.StepID)
+				if step.Error != "" {
+					writeDryRunProperty(&b, "Error", colorstring.Red(step.Error))
+					continue
+				}
+				writeDryRunProperty(&b, "Library", step.Library)
+				writeDryRunProperty(&b, "Version", step.Version)
+				writeDryRunProperty(&b, "Toolkit", step.Toolkit)
+				if step.Timeout > 0 {
+					writeDryRunProperty(&b, "Timeout", fmt.Sprintf("%ds", step.Timeout))
+				}
+				if step.NoOutputTimeout > 0 {
+					writeDryRunProperty(&b, "No output timeout", fmt.Sprintf("%ds", step.NoOutputTimeout))
+				}
+				writeDryRunProperty(&b, "Run if", step.RunIf)
+				if step.IsAlwaysRun {
+					writeDryRunProperty(&b, "Always run", "true")
+				}
+				if step.Retry != nil {
+					writeDryRunProperty(&b, "Retry", fmt.Sprintf("%d attempts", step.Retry.Attempts))
+				}
+				writeDryRunProperty(&b, "Container", step.ContainerID)
+				writeDryRunProperty(&b, "Services", strings.Join(step.ServiceIDs, ", "))
+
+				if len(step.Inputs) > 0 {
+					fmt.Fprintf(&b, "     %s:\n", colorstring.Yellow("Inputs"))
+					for _, key := range sortedKeys(step.Inputs) {
+						fmt.Fprintf(&b, "       %s: %s\n", key, step.Inputs[key])
+					}
+				}
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+func writeDryRunProperty(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, "     %s: %s\n", colorstring.Yellow(name), value)
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainBuild(t *testing.T) {
+	configStr := `
+format_version: '13'
+containers:
+  ruby:
+    image: ruby:3.2
+services:
+  postgres:
+    image: postgres:16
+workflows:
+  before: {}
+  primary:
+    before_run:
+    - before
+    steps:
+    - path::./non-existent-step:
+        title: Missing
+    - with:
+        container: ruby
+        services: [postgres]
+        steps:
+        - path::./non-existent-step: {}
+`
+	config, _, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.NoError(t, configs.InitPaths())
+
+	runner := NewWorkflowRunner(RunConfig{Config: config, Workflow: "primary", DryRun: true}, nil)
+	build, err := runner.explainBuild("primary")
+	require.NoError(t, err)
+
+	require.Equal(t, "primary", build.WorkflowID)
+	require.Equal(t, 2, len(build.Workflows))
+	require.Equal(t, "before", build.Workflows[0].WorkflowID)
+	require.Equal(t, 0, len(build.Workflows[0].Steps))
+
+	steps := build.Workflows[1].Steps
+	require.Equal(t, 2, len(steps))
+	require.Equal(t, "path::./non-existent-step", steps[0].StepID)
+	require.Equal(t, "Missing", steps[0].Title)
+	require.Equal(t, "path", steps[0].Library)
+	require.NotEmpty(t, steps[0].Error)
+	require.Equal(t, "ruby", steps[1].ContainerID)
+	require.Equal(t, []string{"postgres"}, steps[1].ServiceIDs)
+
+	exitCode, err := runner.dryRun()
+	require.EqualError(t, err, "failed to resolve 2 step(s)")
+	require.Equal(t, 1, exitCode)
+}
+
+func TestExplainStepInputs(t *testing.T) {
+	runner := NewWorkflowRunner(RunConfig{}, nil)
+
+	step := stepmanModels.StepModel{
+		Inputs: []envmanModels.EnvironmentItemModel{
+			{"project_path": "$PROJECT_DIR/app.xcodeproj"},
+			{"api_token": "$API_TOKEN"},
+			{"password": "plain", "opts": map[string]interface{}{"is_sensitive": true}},
+		},
+	}
+	environments := []envmanModels.EnvironmentItemModel{
+		{"API_TOKEN": "secret-token", "opts": map[string]interface{}{"is_expand": false}},
+		{"PROJECT_DIR": "ios"},
+	}
+
+	inputs, err := runner.explainStepInputs(step, models.BuildRunResultsModel{}, environments, []string{"secret-token"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"project_path": "ios/app.xcodeproj",
+		"api_token":    "[REDACTED]",
+		"password":     "[REDACTED]",
+	}, inputs)
+}
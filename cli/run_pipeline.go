@@ -0,0 +1,528 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise/analytics"
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/log"
+	"github.com/bitrise-io/bitrise/models"
+	envman "github.com/bitrise-io/envman/cli"
+	envmanEnv "github.com/bitrise-io/envman/env"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/colorstring"
+)
+
+const buildCancelledReason = "The workflow was not started, because the build was cancelled."
+
+func (r WorkflowRunner) runPipeline(tracker analytics.Tracker) (models.PipelineRunResultsModel, error) {
+	plan, err := createPipelineRunPlan(r.config.Pipeline, r.config.Config)
+	if err != nil {
+		return models.PipelineRunResultsModel{}, fmt.Errorf("failed to create pipeline execution plan: %w", err)
+	}
+
+	if err := r.prepareRun(); err != nil {
+		return models.PipelineRunResultsModel{}, err
+	}
+
+	sharedData, err := newPipelineSharedDataStore(plan.PipelineID)
+	if err != nil {
+		return models.PipelineRunResultsModel{}, err
+	}
+	defer func() {
+		if err := sharedData.remove(); err != nil {
+			log.Warnf("Failed to remove pipeline shared data: %s", err)
+		}
+	}()
+	r.sharedData = &sharedData
+
+	printPipelineRunPlan(plan)
+
+	pipelineRunResults := models.PipelineRunResultsModel{
+		PipelineID: plan.PipelineID,
+		StartTime:  time.Now(),
+	}
+
+	for _, stagePlan := range plan.Stages {
+		stageRunResults := r.runPipelineStage(stagePlan, pipelineRunResults, tracker)
+		pipelineRunResults.Stages = append(pipelineRunResults.Stages, stageRunResults)
+
+		log.PrintStageFinishedEvent(stageFinishedParamsFromResults(plan.PipelineID, stageRunResults))
+	}
+
+	return pipelineRunResults, nil
+}
+
+func (r WorkflowRunner) runPipelineStage(stagePlan models.PipelineStageRunPlan, pipelineRunResults models.PipelineRunResultsModel, tracker analytics.Tracker) models.StageRunResultsModel {
+	stageRunResults := models.StageRunResultsModel{
+		StageID:   stagePlan.StageID,
+		StartTime: time.Now(),
+	}
+
+	skipReason := ""
+	if pipelineRunResults.IsBuildFailed() && !stagePlan.ShouldAlwaysRun {
+		skipReason = "A previous stage failed, and this stage was not marked \"should_always_run\"."
+	} else if stagePlan.RunIf != "" {
+		isRun, err := r.evaluatePipelineRunIf(stagePlan.RunIf, pipelineRunResults)
+		if err != nil {
+			skipReason = fmt.Sprintf("Failed to evaluate the stage's \"run_if\" expression: %s", err)
+		} else if !isRun {
+			skipReason = fmt.Sprintf("The stage's \"run_if\" expression evaluated to false: %s", stagePlan.RunIf)
+		}
+	}
+	stageRunResults.StatusReason = skipReason
+
+	if skipReason == "" && stagePlan.StageID == "" && r.config.MaxParallel > 1 {
+		stageRunResults.Workflows = r.runDAGWorkflowsInParallel(stagePlan, pipelineRunResults, tracker)
+		stageRunResults.RunTime = time.Since(stageRunResults.StartTime)
+		stageRunResults.Status = stageStatus(stageRunResults)
+
+		return stageRunResults
+	}
+
+	abortReason := ""
+	workflowStatuses := map[string]models.WorkflowRunStatus{}
+
+	for _, workflowPlan := range stagePlan.Workflows {
+		var workflowRunResults models.WorkflowRunResultsModel
+
+		if skipReason != "" {
+			workflowRunResults = newNotStartedWorkflowRunResults(workflowPlan.WorkflowID, models.WorkflowRunStatusSkipped, "")
+		} else if abortReason != "" {
+			workflowRunResults = newNotStartedWorkflowRunResults(workflowPlan.WorkflowID, models.WorkflowRunStatusAborted, abortReason)
+		} else if r.cancellation.isCancelled() {
+			workflowRunResults = newNotStartedWorkflowRunResults(workflowPlan.WorkflowID, models.WorkflowRunStatusAborted, buildCancelledReason)
+		} else if dependency := firstUnsuccessfulDependency(workflowPlan.DependsOn, workflowStatuses); dependency != "" {
+			reason := fmt.Sprintf("The workflow was not started, because its dependency (%s) did not succeed.", dependency)
+			workflowRunResults = newNotStartedWorkflowRunResults(workflowPlan.WorkflowID, models.WorkflowRunStatusSkipped, reason)
+		} else {
+			// Workflow level run_if expressions can rely on the results of the current stage as well
+			currentRunResults := pipelineRunResults
+			currentRunResults.Stages = append(append([]models.StageRunResultsModel{}, pipelineRunResults.Stages...), stageRunResults)
+
+			upstreamWorkflowIDs := upstreamWorkflowIDs(stagePlan, workflowPlan.WorkflowID, pipelineRunResults)
+			workflowRunResults = r.runPipelineWorkflowIfNeeded(workflowPlan, upstreamWorkflowIDs, currentRunResults, tracker)
+			if workflowRunResults.Status == models.WorkflowRunStatusFailed && stagePlan.AbortOnFail {
+				abortReason = fmt.Sprintf("The workflow was not started, because workflow (%s) failed and the stage was marked \"abort_on_fail\".", workflowPlan.WorkflowID)
+			}
+		}
+
+		workflowStatuses[workflowPlan.WorkflowID] = workflowRunResults.Status
+		stageRunResults.Workflows = append(stageRunResults.Workflows, workflowRunResults)
+
+		log.PrintWorkflowFinishedEvent(workflowFinishedParamsFromResults(stagePlan.StageID, workflowRunResults))
+	}
+
+	stageRunResults.RunTime = time.Since(stageRunResults.StartTime)
+	stageRunResults.Status = stageStatus(stageRunResults)
+
+	return stageRunResults
+}
+
+// runDAGWorkflowsInParallel starts every workflow as soon as its dependencies finished, running at most MaxParallel workflows at a time.
+// Once the build is cancelled, no more workflows are started, the running ones are waited for.
+// The results are returned in the order of the stage plan.
+func (r WorkflowRunner) runDAGWorkflowsInParallel(stagePlan models.PipelineStageRunPlan, pipelineRunResults models.PipelineRunResultsModel, tracker analytics.Tracker) []models.WorkflowRunResultsModel {
+	workflowRunResults := make([]models.WorkflowRunResultsModel, len(stagePlan.Workflows))
+	isStarted := make([]bool, len(stagePlan.Workflows))
+	workflowStatuses := map[string]models.WorkflowRunStatus{}
+
+	finishedIdxChan := make(chan int)
+	running, finished := 0, 0
+
+	for finished < len(stagePlan.Workflows) {
+		for idx, workflowPlan := range stagePlan.Workflows {
+			if isStarted[idx] {
+				continue
+			}
+
+			if r.cancellation.isCancelled() {
+				isStarted[idx] = true
+				finished++
+
+				workflowRunResults[idx] = newNotStartedWorkflowRunResults(workflowPlan.WorkflowID, models.WorkflowRunStatusAborted, buildCancelledReason)
+				workflowStatuses[workflowPlan.WorkflowID] = models.WorkflowRunStatusAborted
+
+				log.PrintWorkflowFinishedEvent(workflowFinishedParamsFromResults(stagePlan.StageID, workflowRunResults[idx]))
+				continue
+			}
+
+			if !areDependenciesFinished(workflowPlan.DependsOn, workflowStatuses) {
+				continue
+			}
+
+			if dependency := firstUnsuccessfulDependency(workflowPlan.DependsOn, workflowStatuses); dependency != "" {
+				isStarted[idx] = true
+				finished++
+
+				reason := fmt.Sprintf("The workflow was not started, because its dependency (%s) did not succeed.", dependency)
+				workflowRunResults[idx] = newNotStartedWorkflowRunResults(workflowPlan.WorkflowID, models.WorkflowRunStatusSkipped, reason)
+				workflowStatuses[workflowPlan.WorkflowID] = models.WorkflowRunStatusSkipped
+
+				log.PrintWorkflowFinishedEvent(workflowFinishedParamsFromResults(stagePlan.StageID, workflowRunResults[idx]))
+				continue
+			}
+
+			if running >= r.config.MaxParallel {
+				continue
+			}
+
+			isStarted[idx] = true
+			running++
+
+			upstreamWorkflowIDs := upstreamWorkflowIDs(stagePlan, workflowPlan.WorkflowID, pipelineRunResults)
+			go func(idx int, workflowPlan models.PipelineWorkflowRunPlan) {
+				workflowRunResults[idx] = r.runPipelineWorkflowInWorkspace(workflowPlan, upstreamWorkflowIDs, pipelineRunResults, tracker)
+				finishedIdxChan <- idx
+			}(idx, workflowPlan)
+		}
+
+		if running == 0 {
+			// Every workflow is finished, or the remaining ones depend on workflows which are not part of the stage
+			break
+		}
+
+		idx := <-finishedIdxChan
+		running--
+		finished++
+
+		workflowStatuses[stagePlan.Workflows[idx].WorkflowID] = workflowRunResults[idx].Status
+		log.PrintWorkflowFinishedEvent(workflowFinishedParamsFromResults(stagePlan.StageID, workflowRunResults[idx]))
+	}
+
+	return workflowRunResults
+}
+
+// runPipelineWorkflowInWorkspace runs the workflow in its own workspace and with its own logger,
+// so that it doesn't share process level state with the concurrently running workflows.
+func (r WorkflowRunner) runPipelineWorkflowInWorkspace(workflowPlan models.PipelineWorkflowRunPlan, upstreamWorkflowIDs []string, pipelineRunResults models.PipelineRunResultsModel, tracker analytics.Tracker) models.WorkflowRunResultsModel {
+	workflowTitle := r.config.Config.Workflows[workflowPlan.WorkflowID].Title
+	if workflowTitle == "" {
+		workflowTitle = workflowPlan.WorkflowID
+	}
+
+	workspace, err := newIsolatedBuildWorkspace(workflowPlan.WorkflowID, workflowTitle)
+	if err != nil {
+		reason := fmt.Sprintf("Failed to create the workspace of the workflow: %s", err)
+		return newNotStartedWorkflowRunResults(workflowPlan.WorkflowID, models.WorkflowRunStatusFailed, reason)
+	}
+	defer func() {
+		if err := workspace.remove(); err != nil {
+			log.Warnf("Failed to remove the workspace of workflow (%s): %s", workflowPlan.WorkflowID, err)
+		}
+	}()
+
+	opts := log.GetGlobalLoggerOpts()
+	opts.ProducerID = workflowPlan.WorkflowID
+
+	runner := r
+	runner.workspace = &workspace
+	runner.logger = log.NewLogger(opts)
+
+	return runner.runPipelineWorkflowIfNeeded(workflowPlan, upstreamWorkflowIDs, pipelineRunResults, tracker)
+}
+
+func (r WorkflowRunner) runPipelineWorkflowIfNeeded(workflowPlan models.PipelineWorkflowRunPlan, upstreamWorkflowIDs []string, pipelineRunResults models.PipelineRunResultsModel, tracker analytics.Tracker) models.WorkflowRunResultsModel {
+	if workflowPlan.RunIf != "" {
+		isRun, err := r.evaluatePipelineRunIf(workflowPlan.RunIf, pipelineRunResults)
+		if err != nil {
+			reason := fmt.Sprintf("Failed to evaluate the workflow's \"run_if\" expression: %s", err)
+			return newNotStartedWorkflowRunResults(workflowPlan.WorkflowID, models.WorkflowRunStatusFailed, reason)
+		}
+		if !isRun {
+			reason := fmt.Sprintf("The workflow's \"run_if\" expression evaluated to false: %s", workflowPlan.RunIf)
+			return newNotStartedWorkflowRunResults(workflowPlan.WorkflowID, models.WorkflowRunStatusSkipped, reason)
+		}
+	}
+
+	return r.runPipelineWorkflow(workflowPlan.WorkflowID, upstreamWorkflowIDs, tracker)
+}
+
+func (r WorkflowRunner) runPipelineWorkflow(workflowID string, upstreamWorkflowIDs []string, tracker analytics.Tracker) models.WorkflowRunResultsModel {
+	// The build can be cancelled while the run_if expression is evaluated or the workspace is created
+	if r.cancellation.isCancelled() {
+		return newNotStartedWorkflowRunResults(workflowID, models.WorkflowRunStatusAborted, buildCancelledReason)
+	}
+
+	workflowRunResults := models.WorkflowRunResultsModel{
+		WorkflowID: workflowID,
+		StartTime:  time.Now(),
+	}
+
+	var pipelineEnvironments []envmanModels.EnvironmentItemModel
+	if r.sharedData != nil {
+		environments, err := r.sharedData.restore(workflowID, upstreamWorkflowIDs)
+		if err != nil {
+			workflowRunResults.Status = models.WorkflowRunStatusFailed
+			workflowRunResults.StatusReason = fmt.Sprintf("Failed to restore the outputs of the upstream workflows: %s", err)
+			return workflowRunResults
+		}
+		pipelineEnvironments = environments
+	}
+
+	buildRunResults, environments, err := r.runBuild(workflowID, pipelineEnvironments, tracker)
+	workflowRunResults.RunTime = time.Since(workflowRunResults.StartTime)
+	if err != nil {
+		workflowRunResults.Status = models.WorkflowRunStatusFailed
+		workflowRunResults.StatusReason = err.Error()
+		return workflowRunResults
+	}
+
+	workflowRunResults.BuildRunResults = &buildRunResults
+	if buildRunResults.IsBuildFailed() {
+		workflowRunResults.Status = models.WorkflowRunStatusFailed
+		return workflowRunResults
+	}
+
+	workflowRunResults.Status = models.WorkflowRunStatusSuccess
+	if share := r.config.Config.Workflows[workflowID].Share; share != nil && r.sharedData != nil {
+		if err := r.sharedData.save(workflowID, *share, environments); err != nil {
+			workflowRunResults.Status = models.WorkflowRunStatusFailed
+			workflowRunResults.StatusReason = fmt.Sprintf("Failed to share the outputs of the workflow: %s", err)
+		}
+	}
+
+	return workflowRunResults
+}
+
+func (r WorkflowRunner) evaluatePipelineRunIf(expression string, pipelineRunResults models.PipelineRunResultsModel) (bool, error) {
+	environments := append([]envmanModels.EnvironmentItemModel{}, r.config.Secrets...)
+	environments = append(environments, r.config.Config.App.Environments...)
+	envList, err := envman.ConvertToEnvsJSONModel(environments, true, false, &envmanEnv.DefaultEnvironmentSource{})
+	if err != nil {
+		return false, fmt.Errorf("EnvmanReadEnvList failed, err: %s", err)
+	}
+
+	buildRunResults := models.BuildRunResultsModel{FailedSteps: pipelineRunResults.FailedSteps()}
+	return bitrise.EvaluateTemplateToBool(expression, r.config.Modes.CIMode, r.config.Modes.PRMode, buildRunResults, envList)
+}
+
+// upstreamWorkflowIDs returns the workflows whose shared outputs are available for the given workflow:
+// every workflow of the previous stages, and the direct and transitive dependencies within the stage.
+func upstreamWorkflowIDs(stagePlan models.PipelineStageRunPlan, workflowID string, pipelineRunResults models.PipelineRunResultsModel) []string {
+	var workflowIDs []string
+	for _, workflowRunResults := range pipelineRunResults.WorkflowResults() {
+		workflowIDs = append(workflowIDs, workflowRunResults.WorkflowID)
+	}
+
+	dependsOn := map[string][]string{}
+	for _, workflowPlan := range stagePlan.Workflows {
+		dependsOn[workflowPlan.WorkflowID] = workflowPlan.DependsOn
+	}
+
+	dependencies := map[string]bool{}
+	var collect func(workflowID string)
+	collect = func(workflowID string) {
+		for _, dependency := range dependsOn[workflowID] {
+			if !dependencies[dependency] {
+				dependencies[dependency] = true
+				collect(dependency)
+			}
+		}
+	}
+	collect(workflowID)
+
+	// Keep the execution order, so that the closer dependencies override the values of the earlier ones
+	for _, workflowPlan := range stagePlan.Workflows {
+		if dependencies[workflowPlan.WorkflowID] {
+			workflowIDs = append(workflowIDs, workflowPlan.WorkflowID)
+		}
+	}
+
+	return workflowIDs
+}
+
+func newNotStartedWorkflowRunResults(workflowID string, status models.WorkflowRunStatus, reason string) models.WorkflowRunResultsModel {
+	return models.WorkflowRunResultsModel{
+		WorkflowID:   workflowID,
+		Status:       status,
+		StatusReason: reason,
+		StartTime:    time.Now(),
+	}
+}
+
+func firstUnsuccessfulDependency(dependencies []string, workflowStatuses map[string]models.WorkflowRunStatus) string {
+	for _, dependency := range dependencies {
+		if workflowStatuses[dependency] != models.WorkflowRunStatusSuccess {
+			return dependency
+		}
+	}
+	return ""
+}
+
+func areDependenciesFinished(dependencies []string, workflowStatuses map[string]models.WorkflowRunStatus) bool {
+	for _, dependency := range dependencies {
+		if _, ok := workflowStatuses[dependency]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func stageStatus(stageRunResults models.StageRunResultsModel) models.WorkflowRunStatus {
+	if stageRunResults.IsFailed() {
+		return models.WorkflowRunStatusFailed
+	}
+
+	for _, workflowRunResults := range stageRunResults.Workflows {
+		if workflowRunResults.Status != models.WorkflowRunStatusSkipped {
+			return models.WorkflowRunStatusSuccess
+		}
+	}
+
+	return models.WorkflowRunStatusSkipped
+}
+
+func createPipelineRunPlan(pipelineID string, config models.BitriseDataModel) (models.PipelineRunPlan, error) {
+	pipeline, ok := config.Pipelines[pipelineID]
+	if !ok {
+		return models.PipelineRunPlan{}, fmt.Errorf("pipeline (%s) does not exist", pipelineID)
+	}
+
+	plan := models.PipelineRunPlan{PipelineID: pipelineID}
+
+	if len(pipeline.Stages) > 0 {
+		for _, stageListItem := range pipeline.Stages {
+			stageID, err := stageListItem.GetStageID()
+			if err != nil {
+				return models.PipelineRunPlan{}, err
+			}
+
+			stage, ok := config.Stages[stageID]
+			if !ok {
+				return models.PipelineRunPlan{}, fmt.Errorf("stage (%s) defined in pipeline (%s) does not exist", stageID, pipelineID)
+			}
+
+			stagePlan := models.PipelineStageRunPlan{
+				StageID:         stageID,
+				ShouldAlwaysRun: stage.ShouldAlwaysRun,
+				AbortOnFail:     stage.AbortOnFail,
+				RunIf:           stage.RunIf,
+			}
+
+			for _, stageWorkflowListItem := range stage.Workflows {
+				workflowID, stageWorkflow, err := stageWorkflowListItem.GetWorkflowIDAndWorkflow()
+				if err != nil {
+					return models.PipelineRunPlan{}, err
+				}
+
+				stagePlan.Workflows = append(stagePlan.Workflows, models.PipelineWorkflowRunPlan{
+					WorkflowID: workflowID,
+					RunIf:      stageWorkflow.RunIf,
+				})
+			}
+
+			plan.Stages = append(plan.Stages, stagePlan)
+		}
+
+		return plan, nil
+	}
+
+	workflowIDs, err := sortDAGWorkflows(pipeline.Workflows)
+	if err != nil {
+		return models.PipelineRunPlan{}, err
+	}
+
+	stagePlan := models.PipelineStageRunPlan{}
+	for _, workflowID := range workflowIDs {
+		stagePlan.Workflows = append(stagePlan.Workflows, models.PipelineWorkflowRunPlan{
+			WorkflowID: workflowID,
+			DependsOn:  pipeline.Workflows[workflowID].DependsOn,
+		})
+	}
+	plan.Stages = append(plan.Stages, stagePlan)
+
+	return plan, nil
+}
+
+// sortDAGWorkflows orders the workflows so that every workflow comes after its dependencies.
+// Workflows which are ready to run at the same time are ordered by their IDs to keep the execution deterministic.
+func sortDAGWorkflows(workflows models.DagWorkflowListItemModel) ([]string, error) {
+	remainingDependencyCounts := map[string]int{}
+	dependents := map[string][]string{}
+	for workflowID, workflow := range workflows {
+		remainingDependencyCounts[workflowID] = len(workflow.DependsOn)
+		for _, dependency := range workflow.DependsOn {
+			if _, ok := workflows[dependency]; !ok {
+				return nil, fmt.Errorf("workflow (%s) defined in dependencies (%s) is not part of the pipeline", dependency, workflowID)
+			}
+			dependents[dependency] = append(dependents[dependency], workflowID)
+		}
+	}
+
+	var ready []string
+	for workflowID, count := range remainingDependencyCounts {
+		if count == 0 {
+			ready = append(ready, workflowID)
+		}
+	}
+
+	var sorted []string
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		workflowID := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, workflowID)
+
+		for _, dependent := range dependents[workflowID] {
+			remainingDependencyCounts[dependent]--
+			if remainingDependencyCounts[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(sorted) != len(workflows) {
+		return nil, errors.New("the workflow dependencies of the pipeline contain a cycle")
+	}
+
+	return sorted, nil
+}
+
+func printPipelineRunPlan(plan models.PipelineRunPlan) {
+	log.Print()
+	log.Infof("Running pipeline: %s", plan.PipelineID)
+
+	for _, stagePlan := range plan.Stages {
+		var workflowIDs []string
+		for _, workflowPlan := range stagePlan.Workflows {
+			workflowIDs = append(workflowIDs, workflowPlan.WorkflowID)
+		}
+
+		if stagePlan.StageID != "" {
+			log.Printf("Stage %s: %s", stagePlan.StageID, colorstring.Cyan(strings.Join(workflowIDs, ", ")))
+		} else {
+			log.Printf("Workflows: %s", colorstring.Cyan(strings.Join(workflowIDs, " → ")))
+		}
+	}
+}
+
+func workflowFinishedParamsFromResults(stageID string, results models.WorkflowRunResultsModel) log.WorkflowFinishedParams {
+	return log.WorkflowFinishedParams{
+		WorkflowID:   results.WorkflowID,
+		StageID:      stageID,
+		Status:       results.Status.String(),
+		StatusReason: results.StatusReason,
+		RunTime:      results.RunTime.Milliseconds(),
+	}
+}
+
+func stageFinishedParamsFromResults(pipelineID string, results models.StageRunResultsModel) log.StageFinishedParams {
+	params := log.StageFinishedParams{
+		PipelineID:   pipelineID,
+		StageID:      results.StageID,
+		Status:       results.Status.String(),
+		StatusReason: results.StatusReason,
+		RunTime:      results.RunTime.Milliseconds(),
+	}
+
+	for _, workflowResults := range results.Workflows {
+		params.Workflows = append(params.Workflows, workflowFinishedParamsFromResults(results.StageID, workflowResults))
+	}
+
+	return params
+}
@@ -0,0 +1,368 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/bitrise-io/go-utils/v2/analytics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePipelineRunPlan(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		pipelineID string
+		want       models.PipelineRunPlan
+		wantErr    string
+	}{
+		{
+			name:       "Staged pipeline",
+			config:     validStagedPipeline,
+			pipelineID: "staged",
+			want: models.PipelineRunPlan{
+				PipelineID: "staged",
+				Stages: []models.PipelineStageRunPlan{
+					{StageID: "s1", Workflows: []models.PipelineWorkflowRunPlan{{WorkflowID: "a"}}},
+					{StageID: "s2", Workflows: []models.PipelineWorkflowRunPlan{{WorkflowID: "b"}, {WorkflowID: "c"}, {WorkflowID: "d"}}},
+					{StageID: "s3", Workflows: []models.PipelineWorkflowRunPlan{{WorkflowID: "e"}}},
+				},
+			},
+		},
+		{
+			name:       "DAG pipeline",
+			config:     validDAGPipeline,
+			pipelineID: "dag",
+			want: models.PipelineRunPlan{
+				PipelineID: "dag",
+				Stages: []models.PipelineStageRunPlan{
+					{Workflows: []models.PipelineWorkflowRunPlan{
+						{WorkflowID: "a"},
+						{WorkflowID: "b", DependsOn: []string{"a"}},
+						{WorkflowID: "c", DependsOn: []string{"a"}},
+						{WorkflowID: "d", DependsOn: []string{"a"}},
+						{WorkflowID: "e", DependsOn: []string{"b", "d"}},
+						{WorkflowID: "f", DependsOn: []string{"e"}},
+						{WorkflowID: "g", DependsOn: []string{"a", "e", "f"}},
+					}},
+				},
+			},
+		},
+		{
+			name:       "Stage properties",
+			pipelineID: "staged",
+			config: `
+format_version: '13'
+pipelines:
+  staged:
+    stages:
+    - s1: {}
+stages:
+  s1:
+    should_always_run: true
+    abort_on_fail: true
+    run_if: '{{enveq "RUN" "true"}}'
+    workflows:
+    - a:
+        run_if: .IsCI
+workflows:
+  a: {}
+`,
+			want: models.PipelineRunPlan{
+				PipelineID: "staged",
+				Stages: []models.PipelineStageRunPlan{
+					{
+						StageID:         "s1",
+						ShouldAlwaysRun: true,
+						AbortOnFail:     true,
+						RunIf:           `{{enveq "RUN" "true"}}`,
+						Workflows:       []models.PipelineWorkflowRunPlan{{WorkflowID: "a", RunIf: ".IsCI"}},
+					},
+				},
+			},
+		},
+		{
+			name:       "Missing pipeline",
+			config:     validDAGPipeline,
+			pipelineID: "missing",
+			wantErr:    "pipeline (missing) does not exist",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _, err := bitrise.ConfigModelFromYAMLBytes([]byte(tt.config))
+			require.NoError(t, err)
+
+			got, err := createPipelineRunPlan(tt.pipelineID, config)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSortDAGWorkflows(t *testing.T) {
+	got, err := sortDAGWorkflows(models.DagWorkflowListItemModel{
+		"deploy":     {DependsOn: []string{"unit_test", "ui_test"}},
+		"ui_test":    {DependsOn: []string{"build"}},
+		"unit_test":  {DependsOn: []string{"build"}},
+		"build":      {},
+		"lint":       {},
+		"screenshot": {DependsOn: []string{"ui_test"}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"build", "lint", "ui_test", "screenshot", "unit_test", "deploy"}, got)
+
+	_, err = sortDAGWorkflows(models.DagWorkflowListItemModel{
+		"a": {DependsOn: []string{"b"}},
+		"b": {DependsOn: []string{"a"}},
+	})
+	require.EqualError(t, err, "the workflow dependencies of the pipeline contain a cycle")
+}
+
+func TestRunPipeline(t *testing.T) {
+	configStr := `
+format_version: '13'
+pipelines:
+  staged:
+    stages:
+    - s1: {}
+    - s2: {}
+    - s3: {}
+  dag:
+    workflows:
+      a: {}
+      b: { depends_on: [a] }
+      c:
+        depends_on: [b]
+stages:
+  s1:
+    workflows:
+    - a: {}
+    - b:
+        run_if: "false"
+  s2:
+    run_if: "false"
+    workflows:
+    - c: {}
+  s3:
+    should_always_run: true
+    workflows:
+    - c: {}
+workflows:
+  a: {}
+  b: {}
+  c: {}
+`
+	config, _, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.NoError(t, configs.InitPaths())
+
+	t.Log("staged pipeline")
+	{
+		runner := NewWorkflowRunner(RunConfig{Config: config, Pipeline: "staged"}, nil)
+		results, err := runner.runPipeline(noOpTracker{})
+		require.NoError(t, err)
+		require.False(t, results.IsBuildFailed())
+		require.Equal(t, 3, len(results.Stages))
+
+		require.Equal(t, models.WorkflowRunStatusSuccess, results.Stages[0].Status)
+		require.Equal(t, models.WorkflowRunStatusSuccess, results.Stages[0].Workflows[0].Status)
+		require.Equal(t, models.WorkflowRunStatusSkipped, results.Stages[0].Workflows[1].Status)
+		require.Equal(t, models.WorkflowRunStatusSkipped, results.Stages[1].Status)
+		require.Equal(t, models.WorkflowRunStatusSkipped, results.Stages[1].Workflows[0].Status)
+		require.Equal(t, models.WorkflowRunStatusSuccess, results.Stages[2].Status)
+	}
+
+	t.Log("DAG pipeline")
+	{
+		require.NoError(t, configs.InitPaths())
+
+		runner := NewWorkflowRunner(RunConfig{Config: config, Pipeline: "dag"}, nil)
+		results, err := runner.runPipeline(noOpTracker{})
+		require.NoError(t, err)
+		require.False(t, results.IsBuildFailed())
+		require.Equal(t, 1, len(results.Stages))
+
+		var workflowIDs []string
+		for _, workflowResults := range results.WorkflowResults() {
+			require.Equal(t, models.WorkflowRunStatusSuccess, workflowResults.Status)
+			workflowIDs = append(workflowIDs, workflowResults.WorkflowID)
+		}
+		require.Equal(t, []string{"a", "b", "c"}, workflowIDs)
+	}
+}
+
+func TestRunPipeline_ParallelDAGWorkflows(t *testing.T) {
+	configStr := `
+format_version: '13'
+pipelines:
+  dag:
+    workflows:
+      a: {}
+      b: { depends_on: [a] }
+      c: { depends_on: [a] }
+      d: { depends_on: [a] }
+      e: { depends_on: [b, c] }
+      f: { depends_on: [d] }
+workflows:
+  a: {}
+  b: {}
+  c: {}
+  d:
+    steps:
+    - path::./non-existent-step: {}
+  e: {}
+  f: {}
+`
+	config, _, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.NoError(t, configs.InitPaths())
+
+	runner := NewWorkflowRunner(RunConfig{Config: config, Pipeline: "dag", MaxParallel: 2}, nil)
+	results, err := runner.runPipeline(noOpTracker{})
+	require.NoError(t, err)
+	require.True(t, results.IsBuildFailed())
+
+	statuses := map[string]models.WorkflowRunStatus{}
+	var workflowIDs []string
+	for _, workflowResults := range results.WorkflowResults() {
+		statuses[workflowResults.WorkflowID] = workflowResults.Status
+		workflowIDs = append(workflowIDs, workflowResults.WorkflowID)
+	}
+	require.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, workflowIDs)
+	require.Equal(t, map[string]models.WorkflowRunStatus{
+		"a": models.WorkflowRunStatusSuccess,
+		"b": models.WorkflowRunStatusSuccess,
+		"c": models.WorkflowRunStatusSuccess,
+		"d": models.WorkflowRunStatusFailed,
+		"e": models.WorkflowRunStatusSuccess,
+		"f": models.WorkflowRunStatusSkipped,
+	}, statuses)
+
+	entries, err := os.ReadDir(configs.BitriseWorkDirPath)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		require.False(t, strings.HasPrefix(entry.Name(), "workflow-"), "workspace not removed: %s", entry.Name())
+	}
+}
+
+func TestRunPipeline_Cancelled(t *testing.T) {
+	configStr := `
+format_version: '13'
+pipelines:
+  staged:
+    stages:
+    - s1: {}
+    - s2: {}
+  dag:
+    workflows:
+      a: {}
+      b: { depends_on: [a] }
+      c: { depends_on: [a] }
+      d: { depends_on: [b] }
+stages:
+  s1:
+    workflows:
+    - a: {}
+    - b: {}
+  s2:
+    should_always_run: true
+    workflows:
+    - c: {}
+workflows:
+  a: {}
+  b: {}
+  c: {}
+  d: {}
+`
+	config, _, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.NoError(t, configs.InitPaths())
+
+	t.Log("staged pipeline")
+	{
+		runner := NewWorkflowRunner(RunConfig{Config: config, Pipeline: "staged"}, nil)
+		results, err := runner.runPipeline(cancellingTracker{workflowID: "a", cancellation: runner.cancellation})
+		require.NoError(t, err)
+		require.True(t, results.IsBuildFailed())
+
+		require.Equal(t, models.WorkflowRunStatusSuccess, results.Stages[0].Workflows[0].Status)
+		require.Equal(t, models.WorkflowRunStatusAborted, results.Stages[0].Workflows[1].Status)
+		require.Equal(t, buildCancelledReason, results.Stages[0].Workflows[1].StatusReason)
+		require.Equal(t, models.WorkflowRunStatusAborted, results.Stages[1].Workflows[0].Status)
+		require.Equal(t, buildCancelledReason, results.Stages[1].Workflows[0].StatusReason)
+	}
+
+	t.Log("DAG pipeline")
+	{
+		require.NoError(t, configs.InitPaths())
+
+		runner := NewWorkflowRunner(RunConfig{Config: config, Pipeline: "dag", MaxParallel: 2}, nil)
+		results, err := runner.runPipeline(cancellingTracker{workflowID: "a", cancellation: runner.cancellation})
+		require.NoError(t, err)
+		require.True(t, results.IsBuildFailed())
+
+		statuses := map[string]models.WorkflowRunStatus{}
+		for _, workflowResults := range results.WorkflowResults() {
+			statuses[workflowResults.WorkflowID] = workflowResults.Status
+			if workflowResults.Status == models.WorkflowRunStatusAborted {
+				require.Equal(t, buildCancelledReason, workflowResults.StatusReason)
+			}
+		}
+		require.Equal(t, map[string]models.WorkflowRunStatus{
+			"a": models.WorkflowRunStatusSuccess,
+			"b": models.WorkflowRunStatusAborted,
+			"c": models.WorkflowRunStatusAborted,
+			"d": models.WorkflowRunStatusAborted,
+		}, statuses)
+	}
+}
+
+// cancellingTracker cancels the build when the given workflow starts.
+type cancellingTracker struct {
+	noOpTracker
+	workflowID   string
+	cancellation *buildCancellation
+}
+
+func (t cancellingTracker) SendWorkflowStarted(_ analytics.Properties, workflowID string, _ string) {
+	if workflowID == t.workflowID {
+		t.cancellation.cancel()
+	}
+}
+
+func TestBuildWorkspace(t *testing.T) {
+	require.NoError(t, configs.InitPaths())
+
+	defaultWorkspace := defaultBuildWorkspace()
+	require.Equal(t, configs.OutputEnvstorePath, defaultWorkspace.outputEnvstorePath)
+	require.Nil(t, defaultWorkspace.stepEnvironments(false))
+
+	workspace, err := newIsolatedBuildWorkspace("test", "Test")
+	require.NoError(t, err)
+	require.NotEqual(t, configs.InputEnvstorePath, workspace.inputEnvstorePath)
+	require.NotEqual(t, configs.BitriseWorkStepsDirPath, workspace.stepsDir)
+	require.FileExists(t, workspace.outputEnvstorePath)
+	require.DirExists(t, workspace.stepsDir)
+
+	require.Equal(t, []envmanModels.EnvironmentItemModel{
+		{configs.EnvstorePathEnvKey: workspace.outputEnvstorePath},
+		{configs.FormattedOutputPathEnvKey: workspace.formattedOutputPath},
+		{"BITRISE_TRIGGERED_WORKFLOW_ID": "test"},
+		{"BITRISE_TRIGGERED_WORKFLOW_TITLE": "Test"},
+		{"STEPLIB_BUILD_STATUS": "1"},
+		{"BITRISE_BUILD_STATUS": "1"},
+	}, workspace.stepEnvironments(true))
+
+	require.NoError(t, workspace.remove())
+	require.NoDirExists(t, workspace.workDir)
+}
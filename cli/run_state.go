@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/log"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/colorstring"
+)
+
+// runStatePath returns the state file of the workflow, scoped to the current project dir.
+func runStatePath(workflowID string) string {
+	projectHash := sha256.Sum256([]byte(configs.CurrentDir))
+	return filepath.Join(configs.GetBitriseRunStatesDirPath(), fmt.Sprintf("%s-%x.json", workflowID, projectHash[:6]))
+}
+
+func readRunState(pth string) (*models.WorkflowRunStateModel, error) {
+	content, err := os.ReadFile(pth)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var state models.WorkflowRunStateModel
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse run state (%s): %w", pth, err)
+	}
+	return &state, nil
+}
+
+// runStateRecorder persists the progress of a build as it runs, and provides the completed steps of the resumed build.
+type runStateRecorder struct {
+	pth   string
+	state models.WorkflowRunStateModel
+
+	resumedSteps []models.StepRunStateModel
+}
+
+// newRunStateRecorder starts recording the build. If a previous state is given, its completed steps are provided for
+// resuming the build, as long as the workflows and steps run before the failed step didn't change.
+func newRunStateRecorder(pth, workflowID string, plan models.WorkflowRunPlan, previousState *models.WorkflowRunStateModel) (*runStateRecorder, error) {
+	recorder := &runStateRecorder{
+		pth:   pth,
+		state: models.WorkflowRunStateModel{WorkflowID: workflowID, Plan: plan},
+	}
+
+	if previousState != nil {
+		if err := validateResumedPlan(plan, *previousState); err != nil {
+			return nil, err
+		}
+		recorder.resumedSteps = previousState.CompletedSteps
+	}
+
+	if err := recorder.write(); err != nil {
+		log.Warnf("Failed to save the run state: %s", err)
+	}
+
+	return recorder, nil
+}
+
+func validateResumedPlan(plan models.WorkflowRunPlan, previousState models.WorkflowRunStateModel) error {
+	var steps []models.StepRunStateModel
+	for _, workflowPlan := range plan.ExecutionPlan {
+		for _, stepPlan := range workflowPlan.Steps {
+			steps = append(steps, models.StepRunStateModel{WorkflowID: workflowPlan.WorkflowID, StepID: stepPlan.StepID})
+		}
+	}
+
+	if len(previousState.CompletedSteps) >= len(steps) {
+		return errors.New("the previous build has no failed step to resume from")
+	}
+
+	for idx, completedStep := range previousState.CompletedSteps {
+		if completedStep.WorkflowID != steps[idx].WorkflowID || completedStep.StepID != steps[idx].StepID {
+			return fmt.Errorf("the workflow changed before the failed step: step #%d was %s (%s), now it is %s (%s)",
+				idx, completedStep.StepID, completedStep.WorkflowID, steps[idx].StepID, steps[idx].WorkflowID)
+		}
+	}
+
+	return nil
+}
+
+// nextResumedStep returns the state of the next step, if it was completed by the resumed build.
+func (r *runStateRecorder) nextResumedStep() (models.StepRunStateModel, bool) {
+	if r == nil {
+		return models.StepRunStateModel{}, false
+	}
+
+	idx := len(r.state.CompletedSteps)
+	if r.state.FailedStep != nil || idx >= len(r.resumedSteps) {
+		return models.StepRunStateModel{}, false
+	}
+	return r.resumedSteps[idx], true
+}
+
+// recordStep stores the step's results until the first failed step.
+func (r *runStateRecorder) recordStep(stepState models.StepRunStateModel) {
+	if r == nil || r.state.FailedStep != nil {
+		return
+	}
+
+	if isStepRunStatusFailed(stepState.Results.Status) {
+		r.state.FailedStep = &stepState
+	} else {
+		r.state.CompletedSteps = append(r.state.CompletedSteps, stepState)
+	}
+
+	if err := r.write(); err != nil {
+		log.Warnf("Failed to save the run state: %s", err)
+	}
+}
+
+// finish removes the state of a successful build, there is nothing to resume.
+func (r *runStateRecorder) finish(buildRunResults models.BuildRunResultsModel) {
+	if r == nil {
+		return
+	}
+
+	if buildRunResults.IsBuildFailed() {
+		log.Printf("The build can be resumed from the failed step with: %s", colorstring.Cyan("bitrise run --resume "+r.state.WorkflowID))
+		return
+	}
+
+	if err := os.Remove(r.pth); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Warnf("Failed to remove the run state: %s", err)
+	}
+}
+
+func (r *runStateRecorder) write() error {
+	content, err := json.MarshalIndent(r.state, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.pth), 0755); err != nil {
+		return err
+	}
+	// The state contains the step outputs, which might be sensitive
+	return os.WriteFile(r.pth, content, 0600)
+}
+
+func isStepRunStatusFailed(status models.StepRunStatus) bool {
+	switch status {
+	case models.StepRunStatusCodeFailed,
+		models.StepRunStatusCodePreparationFailed,
+		models.StepRunStatusAbortedWithCustomTimeout,
+		models.StepRunStatusAbortedWithNoOutputTimeout,
+		models.StepRunStatusAbortedWithRunTimeout,
+		models.StepRunStatusAborted:
+		return true
+	default:
+		return false
+	}
+}
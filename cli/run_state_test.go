@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateResumedPlan(t *testing.T) {
+	plan := models.WorkflowRunPlan{ExecutionPlan: []models.WorkflowExecutionPlan{
+		{WorkflowID: "before", Steps: []models.StepExecutionPlan{{StepID: "git-clone"}}},
+		{WorkflowID: "build", Steps: []models.StepExecutionPlan{{StepID: "script"}, {StepID: "xcode-archive"}}},
+	}}
+
+	tests := []struct {
+		name           string
+		completedSteps []models.StepRunStateModel
+		wantErr        string
+	}{
+		{
+			name:           "Failed in the second workflow",
+			completedSteps: []models.StepRunStateModel{{WorkflowID: "before", StepID: "git-clone"}, {WorkflowID: "build", StepID: "script"}},
+		},
+		{
+			name:           "Failed at the first step",
+			completedSteps: nil,
+		},
+		{
+			name:           "Step changed before the failed step",
+			completedSteps: []models.StepRunStateModel{{WorkflowID: "before", StepID: "activate-ssh-key"}},
+			wantErr:        "the workflow changed before the failed step: step #0 was activate-ssh-key (before), now it is git-clone (before)",
+		},
+		{
+			name: "Nothing to resume",
+			completedSteps: []models.StepRunStateModel{
+				{WorkflowID: "before", StepID: "git-clone"},
+				{WorkflowID: "build", StepID: "script"},
+				{WorkflowID: "build", StepID: "xcode-archive"},
+			},
+			wantErr: "the previous build has no failed step to resume from",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateResumedPlan(plan, models.WorkflowRunStateModel{CompletedSteps: tt.completedSteps})
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRunStateRecorder(t *testing.T) {
+	pth := t.TempDir() + "/state.json"
+	plan := models.WorkflowRunPlan{ExecutionPlan: []models.WorkflowExecutionPlan{
+		{WorkflowID: "build", Steps: []models.StepExecutionPlan{{StepID: "script"}, {StepID: "xcode-archive"}, {StepID: "deploy"}}},
+	}}
+	recorder, err := newRunStateRecorder(pth, "build", plan, nil)
+	require.NoError(t, err)
+
+	_, isResumed := recorder.nextResumedStep()
+	require.False(t, isResumed)
+
+	scriptOutputs := []envmanModels.EnvironmentItemModel{{"OUTPUT": "value"}}
+	recorder.recordStep(models.StepRunStateModel{WorkflowID: "build", StepID: "script", Results: models.StepRunResultsModel{Status: models.StepRunStatusCodeSuccess}, OutputEnvironments: scriptOutputs})
+	recorder.recordStep(models.StepRunStateModel{WorkflowID: "build", StepID: "xcode-archive", Results: models.StepRunResultsModel{Status: models.StepRunStatusCodeFailed}})
+	recorder.recordStep(models.StepRunStateModel{WorkflowID: "build", StepID: "deploy", Results: models.StepRunResultsModel{Status: models.StepRunStatusCodeSkipped}})
+
+	state, err := readRunState(pth)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(state.CompletedSteps))
+	require.Equal(t, scriptOutputs, state.CompletedSteps[0].OutputEnvironments)
+	require.Equal(t, "xcode-archive", state.FailedStep.StepID)
+
+	resumedRecorder, err := newRunStateRecorder(pth, "build", plan, state)
+	require.NoError(t, err)
+
+	resumedStep, isResumed := resumedRecorder.nextResumedStep()
+	require.True(t, isResumed)
+	require.Equal(t, "script", resumedStep.StepID)
+	resumedRecorder.recordStep(resumedStep)
+
+	_, isResumed = resumedRecorder.nextResumedStep()
+	require.False(t, isResumed)
+
+	resumedRecorder.finish(models.BuildRunResultsModel{})
+	state, err = readRunState(pth)
+	require.NoError(t, err)
+	require.Nil(t, state)
+}
+
+func TestRunWorkflows_Resume(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	configStr := `
+format_version: '13'
+workflows:
+  build:
+    steps:
+    - path::./non-existent-step-1: {}
+    - path::./non-existent-step-2: {}
+`
+	config, _, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.NoError(t, configs.InitPaths())
+
+	runner := NewWorkflowRunner(RunConfig{Config: config, Workflow: "build"}, nil)
+	buildRunResults, err := runner.runWorkflows(noOpTracker{})
+	require.NoError(t, err)
+	require.True(t, buildRunResults.IsBuildFailed())
+
+	// Pretend that the first step succeeded
+	state, err := readRunState(runStatePath("build"))
+	require.NoError(t, err)
+	require.NotNil(t, state.FailedStep)
+	completedStep := *state.FailedStep
+	completedStep.Results.Status = models.StepRunStatusCodeSuccess
+	state.CompletedSteps = []models.StepRunStateModel{completedStep}
+	recorder := runStateRecorder{pth: runStatePath("build"), state: *state}
+	require.NoError(t, recorder.write())
+
+	require.NoError(t, configs.InitPaths())
+
+	runner = NewWorkflowRunner(RunConfig{Config: config, Workflow: "build", Resume: true}, nil)
+	buildRunResults, err = runner.runWorkflows(noOpTracker{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(buildRunResults.SuccessSteps))
+	require.Equal(t, 1, len(buildRunResults.FailedSteps))
+	require.Equal(t, 1, buildRunResults.FailedSteps[0].Idx)
+
+	state, err = readRunState(runStatePath("build"))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(state.CompletedSteps))
+	require.Equal(t, "path::./non-existent-step-2", state.FailedStep.StepID)
+}
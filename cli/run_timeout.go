@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+)
+
+// runTimeout is the deadline of the build or the running workflow, whichever expires first.
+type runTimeout struct {
+	deadline time.Time
+	timeout  time.Duration
+	// workflowID is empty if the deadline is set by the build timeout
+	workflowID string
+}
+
+// runTimeoutError is returned for steps aborted or not started because of the build or workflow timeout.
+type runTimeoutError struct {
+	timeout    time.Duration
+	workflowID string
+}
+
+func (e runTimeoutError) Error() string {
+	if e.workflowID != "" {
+		return fmt.Sprintf("workflow (%s) timed out after %s", e.workflowID, e.timeout)
+	}
+	return fmt.Sprintf("build timed out after %s", e.timeout)
+}
+
+func newBuildTimeout(timeout time.Duration) *runTimeout {
+	if timeout <= 0 {
+		return nil
+	}
+	return &runTimeout{deadline: time.Now().Add(timeout), timeout: timeout}
+}
+
+// withWorkflowTimeout returns the deadline of a workflow starting now, limited by the current deadline.
+func (t *runTimeout) withWorkflowTimeout(workflowID string, timeoutSeconds int) *runTimeout {
+	if timeoutSeconds <= 0 {
+		return t
+	}
+
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	deadline := time.Now().Add(timeout)
+	if t != nil && !deadline.Before(t.deadline) {
+		return t
+	}
+	return &runTimeout{deadline: deadline, timeout: timeout, workflowID: workflowID}
+}
+
+func (t *runTimeout) remaining() time.Duration {
+	if t == nil {
+		return -1
+	}
+	return time.Until(t.deadline)
+}
+
+func (t *runTimeout) expired() bool {
+	return t != nil && t.remaining() <= 0
+}
+
+// stepTimeout limits the step's own timeout (-1 if not set) to the remaining time.
+// The returned flag is true if the remaining time is the effective limit.
+func (t *runTimeout) stepTimeout(timeout time.Duration) (time.Duration, bool) {
+	if t == nil {
+		return timeout, false
+	}
+
+	remaining := t.remaining()
+	if timeout > 0 && timeout <= remaining {
+		return timeout, false
+	}
+	return remaining, true
+}
+
+func (t *runTimeout) err() error {
+	return runTimeoutError{timeout: t.timeout, workflowID: t.workflowID}
+}
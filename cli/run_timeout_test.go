@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/exitcode"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTimeout(t *testing.T) {
+	t.Log("no timeout")
+	{
+		var timeout *runTimeout
+		require.Nil(t, newBuildTimeout(0))
+		require.False(t, timeout.expired())
+
+		stepTimeout, isRunTimeout := timeout.stepTimeout(time.Minute)
+		require.Equal(t, time.Minute, stepTimeout)
+		require.False(t, isRunTimeout)
+	}
+
+	t.Log("build timeout limits the step timeout")
+	{
+		timeout := newBuildTimeout(time.Hour)
+		require.False(t, timeout.expired())
+
+		stepTimeout, isRunTimeout := timeout.stepTimeout(time.Minute)
+		require.Equal(t, time.Minute, stepTimeout)
+		require.False(t, isRunTimeout)
+
+		stepTimeout, isRunTimeout = timeout.stepTimeout(-1)
+		require.True(t, stepTimeout > 59*time.Minute)
+		require.True(t, isRunTimeout)
+
+		require.EqualError(t, timeout.err(), "build timed out after 1h0m0s")
+	}
+
+	t.Log("the earlier deadline wins")
+	{
+		timeout := newBuildTimeout(time.Hour)
+		require.Equal(t, timeout, timeout.withWorkflowTimeout("primary", 0))
+		require.Equal(t, timeout, timeout.withWorkflowTimeout("primary", 2*60*60))
+
+		workflowTimeout := timeout.withWorkflowTimeout("primary", 60)
+		require.Equal(t, "primary", workflowTimeout.workflowID)
+		require.EqualError(t, workflowTimeout.err(), "workflow (primary) timed out after 1m0s")
+
+		var noBuildTimeout *runTimeout
+		require.Equal(t, "primary", noBuildTimeout.withWorkflowTimeout("primary", 60).workflowID)
+	}
+}
+
+func TestRunWorkflows_Timeout(t *testing.T) {
+	configStr := `
+format_version: '13'
+workflows:
+  build:
+    timeout: 60
+    steps:
+    - path::./non-existent-step-1: {}
+    - path::./non-existent-step-2:
+        is_always_run: true
+`
+	config, _, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.NoError(t, configs.InitPaths())
+
+	runner := NewWorkflowRunner(RunConfig{Config: config, Workflow: "build"}, nil)
+	runner.timeout = &runTimeout{deadline: time.Now().Add(-time.Second), timeout: time.Minute}
+
+	buildRunResults, err := runner.runWorkflows(noOpTracker{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(buildRunResults.FailedSteps))
+	for _, stepResults := range buildRunResults.FailedSteps {
+		require.Equal(t, models.StepRunStatusAbortedWithRunTimeout, stepResults.Status)
+		require.Equal(t, "build timed out after 1m0s", stepResults.ErrorStr)
+	}
+	require.Equal(t, exitcode.CLIAbortedWithRunTimeout, buildRunResults.ExitCode())
+}
+
+func TestReadBuildTimeoutConfiguration(t *testing.T) {
+	t.Setenv(configs.BuildTimeoutEnvKey, "")
+
+	timeout, err := readBuildTimeoutConfiguration(0, nil)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), timeout)
+
+	timeout, err = readBuildTimeoutConfiguration(0, []envmanModels.EnvironmentItemModel{{configs.BuildTimeoutEnvKey: "120"}})
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Minute, timeout)
+
+	t.Setenv(configs.BuildTimeoutEnvKey, "30")
+	timeout, err = readBuildTimeoutConfiguration(0, nil)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, timeout)
+
+	timeout, err = readBuildTimeoutConfiguration(600, nil)
+	require.NoError(t, err)
+	require.Equal(t, 10*time.Minute, timeout)
+
+	t.Setenv(configs.BuildTimeoutEnvKey, "invalid")
+	_, err = readBuildTimeoutConfiguration(0, nil)
+	require.EqualError(t, err, "invalid configuration environment variable value $BITRISE_BUILD_TIMEOUT=invalid")
+
+	_, err = readBuildTimeoutConfiguration(-1, nil)
+	require.EqualError(t, err, "invalid timeout value (-1): can't be negative")
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/tracing"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWorkflows_ExportsTrace(t *testing.T) {
+	var spans []tracing.OTLPSpan
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var request tracing.ExportTraceServiceRequest
+		require.NoError(t, json.Unmarshal(body, &request))
+		for _, resourceSpans := range request.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				spans = append(spans, scopeSpans.Spans...)
+			}
+		}
+	}))
+	defer receiver.Close()
+
+	configStr := `
+format_version: '13'
+workflows:
+  setup:
+    steps:
+    - path::./non-existent-step-1: {}
+  build:
+    before_run:
+    - setup
+    steps:
+    - path::./non-existent-step-2:
+        is_always_run: true
+`
+	config, _, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.NoError(t, configs.InitPaths())
+
+	runner := NewWorkflowRunner(RunConfig{Config: config, Workflow: "build", OTLPEndpoint: receiver.URL}, nil)
+	_, err = runner.runWorkflows(noOpTracker{})
+	require.NoError(t, err)
+
+	require.Len(t, spans, 5)
+	build := spans[0]
+	require.Equal(t, "bitrise run build", build.Name)
+	require.Equal(t, "", build.ParentSpanID)
+
+	spansByParent := map[string][]tracing.OTLPSpan{}
+	for _, span := range spans {
+		require.Equal(t, build.TraceID, span.TraceID)
+		spansByParent[span.ParentSpanID] = append(spansByParent[span.ParentSpanID], span)
+	}
+
+	workflows := spansByParent[build.SpanID]
+	require.Len(t, workflows, 2)
+	for _, workflow := range workflows {
+		steps := spansByParent[workflow.SpanID]
+		require.Len(t, steps, 1)
+		require.Equal(t, 2, steps[0].Status.Code)
+	}
+}
+
+func TestReadOTLPEndpointConfiguration(t *testing.T) {
+	t.Setenv(configs.OTLPEndpointEnvKey, "")
+
+	endpoint, err := readOTLPEndpointConfiguration("", nil)
+	require.NoError(t, err)
+	require.Equal(t, "", endpoint)
+
+	endpoint, err = readOTLPEndpointConfiguration("", []envmanModels.EnvironmentItemModel{{configs.OTLPEndpointEnvKey: "http://inventory:4318"}})
+	require.NoError(t, err)
+	require.Equal(t, "http://inventory:4318", endpoint)
+
+	t.Setenv(configs.OTLPEndpointEnvKey, "http://env:4318")
+	endpoint, err = readOTLPEndpointConfiguration("", nil)
+	require.NoError(t, err)
+	require.Equal(t, "http://env:4318", endpoint)
+
+	endpoint, err = readOTLPEndpointConfiguration("http://flag:4318", nil)
+	require.NoError(t, err)
+	require.Equal(t, "http://flag:4318", endpoint)
+}
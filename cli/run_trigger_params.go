@@ -3,8 +3,13 @@ package cli
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
 
+	"github.com/bitrise-io/bitrise/gitinfo"
 	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/webhook"
 )
 
 // --------------------
@@ -15,6 +20,7 @@ import (
 type RunAndTriggerParamsModel struct {
 	// Run Params
 	WorkflowToRunID string `json:"workflow"`
+	PipelineToRunID string `json:"pipeline"`
 
 	// Trigger Params
 	TriggerPattern string `json:"pattern"`
@@ -25,6 +31,11 @@ type RunAndTriggerParamsModel struct {
 	PRReadyState   models.PullRequestReadyState `json:"pr-ready-state"`
 	Tag            string                       `json:"tag"`
 
+	CommitMessage string   `json:"commit-message"`
+	ChangedFiles  []string `json:"changed-files"`
+	PRLabels      []string `json:"pr-labels"`
+	PRComment     string   `json:"pr-comment"`
+
 	// Trigger Check Params
 	Format string `json:"format"`
 
@@ -45,7 +56,7 @@ func parseRunAndTriggerJSONParams(jsonParams string) (RunAndTriggerParamsModel,
 }
 
 func parseRunAndTriggerParams(
-	workflowToRunID,
+	workflowToRunID, pipelineToRunID,
 	triggerPattern,
 	pushBranch, prSourceBranch, prTargetBranch string, prReadyState models.PullRequestReadyState, tag,
 	format,
@@ -75,6 +86,9 @@ func parseRunAndTriggerParams(
 	if workflowToRunID != "" {
 		params.WorkflowToRunID = workflowToRunID
 	}
+	if pipelineToRunID != "" {
+		params.PipelineToRunID = pipelineToRunID
+	}
 
 	if triggerPattern != "" {
 		params.TriggerPattern = triggerPattern
@@ -117,11 +131,11 @@ func parseRunAndTriggerParams(
 }
 
 func parseRunParams(
-	workflowToRunID,
+	workflowToRunID, pipelineToRunID,
 	bitriseConfigPath, bitriseConfigBase64Data,
 	inventoryPath, inventoryBase64Data,
 	jsonParams, base64JSONParams string) (RunAndTriggerParamsModel, error) {
-	return parseRunAndTriggerParams(workflowToRunID, "", "", "", "", "", "", "", bitriseConfigPath, bitriseConfigBase64Data, inventoryPath, inventoryBase64Data, jsonParams, base64JSONParams)
+	return parseRunAndTriggerParams(workflowToRunID, pipelineToRunID, "", "", "", "", "", "", "", bitriseConfigPath, bitriseConfigBase64Data, inventoryPath, inventoryBase64Data, jsonParams, base64JSONParams)
 }
 
 func parseTriggerParams(
@@ -130,7 +144,7 @@ func parseTriggerParams(
 	bitriseConfigPath, bitriseConfigBase64Data,
 	inventoryPath, inventoryBase64Data,
 	jsonParams, base64JSONParams string) (RunAndTriggerParamsModel, error) {
-	return parseRunAndTriggerParams("", triggerPattern, pushBranch, prSourceBranch, prTargetBranch, prReadyState, tag, "", bitriseConfigPath, bitriseConfigBase64Data, inventoryPath, inventoryBase64Data, jsonParams, base64JSONParams)
+	return parseRunAndTriggerParams("", "", triggerPattern, pushBranch, prSourceBranch, prTargetBranch, prReadyState, tag, "", bitriseConfigPath, bitriseConfigBase64Data, inventoryPath, inventoryBase64Data, jsonParams, base64JSONParams)
 }
 
 func parseTriggerCheckParams(
@@ -140,5 +154,114 @@ func parseTriggerCheckParams(
 	bitriseConfigPath, bitriseConfigBase64Data,
 	inventoryPath, inventoryBase64Data,
 	jsonParams, base64JSONParams string) (RunAndTriggerParamsModel, error) {
-	return parseRunAndTriggerParams("", triggerPattern, pushBranch, prSourceBranch, prTargetBranch, prReadyState, tag, format, bitriseConfigPath, bitriseConfigBase64Data, inventoryPath, inventoryBase64Data, jsonParams, base64JSONParams)
+	return parseRunAndTriggerParams("", "", triggerPattern, pushBranch, prSourceBranch, prTargetBranch, prReadyState, tag, format, bitriseConfigPath, bitriseConfigBase64Data, inventoryPath, inventoryBase64Data, jsonParams, base64JSONParams)
+}
+
+// withTriggerConditionParams overrides the params matched against the commit_message, changed_files,
+// pull_request_label and pull_request_comment trigger conditions.
+func (params RunAndTriggerParamsModel) withTriggerConditionParams(commitMessage string, changedFiles, prLabels []string, prComment string) RunAndTriggerParamsModel {
+	if commitMessage != "" {
+		params.CommitMessage = commitMessage
+	}
+	if len(changedFiles) > 0 {
+		params.ChangedFiles = changedFiles
+	}
+	if len(prLabels) > 0 {
+		params.PRLabels = prLabels
+	}
+	if prComment != "" {
+		params.PRComment = prComment
+	}
+	return params
+}
+
+func (params RunAndTriggerParamsModel) triggerParams() models.TriggerParams {
+	return models.TriggerParams{
+		PushBranch:     params.PushBranch,
+		PRSourceBranch: params.PRSourceBranch,
+		PRTargetBranch: params.PRTargetBranch,
+		PRReadyState:   params.PRReadyState,
+		Tag:            params.Tag,
+		CommitMessage:  params.CommitMessage,
+		ChangedFiles:   params.ChangedFiles,
+		PRLabels:       params.PRLabels,
+		PRComment:      params.PRComment,
+	}
+}
+
+// withWebhookPayload fills the trigger params which are not set from the git provider's webhook payload.
+func (params RunAndTriggerParamsModel) withWebhookPayload(pth, provider string) (RunAndTriggerParamsModel, error) {
+	if provider == "" {
+		return params, errors.New("provider of the webhook payload is not specified")
+	}
+
+	payload, err := os.ReadFile(pth)
+	if err != nil {
+		return params, err
+	}
+
+	payloadParams, err := webhook.ParsePayload(provider, payload)
+	if err != nil {
+		return params, err
+	}
+
+	// For example the GitHub issue_comment event payload doesn't contain the pull request branches
+	isEventDerived := payloadParams.PushBranch != "" || payloadParams.PRSourceBranch != "" || payloadParams.PRTargetBranch != "" || payloadParams.Tag != ""
+	if !params.hasEventParams() && !isEventDerived {
+		return params, fmt.Errorf("the webhook payload doesn't contain the pull request branches, set them with the --%s and --%s flags", PRSourceBranchKey, PRTargetBranchKey)
+	}
+
+	return params.withDerivedTriggerParams(payloadParams), nil
+}
+
+// withGitRepository fills the trigger params which are not set from the local git repository containing dir,
+// the read repository state is returned too.
+func (params RunAndTriggerParamsModel) withGitRepository(dir, baseRef string) (RunAndTriggerParamsModel, gitinfo.Info, error) {
+	info, err := gitinfo.Read(dir, baseRef)
+	if err != nil {
+		return params, info, err
+	}
+
+	var gitParams models.TriggerParams
+	if !params.hasEventParams() {
+		if gitParams, err = info.TriggerParams(); err != nil {
+			return params, info, err
+		}
+	} else {
+		gitParams = models.TriggerParams{CommitMessage: info.CommitMessage, ChangedFiles: info.ChangedFiles}
+	}
+
+	return params.withDerivedTriggerParams(gitParams), info, nil
+}
+
+func (params RunAndTriggerParamsModel) hasEventParams() bool {
+	return params.TriggerPattern != "" || params.PushBranch != "" || params.PRSourceBranch != "" || params.PRTargetBranch != "" || params.Tag != ""
+}
+
+// withDerivedTriggerParams fills the trigger params which are not set, the event params are only used if none of them is set.
+func (params RunAndTriggerParamsModel) withDerivedTriggerParams(derived models.TriggerParams) RunAndTriggerParamsModel {
+	if !params.hasEventParams() {
+		params.PushBranch = derived.PushBranch
+		params.PRSourceBranch = derived.PRSourceBranch
+		params.PRTargetBranch = derived.PRTargetBranch
+		params.Tag = derived.Tag
+	}
+	if params.PRReadyState == "" {
+		params.PRReadyState = derived.PRReadyState
+	}
+
+	if params.CommitMessage == "" {
+		params.CommitMessage = derived.CommitMessage
+	}
+	if len(params.ChangedFiles) == 0 {
+		params.ChangedFiles = derived.ChangedFiles
+	}
+	if len(params.PRLabels) == 0 {
+		params.PRLabels = derived.PRLabels
+	}
+	if params.PRComment == "" {
+		params.PRComment = derived.PRComment
+	}
+
+	return params
 }
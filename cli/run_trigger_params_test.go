@@ -25,6 +25,7 @@ func TestParseRunAndTriggerJSONParams(t *testing.T) {
 	{
 		paramsMap := map[string]interface{}{
 			WorkflowKey: "primary",
+			PipelineKey: "release",
 
 			PatternKey:        "master",
 			PushBranchKey:     "deploy",
@@ -45,6 +46,7 @@ func TestParseRunAndTriggerJSONParams(t *testing.T) {
 		require.NoError(t, err)
 
 		require.Equal(t, "primary", params.WorkflowToRunID)
+		require.Equal(t, "release", params.PipelineToRunID)
 
 		require.Equal(t, "master", params.TriggerPattern)
 		require.Equal(t, "deploy", params.PushBranch)
@@ -85,6 +87,31 @@ func TestParseRunAndTriggerJSONParams(t *testing.T) {
 	}
 }
 
+func TestRunAndTriggerParamsModel_WithTriggerConditionParams(t *testing.T) {
+	params, err := parseRunAndTriggerJSONParams(toJSON(t, map[string]interface{}{
+		PushBranchKey:    "main",
+		CommitMessageKey: "Release",
+		ChangedFilesKey:  []string{"README.md"},
+		PRLabelsKey:      []string{"bug"},
+		PRCommentKey:     "LGTM",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, models.TriggerParams{
+		PushBranch:    "main",
+		CommitMessage: "Release",
+		ChangedFiles:  []string{"README.md"},
+		PRLabels:      []string{"bug"},
+		PRComment:     "LGTM",
+	}, params.triggerParams())
+
+	t.Log("flags override the json params")
+	params = params.withTriggerConditionParams("Release [deploy]", []string{"ios/Podfile", "android/build.gradle"}, nil, "")
+	require.Equal(t, "Release [deploy]", params.CommitMessage)
+	require.Equal(t, []string{"ios/Podfile", "android/build.gradle"}, params.ChangedFiles)
+	require.Equal(t, []string{"bug"}, params.PRLabels)
+	require.Equal(t, "LGTM", params.PRComment)
+}
+
 func TestParseRunAndTriggerParams(t *testing.T) {
 	t.Log("it parses cli params")
 	{
@@ -108,7 +135,7 @@ func TestParseRunAndTriggerParams(t *testing.T) {
 		base64JSONParams := ""
 
 		params, err := parseRunAndTriggerParams(
-			workflow,
+			workflow, "",
 			pattern,
 			pushBranch, prSourceBranch, prTargetBranch, prReadyState, tag,
 			format,
@@ -175,7 +202,7 @@ func TestParseRunAndTriggerParams(t *testing.T) {
 		jsonParams := toJSON(t, paramsMap)
 		base64JSONParams := ""
 
-		params, err := parseRunAndTriggerParams("", "", "", "", "", "", "", "", "", "", "", "", jsonParams, base64JSONParams)
+		params, err := parseRunAndTriggerParams("", "", "", "", "", "", "", "", "", "", "", "", "", jsonParams, base64JSONParams)
 		require.NoError(t, err)
 
 		require.Equal(t, workflow, params.WorkflowToRunID)
@@ -235,7 +262,7 @@ func TestParseRunAndTriggerParams(t *testing.T) {
 		jsonParams := ""
 		base64JSONParams := toBase64(toJSON(t, paramsMap))
 
-		params, err := parseRunAndTriggerParams("", "", "", "", "", "", "", "", "", "", "", "", jsonParams, base64JSONParams)
+		params, err := parseRunAndTriggerParams("", "", "", "", "", "", "", "", "", "", "", "", "", jsonParams, base64JSONParams)
 		require.NoError(t, err)
 
 		require.Equal(t, workflow, params.WorkflowToRunID)
@@ -295,7 +322,7 @@ func TestParseRunAndTriggerParams(t *testing.T) {
 		jsonParams := `{"workflow":"test","pr-ready-state":"draft"}`
 		base64JSONParams := toBase64(toJSON(t, paramsMap))
 
-		params, err := parseRunAndTriggerParams("", "", "", "", "", "", "", "", "", "", "", "", jsonParams, base64JSONParams)
+		params, err := parseRunAndTriggerParams("", "", "", "", "", "", "", "", "", "", "", "", "", jsonParams, base64JSONParams)
 		require.NoError(t, err)
 
 		require.Equal(t, "test", params.WorkflowToRunID)
@@ -338,7 +365,7 @@ func TestParseRunAndTriggerParams(t *testing.T) {
 		base64JSONParams := ""
 
 		params, err := parseRunAndTriggerParams(
-			workflow,
+			workflow, "",
 			pattern,
 			pushBranch, prSourceBranch, prTargetBranch, prReadyState, tag,
 			format,
@@ -371,6 +398,7 @@ func TestParseRunParams(t *testing.T) {
 	t.Log("it parses cli params")
 	{
 		workflow := "primary"
+		pipeline := "release"
 
 		bitriseConfigPath := "bitrise.yml"
 		bitriseConfigBase64Data := toBase64("bitrise.yml")
@@ -382,7 +410,7 @@ func TestParseRunParams(t *testing.T) {
 		base64JSONParams := ""
 
 		params, err := parseRunParams(
-			workflow,
+			workflow, pipeline,
 			bitriseConfigPath, bitriseConfigBase64Data,
 			inventoryPath, inventoryBase64Data,
 			jsonParams, base64JSONParams,
@@ -390,6 +418,7 @@ func TestParseRunParams(t *testing.T) {
 		require.NoError(t, err)
 
 		require.Equal(t, workflow, params.WorkflowToRunID)
+		require.Equal(t, pipeline, params.PipelineToRunID)
 
 		require.Equal(t, "", params.TriggerPattern)
 		require.Equal(t, "", params.PushBranch)
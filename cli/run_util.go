@@ -21,6 +21,7 @@ import (
 	"github.com/bitrise-io/bitrise/log/logwriter"
 	"github.com/bitrise-io/bitrise/models"
 	"github.com/bitrise-io/bitrise/stepruncmd"
+	"github.com/bitrise-io/bitrise/stepruncmd/timeoutcmd"
 	"github.com/bitrise-io/bitrise/tools"
 	"github.com/bitrise-io/bitrise/toolversions"
 	envman "github.com/bitrise-io/envman/cli"
@@ -54,10 +55,12 @@ func (r WorkflowRunner) runWorkflow(
 
 	workflowIDProperties := coreanalytics.Properties{analytics.WorkflowExecutionID: plan.UUID}
 	tracker.SendWorkflowStarted(buildIDProperties.Merge(workflowIDProperties), plan.WorkflowID, plan.WorkflowTitle)
+	r.trace.StartWorkflow(plan, time.Now())
 
 	results := r.activateAndRunSteps(plan, steplibSource, buildRunResults, environments, secrets, isLastWorkflow, tracker, workflowIDProperties)
 
 	tracker.SendWorkflowFinished(workflowIDProperties, results.IsBuildFailed())
+	r.trace.FinishWorkflow(plan.UUID, time.Now(), results.IsBuildFailed())
 	collectToolVersions(tracker)
 
 	return results
@@ -83,6 +86,8 @@ func (r WorkflowRunner) activateAndRunSteps(
 	runResultCollector := newBuildRunResultCollector(r.logger, tracker)
 	currentStepGroupID := ""
 
+	r.timeout = r.timeout.withWorkflowTimeout(plan.WorkflowID, r.config.Config.Workflows[plan.WorkflowID].Timeout)
+
 	// Global variables for restricting Step Bundle's environment variables for the given Step Bundle
 	currentStepBundleUUID := ""
 	// TODO: add the last step bundle's envs to environments
@@ -122,22 +127,31 @@ func (r WorkflowRunner) activateAndRunSteps(
 		stepIDProperties := coreanalytics.Properties{analytics.StepExecutionID: stepPlan.UUID}
 		stepStartedProperties := workflowIDProperties.Merge(stepIDProperties)
 
-		result := r.activateAndRunStep(
-			stepPlan.Step,
-			stepPlan.StepID,
-			idx,
-			defaultStepLibSource,
-			stepPlan.UUID,
-			tracker,
-			envsForStepRun,
-			secrets,
-			buildRunResults,
-			plan.IsSteplibOfflineMode,
-			stepPlan.ContainerID,
-			stepPlan.WithGroupUUID,
-			stepStartTime,
-			stepStartedProperties,
-		)
+		var result activateAndRunStepResult
+		resumedStep, isResumed := r.runState.nextResumedStep()
+		if isResumed {
+			result = activateAndRunStepResult{OutputEnvironments: resumedStep.OutputEnvironments}
+		} else if r.timeout.expired() {
+			result = newRunTimeoutStepResult(stepPlan, r.timeout.err())
+		} else {
+			result = r.activateAndRunStep(
+				stepPlan.Step,
+				stepPlan.StepID,
+				stepPlan.Retry,
+				idx,
+				defaultStepLibSource,
+				stepPlan.UUID,
+				tracker,
+				envsForStepRun,
+				secrets,
+				buildRunResults,
+				plan.IsSteplibOfflineMode,
+				stepPlan.ContainerID,
+				stepPlan.WithGroupUUID,
+				stepStartTime,
+				stepStartedProperties,
+			)
+		}
 
 		*environments = append(*environments, result.OutputEnvironments...)
 		if currentStepBundleUUID != "" {
@@ -156,17 +170,42 @@ func (r WorkflowRunner) activateAndRunSteps(
 
 		isLastStep := isLastWorkflow && isLastStepInWorkflow
 
-		runResultCollector.registerStepRunResults(&buildRunResults, stepPlan.UUID, stepStartTime, stepmanModels.StepModel{}, result.StepInfoPtr, idx,
-			result.StepRunStatus, result.StepRunExitCode, result.StepRunErr, isLastStep, result.PrintStepHeader, result.RedactedStepInputs, stepStartedProperties)
+		var stepResults models.StepRunResultsModel
+		if isResumed {
+			stepResults = runResultCollector.registerResumedStepRunResults(&buildRunResults, resumedStep.Results)
+		} else {
+			stepResults = runResultCollector.registerStepRunResults(&buildRunResults, stepPlan.UUID, stepStartTime, stepmanModels.StepModel{}, result.StepInfoPtr, idx,
+				result.StepRunStatus, result.StepRunExitCode, result.StepRunErr, isLastStep, result.PrintStepHeader, result.RedactedStepInputs, result.Attempts, result.LogPath, stepStartedProperties)
+			r.trace.AddStep(plan.UUID, stepPlan.UUID, stepResults)
+		}
+
+		r.runState.recordStep(models.StepRunStateModel{
+			WorkflowID:         plan.WorkflowID,
+			StepID:             stepPlan.StepID,
+			Results:            stepResults,
+			OutputEnvironments: result.OutputEnvironments,
+		})
 
-		if err := bitrise.SetBuildFailedEnv(buildRunResults.IsBuildFailed()); err != nil {
-			log.Error("Failed to set Build Status envs")
+		if r.workspace == nil {
+			if err := bitrise.SetBuildFailedEnv(buildRunResults.IsBuildFailed()); err != nil {
+				log.Error("Failed to set Build Status envs")
+			}
 		}
 	}
 
 	return buildRunResults
 }
 
+// newRunTimeoutStepResult is the result of a step not started because the build or workflow timed out.
+func newRunTimeoutStepResult(stepPlan models.StepExecutionPlan, err error) activateAndRunStepResult {
+	step := stepPlan.Step
+	if step.Title == nil || *step.Title == "" {
+		step.Title = pointers.NewStringPtr(stepPlan.StepID)
+	}
+	stepInfo := stepmanModels.StepInfoModel{ID: stepPlan.StepID, Step: step}
+	return newActivateAndRunStepResult(step, stepInfo, models.StepRunStatusAbortedWithRunTimeout, 1, err, true, map[string]string{}, nil)
+}
+
 type activateAndRunStepResult struct {
 	Step               stepmanModels.StepModel
 	StepInfoPtr        stepmanModels.StepInfoModel
@@ -176,6 +215,8 @@ type activateAndRunStepResult struct {
 	PrintStepHeader    bool
 	RedactedStepInputs map[string]string
 	OutputEnvironments []envmanModels.EnvironmentItemModel
+	Attempts           []models.StepRunAttemptModel
+	LogPath            string
 }
 
 func newActivateAndRunStepResult(step stepmanModels.StepModel, stepInfoPtr stepmanModels.StepInfoModel, stepRunStatus models.StepRunStatus, stepRunExitCode int, stepRunErr error, printStepHeader bool, redactedStepInputs map[string]string, outputEnvironments []envmanModels.EnvironmentItemModel) activateAndRunStepResult {
@@ -185,6 +226,7 @@ func newActivateAndRunStepResult(step stepmanModels.StepModel, stepInfoPtr stepm
 func (r WorkflowRunner) activateAndRunStep(
 	step stepmanModels.StepModel,
 	stepID string,
+	retry *models.StepRetryModel,
 	stepIDx int,
 	defaultStepLibSource string,
 	stepExecutionID string,
@@ -223,7 +265,7 @@ func (r WorkflowRunner) activateAndRunStep(
 			return newActivateAndRunStepResult(mergedStep, stepInfoPtr, models.StepRunStatusCodePreparationFailed, 1, err, false, map[string]string{}, nil)
 		}
 
-		isRun, err := bitrise.EvaluateTemplateToBool(*mergedStep.RunIf, configs.IsCIMode, configs.IsPullRequestMode, buildRunResults, runIfEnvList)
+		isRun, err := bitrise.EvaluateTemplateToBool(*mergedStep.RunIf, r.config.Modes.CIMode, r.config.Modes.PRMode, buildRunResults, runIfEnvList)
 		if err != nil {
 			return newActivateAndRunStepResult(mergedStep, stepInfoPtr, models.StepRunStatusCodePreparationFailed, 1, err, false, map[string]string{}, nil)
 		}
@@ -239,6 +281,10 @@ func (r WorkflowRunner) activateAndRunStep(
 		log.Warnf("Step (%s) mergedStep.IsAlwaysRun is nil, should not!", stepIDData.IDorURI)
 	}
 
+	if r.cancellation.isCancelled() && !isAlwaysRun {
+		return newActivateAndRunStepResult(mergedStep, stepInfoPtr, models.StepRunStatusAborted, 0, errBuildCancelled, false, map[string]string{}, nil)
+	}
+
 	if buildRunResults.IsBuildFailed() && !isAlwaysRun {
 		return newActivateAndRunStepResult(mergedStep, stepInfoPtr, models.StepRunStatusCodeSkipped, 0, nil, false, map[string]string{}, nil)
 	}
@@ -259,7 +305,15 @@ func (r WorkflowRunner) activateAndRunStep(
 	// Run the step
 	tracker.SendStepStartedEvent(stepStartedProperties, prepareAnalyticsStepInfo(mergedStep, stepInfoPtr), redactedInputsWithType, redactedOriginalInputs)
 
-	exit, outEnvironments, stepRunErr := r.runStep(stepExecutionID, mergedStep, stepIDData, stepDir, stepDeclaredEnvironments, stepSecretValues, containerID, groupID)
+	stepLog, err := r.createStepLogFile(buildRunResults.ResultsCount(), stepIDData.IDorURI)
+	if err != nil {
+		log.Warnf("Failed to create the log file of the step: %s", err)
+	}
+	defer stepLog.close()
+
+	exit, outEnvironments, attempts, stepRunErr := r.runStepWithRetry(retry, *stepInfoPtr.Step.Title, func() (int, []envmanModels.EnvironmentItemModel, error) {
+		return r.runStep(stepExecutionID, mergedStep, stepIDData, stepDir, stepDeclaredEnvironments, stepSecretValues, containerID, groupID, stepLog)
+	})
 
 	if stepTestDir != "" {
 		if err := addTestMetadata(stepTestDir, models.TestResultStepInfo{Number: stepIDx, Title: *mergedStep.Title, ID: stepIDData.IDorURI, Version: stepIDData.Version}); err != nil {
@@ -267,19 +321,28 @@ func (r WorkflowRunner) activateAndRunStep(
 		}
 	}
 
-	if err := tools.EnvmanClear(configs.OutputEnvstorePath); err != nil {
+	if err := tools.EnvmanClear(r.currentWorkspace().outputEnvstorePath); err != nil {
 		log.Errorf("Failed to clear output envstore, error: %s", err)
 	}
 
 	if stepRunErr != nil {
 		if *mergedStep.IsSkippable {
-			return newActivateAndRunStepResult(mergedStep, stepInfoPtr, models.StepRunStatusCodeFailedSkippable, exit, stepRunErr, false, redactedStepInputs, outEnvironments)
+			result := newActivateAndRunStepResult(mergedStep, stepInfoPtr, models.StepRunStatusCodeFailedSkippable, exit, stepRunErr, false, redactedStepInputs, outEnvironments)
+			result.Attempts = attempts
+			result.LogPath = stepLog.path()
+			return result
 		} else {
-			return newActivateAndRunStepResult(mergedStep, stepInfoPtr, models.StepRunStatusCodeFailed, exit, stepRunErr, false, redactedStepInputs, outEnvironments)
+			result := newActivateAndRunStepResult(mergedStep, stepInfoPtr, models.StepRunStatusCodeFailed, exit, stepRunErr, false, redactedStepInputs, outEnvironments)
+			result.Attempts = attempts
+			result.LogPath = stepLog.path()
+			return result
 		}
 	}
 
-	return newActivateAndRunStepResult(mergedStep, stepInfoPtr, models.StepRunStatusCodeSuccess, 0, nil, false, redactedStepInputs, outEnvironments)
+	result := newActivateAndRunStepResult(mergedStep, stepInfoPtr, models.StepRunStatusCodeSuccess, 0, nil, false, redactedStepInputs, outEnvironments)
+	result.Attempts = attempts
+	result.LogPath = stepLog.path()
+	return result
 }
 
 type activateStepResult struct {
@@ -325,19 +388,22 @@ func (r WorkflowRunner) activateStep(
 
 	//
 	// Activating the step
-	if err := bitrise.CleanupStepWorkDir(); err != nil {
+	workspace := r.currentWorkspace()
+	if err := workspace.cleanupStepWorkDir(); err != nil {
 		return newActivateStepResult(stepmanModels.StepModel{}, stepInfoPtr, stepIDData, "", err)
 	}
 
-	stepDir := configs.BitriseWorkStepsDirPath
+	stepDir := workspace.stepsDir
 
 	isStepLibUpdated := false
 	if stepIDData.SteplibSource != "" {
 		isStepLibUpdated = buildRunResults.IsStepLibUpdated(stepIDData.SteplibSource)
 	}
 
+	stepActivationMutex.Lock()
 	activator := newStepActivator()
-	stepYMLPth, didStepLibUpdate, err := activator.activateStep(stepIDData, isStepLibUpdated, stepDir, configs.BitriseWorkDirPath, &stepInfoPtr, isStepLibOfflineMode)
+	stepYMLPth, didStepLibUpdate, err := activator.activateStep(stepIDData, isStepLibUpdated, stepDir, workspace.workDir, &stepInfoPtr, isStepLibOfflineMode)
+	stepActivationMutex.Unlock()
 	if didStepLibUpdate {
 		buildRunResults.StepmanUpdates[stepIDData.SteplibSource]++
 	}
@@ -418,16 +484,17 @@ func (r WorkflowRunner) prepareEnvsForStepRun(
 	buildRunResults models.BuildRunResultsModel,
 	environments []envmanModels.EnvironmentItemModel,
 ) prepareEnvsForStepRunResult {
-	if err := tools.EnvmanInit(configs.InputEnvstorePath, true); err != nil {
+	workspace := r.currentWorkspace()
+	if err := tools.EnvmanInit(workspace.inputEnvstorePath, true); err != nil {
 		return newPrepareEnvsForStepRunResult(nil, nil, nil, nil, nil, "", err)
 	}
 
-	if err := tools.EnvmanAddEnvs(configs.InputEnvstorePath, environments); err != nil {
+	if err := tools.EnvmanAddEnvs(workspace.inputEnvstorePath, environments); err != nil {
 		return newPrepareEnvsForStepRunResult(nil, nil, nil, nil, nil, "", err)
 	}
 
 	// beside of the envs coming from the current parent process these will be added as an extra
-	var additionalEnvironments []envmanModels.EnvironmentItemModel
+	additionalEnvironments := workspace.stepEnvironments(buildRunResults.IsBuildFailed())
 
 	// add this environment variable so all child processes can connect their events to their step lifecycle events
 	additionalEnvironments = append(additionalEnvironments, envmanModels.EnvironmentItemModel{
@@ -468,8 +535,8 @@ func (r WorkflowRunner) prepareEnvsForStepRun(
 		environment:       environmentItemModels,
 		inputs:            stepInputs,
 		buildRunResults:   buildRunResults,
-		isCIMode:          configs.IsCIMode,
-		isPullRequestMode: configs.IsPullRequestMode,
+		isCIMode:          r.config.Modes.CIMode,
+		isPullRequestMode: r.config.Modes.PRMode,
 	}, envSource)
 	if err != nil {
 		err = fmt.Errorf("failed to prepare step environment variables: %s", err)
@@ -516,6 +583,7 @@ func (r WorkflowRunner) runStep(
 	secrets []string,
 	containerID string,
 	groupID string,
+	stepLog *stepLogFile,
 ) (int, []envmanModels.EnvironmentItemModel, error) {
 	log.Debugf("[BITRISE_CLI] - Try running step: %s (%s)", stepIDData.IDorURI, stepIDData.Version)
 
@@ -536,11 +604,12 @@ func (r WorkflowRunner) runStep(
 			fmt.Errorf("Failed to install Step dependency, error: %s", err)
 	}
 
-	if err := tools.EnvmanInit(configs.InputEnvstorePath, true); err != nil {
+	workspace := r.currentWorkspace()
+	if err := tools.EnvmanInit(workspace.inputEnvstorePath, true); err != nil {
 		return 1, []envmanModels.EnvironmentItemModel{}, err
 	}
 
-	if err := tools.EnvmanAddEnvs(configs.InputEnvstorePath, environments); err != nil {
+	if err := tools.EnvmanAddEnvs(workspace.inputEnvstorePath, environments); err != nil {
 		return 1, []envmanModels.EnvironmentItemModel{}, err
 	}
 
@@ -553,8 +622,8 @@ func (r WorkflowRunner) runStep(
 		bitriseSourceDir = configs.CurrentDir
 	}
 
-	if exit, err := r.executeStep(stepUUID, step, stepIDData, stepDir, bitriseSourceDir, secrets, containerID, groupID); err != nil {
-		stepOutputs, envErr := bitrise.CollectEnvironmentsFromFile(configs.OutputEnvstorePath)
+	if exit, err := r.executeStep(stepUUID, step, stepIDData, stepDir, bitriseSourceDir, secrets, containerID, groupID, stepLog); err != nil {
+		stepOutputs, envErr := bitrise.CollectEnvironmentsFromFile(workspace.outputEnvstorePath)
 		if envErr != nil {
 			return 1, []envmanModels.EnvironmentItemModel{}, envErr
 		}
@@ -576,7 +645,7 @@ func (r WorkflowRunner) runStep(
 		return exit, updatedStepOutputs, err
 	}
 
-	stepOutputs, err := bitrise.CollectEnvironmentsFromFile(configs.OutputEnvstorePath)
+	stepOutputs, err := bitrise.CollectEnvironmentsFromFile(workspace.outputEnvstorePath)
 	if err != nil {
 		return 1, []envmanModels.EnvironmentItemModel{}, err
 	}
@@ -607,6 +676,7 @@ func (r WorkflowRunner) executeStep(
 	secrets []string,
 	containerID string,
 	groupID string,
+	stepLog *stepLogFile,
 ) (int, error) {
 
 	toolkitForStep := toolkits.ToolkitForStep(step, r.logger)
@@ -629,6 +699,11 @@ func (r WorkflowRunner) executeStep(
 		timeout = time.Duration(timeoutSeconds) * time.Second
 	}
 
+	timeout, isRunTimeout := r.timeout.stepTimeout(timeout)
+	if isRunTimeout && timeout <= 0 {
+		return 1, r.timeout.err()
+	}
+
 	noOutputTimeout := r.config.Modes.NoOutputTimeout
 	if step.NoOutputTimeout != nil {
 		noOutputTimeout = time.Duration(*step.NoOutputTimeout) * time.Second
@@ -644,7 +719,7 @@ func (r WorkflowRunner) executeStep(
 	opts.ProducerID = stepUUID
 	opts.DebugLogEnabled = true
 	logger := log.NewLogger(opts)
-	stdout := logwriter.NewLogWriter(logger)
+	stdout := stepLog.wrap(logwriter.NewLogWriter(logger))
 
 	var name string
 	var args []string
@@ -652,7 +727,7 @@ func (r WorkflowRunner) executeStep(
 
 	containerDef := r.ContainerDefinition(containerID)
 	if containerDef != nil {
-		envs, err = envman.ReadAndEvaluateEnvs(configs.InputEnvstorePath, &docker.EnvironmentSource{
+		envs, err = envman.ReadAndEvaluateEnvs(r.currentWorkspace().inputEnvstorePath, &docker.EnvironmentSource{
 			Logger: logger,
 		})
 		if err != nil {
@@ -671,10 +746,10 @@ func (r WorkflowRunner) executeStep(
 		cmd := stepruncmd.New(name, args, bitriseSourceDir, envs, stepSecrets, timeout, noOutputTimeout, stdout, logV2.NewLogger())
 
 		logger.Infof("Step is running in container: %s", containerDef.Image)
-		return cmd.Run()
+		return r.runStepCmd(cmd, isRunTimeout)
 	}
 
-	envs, err = envman.ReadAndEvaluateEnvs(configs.InputEnvstorePath, &envmanEnv.DefaultEnvironmentSource{})
+	envs, err = envman.ReadAndEvaluateEnvs(r.currentWorkspace().inputEnvstorePath, &envmanEnv.DefaultEnvironmentSource{})
 	if err != nil {
 		return 1, fmt.Errorf("failed to read command environment: %w", err)
 	}
@@ -686,7 +761,24 @@ func (r WorkflowRunner) executeStep(
 
 	cmd := stepruncmd.New(name, args, bitriseSourceDir, envs, stepSecrets, timeout, noOutputTimeout, stdout, logV2.NewLogger())
 
-	return cmd.Run()
+	return r.runStepCmd(cmd, isRunTimeout)
+}
+
+// runStepCmd runs the step command, and reports the build or workflow timeout if it aborted the step.
+// Steps started after the build was cancelled (is_always_run steps) are not terminated by the cancellation.
+func (r WorkflowRunner) runStepCmd(cmd stepruncmd.Cmd, isRunTimeout bool) (int, error) {
+	if r.cancellation != nil && !r.cancellation.isCancelled() {
+		cmd.SetCancel(r.cancellation.done, r.cancellation.gracePeriod)
+	}
+
+	exitCode, err := cmd.Run()
+
+	var timeoutErr timeoutcmd.TimeoutError
+	if isRunTimeout && errors.As(err, &timeoutErr) {
+		return exitCode, r.timeout.err()
+	}
+
+	return exitCode, err
 }
 
 func (r WorkflowRunner) startContainersForStepGroup(containerID string, serviceIDs []string, environments []envmanModels.EnvironmentItemModel, groupID, workflowTitle string) {
@@ -694,14 +786,15 @@ func (r WorkflowRunner) startContainersForStepGroup(containerID string, serviceI
 		return
 	}
 
-	if err := tools.EnvmanInit(configs.InputEnvstorePath, true); err != nil {
+	inputEnvstorePath := r.currentWorkspace().inputEnvstorePath
+	if err := tools.EnvmanInit(inputEnvstorePath, true); err != nil {
 		log.Debugf("Couldn't initialize envman.")
 	}
-	if err := tools.EnvmanAddEnvs(configs.InputEnvstorePath, environments); err != nil {
+	if err := tools.EnvmanAddEnvs(inputEnvstorePath, environments); err != nil {
 		log.Debugf("Couldn't add envs.")
 	}
 
-	envList, err := tools.EnvmanReadEnvList(configs.InputEnvstorePath)
+	envList, err := tools.EnvmanReadEnvList(inputEnvstorePath)
 	if err != nil {
 		log.Debugf("Couldn't read envs from envman.")
 	}
@@ -939,6 +1032,9 @@ func CreateBitriseConfigFromCLIParams(bitriseConfigBase64Data, bitriseConfigPath
 			if err != nil {
 				return models.BitriseDataModel{}, warnings, fmt.Errorf("failed to create config module merger: %w", err)
 			}
+			if err := useConfigLock(merger, bitriseConfigPath); err != nil {
+				return models.BitriseDataModel{}, warnings, err
+			}
 			mergedConfigContent, _, err := merger.MergeConfig(bitriseConfigPath)
 			if err != nil {
 				return models.BitriseDataModel{}, []string{}, fmt.Errorf("failed to merge Bitrise config (%s): %w", bitriseConfigPath, err)
@@ -1130,7 +1226,7 @@ func logStepStarted(logger log.Logger, stepInfo stepmanModels.StepInfoModel, ste
 		Toolkit:     toolkits.ToolkitForStep(step, logger).ToolkitName(),
 		StartTime:   stepStartTime.Format(time.RFC3339),
 	}
-	log.PrintStepStartedEvent(params)
+	logger.PrintStepStartedEvent(params)
 }
 
 func prepareAnalyticsStepInfo(step stepmanModels.StepModel, stepInfoPtr stepmanModels.StepInfoModel) analytics.StepInfo {
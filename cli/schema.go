@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bitrise-io/bitrise/schema"
+	"github.com/urfave/cli"
+)
+
+var schemaCommand = cli.Command{
+	Name:   "schema",
+	Usage:  "Prints the JSON Schema of the bitrise.yml, which can be used by editors to validate and autocomplete the config.",
+	Action: printSchema,
+}
+
+func printSchema(_ *cli.Context) error {
+	content, err := schema.Generate().Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to generate schema: %w", err)
+	}
+	fmt.Println(string(content))
+	return nil
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/bitrise-io/bitrise/log"
 	"github.com/bitrise-io/stepman/activator"
@@ -9,6 +10,10 @@ import (
 	"github.com/bitrise-io/stepman/stepid"
 )
 
+// stepActivationMutex serializes the step activations of parallel builds,
+// as they share the local StepLib and step caches.
+var stepActivationMutex sync.Mutex
+
 type stepActivator struct {
 }
 
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/log"
+)
+
+var stepLogFileNameInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// stepLogFile receives the output of a step (secret-redacted, if secret filtering is enabled) in addition to the console log.
+type stepLogFile struct {
+	file *os.File
+}
+
+// buildStepLogDir returns the dir of the step log files of the given workflow's build, or an empty string if step logs are not written.
+// The workflows of a pipeline write their step logs into their own subdirectories.
+func (r WorkflowRunner) buildStepLogDir(workflowID string) string {
+	dir := r.config.StepLogDir
+	if dir == "" {
+		deployDir := os.Getenv(configs.BitriseDeployDirEnvKey)
+		if deployDir == "" {
+			return ""
+		}
+		dir = filepath.Join(deployDir, "logs")
+	}
+
+	if r.sharedData != nil {
+		dir = filepath.Join(dir, workflowID)
+	}
+	return dir
+}
+
+// createStepLogFile creates the log file of the build's idx-th step, it returns nil if step logs are not written.
+func (r WorkflowRunner) createStepLogFile(idx int, stepID string) (*stepLogFile, error) {
+	if r.stepLogDir == "" {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(r.stepLogDir, 0755); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%d-%s.log", idx, stepLogFileNameInvalidChars.ReplaceAllString(stepID, "_"))
+	file, err := os.Create(filepath.Join(r.stepLogDir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &stepLogFile{file: file}, nil
+}
+
+func (l *stepLogFile) path() string {
+	if l == nil {
+		return ""
+	}
+	return l.file.Name()
+}
+
+// wrap returns a writer writing both to the console log writer and to the log file.
+func (l *stepLogFile) wrap(console io.WriteCloser) io.WriteCloser {
+	if l == nil {
+		return console
+	}
+	return stepLogWriter{console: console, file: l.file}
+}
+
+func (l *stepLogFile) close() {
+	if l == nil {
+		return
+	}
+	if err := l.file.Close(); err != nil {
+		log.Warnf("Failed to close the log file of the step: %s", err)
+	}
+}
+
+// stepLogWriter closes only the console log writer, the log file is kept open for the retries of the step.
+type stepLogWriter struct {
+	console io.WriteCloser
+	file    io.Writer
+}
+
+func (w stepLogWriter) Write(p []byte) (int, error) {
+	if _, err := w.file.Write(p); err != nil {
+		log.Debugf("Failed to write the log file of the step: %s", err)
+	}
+	return w.console.Write(p)
+}
+
+func (w stepLogWriter) Close() error {
+	return w.console.Close()
+}
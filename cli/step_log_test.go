@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/stretchr/testify/require"
+)
+
+type closeRecorder struct {
+	written []byte
+	closed  bool
+}
+
+func (w *closeRecorder) Write(p []byte) (int, error) {
+	w.written = append(w.written, p...)
+	return len(p), nil
+}
+
+func (w *closeRecorder) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestBuildStepLogDir(t *testing.T) {
+	t.Setenv(configs.BitriseDeployDirEnvKey, "/deploy")
+
+	runner := NewWorkflowRunner(RunConfig{}, nil)
+	require.Equal(t, "/deploy/logs", runner.buildStepLogDir("primary"))
+
+	runner.sharedData = &pipelineSharedDataStore{}
+	require.Equal(t, "/deploy/logs/primary", runner.buildStepLogDir("primary"))
+
+	runner = NewWorkflowRunner(RunConfig{StepLogDir: "/logs"}, nil)
+	require.Equal(t, "/logs", runner.buildStepLogDir("primary"))
+
+	t.Setenv(configs.BitriseDeployDirEnvKey, "")
+	require.Equal(t, "", NewWorkflowRunner(RunConfig{}, nil).buildStepLogDir("primary"))
+}
+
+func TestStepLogFile(t *testing.T) {
+	runner := NewWorkflowRunner(RunConfig{}, nil)
+
+	t.Log("step logs are disabled")
+	{
+		stepLog, err := runner.createStepLogFile(0, "script")
+		require.NoError(t, err)
+		require.Nil(t, stepLog)
+		require.Equal(t, "", stepLog.path())
+
+		console := &closeRecorder{}
+		require.Equal(t, console, stepLog.wrap(console))
+		stepLog.close()
+	}
+
+	t.Log("the output is written both to the console and the log file")
+	{
+		runner.stepLogDir = filepath.Join(t.TempDir(), "logs")
+		stepLog, err := runner.createStepLogFile(3, "path::./steps/my-step")
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(runner.stepLogDir, "3-path_._steps_my-step.log"), stepLog.path())
+
+		console := &closeRecorder{}
+		writer := stepLog.wrap(console)
+		_, err = writer.Write([]byte("attempt 1\n"))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		require.True(t, console.closed)
+
+		writer = stepLog.wrap(&closeRecorder{})
+		_, err = writer.Write([]byte("attempt 2\n"))
+		require.NoError(t, err)
+		stepLog.close()
+
+		require.Equal(t, "attempt 1\n", string(console.written))
+		content, err := os.ReadFile(stepLog.path())
+		require.NoError(t, err)
+		require.Equal(t, "attempt 1\nattempt 2\n", string(content))
+	}
+}
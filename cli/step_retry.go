@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/bitrise-io/bitrise/log"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/bitrise/tools"
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+type stepRunFunc func() (int, []envmanModels.EnvironmentItemModel, error)
+
+// runStepWithRetry runs the step, and runs it again while its retry policy allows it.
+// The attempts are only returned if the step was retried.
+func (r WorkflowRunner) runStepWithRetry(retry *models.StepRetryModel, stepTitle string, run stepRunFunc) (int, []envmanModels.EnvironmentItemModel, []models.StepRunAttemptModel, error) {
+	var attempts []models.StepRunAttemptModel
+
+	for attempt := 1; ; attempt++ {
+		startTime := time.Now()
+		exitCode, outputs, err := run()
+		if retry == nil {
+			return exitCode, outputs, nil, err
+		}
+
+		status := models.StepRunStatusCodeSuccess
+		errStr := ""
+		if err != nil {
+			status, _, _ = stepRunStatusFromError(models.StepRunStatusCodeFailed, exitCode, err)
+			errStr = err.Error()
+		}
+		attempts = append(attempts, models.StepRunAttemptModel{
+			Status:    status,
+			ExitCode:  exitCode,
+			ErrorStr:  errStr,
+			StartTime: startTime,
+			RunTime:   time.Since(startTime),
+		})
+
+		isLastAttempt := err == nil || attempt >= retry.Attempts || !retry.ShouldRetry(status, exitCode)
+		if isLastAttempt || r.cancellation.isCancelled() || r.timeout.expired() {
+			return exitCode, outputs, retriedAttempts(attempts), err
+		}
+
+		// The backoff doesn't extend the build or workflow timeout
+		backoff := retry.BackoffBefore(attempt + 1)
+		if remaining := r.timeout.remaining(); r.timeout != nil && backoff > remaining {
+			backoff = remaining
+		}
+		log.Warnf("Step (%s) finished with status %s, retrying in %s (attempt %d of %d)", stepTitle, status, backoff, attempt+1, retry.Attempts)
+		r.waitBeforeRetry(backoff)
+
+		if r.cancellation.isCancelled() {
+			return exitCode, outputs, retriedAttempts(attempts), err
+		}
+		if r.timeout.expired() {
+			return exitCode, outputs, retriedAttempts(attempts), r.timeout.err()
+		}
+
+		// The outputs of the failed attempt are dropped
+		if err := tools.EnvmanClear(r.currentWorkspace().outputEnvstorePath); err != nil {
+			log.Errorf("Failed to clear output envstore, error: %s", err)
+		}
+	}
+}
+
+// waitBeforeRetry waits for the backoff, or until the build is cancelled.
+func (r WorkflowRunner) waitBeforeRetry(backoff time.Duration) {
+	if backoff <= 0 {
+		return
+	}
+
+	var cancelled chan struct{}
+	if r.cancellation != nil {
+		cancelled = r.cancellation.done
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-cancelled:
+	case <-timer.C:
+	}
+}
+
+// retriedAttempts returns the attempts of a retried step, nil if the step ran only once.
+func retriedAttempts(attempts []models.StepRunAttemptModel) []models.StepRunAttemptModel {
+	if len(attempts) < 2 {
+		return nil
+	}
+	return attempts
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStepWithRetry(t *testing.T) {
+	require.NoError(t, configs.InitPaths())
+
+	flakyStep := func(failures int, exitCode int) (stepRunFunc, *int) {
+		runs := 0
+		return func() (int, []envmanModels.EnvironmentItemModel, error) {
+			runs++
+			if runs <= failures {
+				return exitCode, nil, errors.New("exit status 1")
+			}
+			return 0, []envmanModels.EnvironmentItemModel{{"OUTPUT": "value"}}, nil
+		}, &runs
+	}
+
+	runner := NewWorkflowRunner(RunConfig{}, nil)
+
+	t.Log("no retry policy")
+	{
+		run, runs := flakyStep(1, 1)
+		exitCode, _, attempts, err := runner.runStepWithRetry(nil, "Script", run)
+		require.Error(t, err)
+		require.Equal(t, 1, exitCode)
+		require.Nil(t, attempts)
+		require.Equal(t, 1, *runs)
+	}
+
+	t.Log("succeeds on retry")
+	{
+		run, runs := flakyStep(2, 1)
+		exitCode, outputs, attempts, err := runner.runStepWithRetry(&models.StepRetryModel{Attempts: 3}, "Script", run)
+		require.NoError(t, err)
+		require.Equal(t, 0, exitCode)
+		require.Equal(t, []envmanModels.EnvironmentItemModel{{"OUTPUT": "value"}}, outputs)
+		require.Equal(t, 3, *runs)
+		require.Equal(t, 3, len(attempts))
+		require.Equal(t, models.StepRunStatusCodeFailed, attempts[0].Status)
+		require.Equal(t, "exit status 1", attempts[0].ErrorStr)
+		require.Equal(t, models.StepRunStatusCodeSuccess, attempts[2].Status)
+	}
+
+	t.Log("runs out of attempts")
+	{
+		run, runs := flakyStep(5, 1)
+		_, _, attempts, err := runner.runStepWithRetry(&models.StepRetryModel{Attempts: 2}, "Script", run)
+		require.Error(t, err)
+		require.Equal(t, 2, *runs)
+		require.Equal(t, 2, len(attempts))
+	}
+
+	t.Log("exit code is not retried")
+	{
+		run, runs := flakyStep(1, 2)
+		_, _, attempts, err := runner.runStepWithRetry(&models.StepRetryModel{Attempts: 3, ExitCodes: []int{75}}, "Script", run)
+		require.Error(t, err)
+		require.Equal(t, 1, *runs)
+		require.Nil(t, attempts)
+	}
+
+	t.Log("build timeout stops retrying")
+	{
+		timeoutRunner := NewWorkflowRunner(RunConfig{}, nil)
+		timeoutRunner.timeout = newBuildTimeout(100 * time.Millisecond)
+
+		run, runs := flakyStep(5, 1)
+		startTime := time.Now()
+		_, _, attempts, err := timeoutRunner.runStepWithRetry(&models.StepRetryModel{Attempts: 5, Backoff: 60}, "Script", run)
+		require.EqualError(t, err, "build timed out after 100ms")
+		require.Less(t, time.Since(startTime), 10*time.Second)
+		require.Equal(t, 1, *runs)
+		require.Nil(t, attempts)
+	}
+
+	t.Log("cancellation interrupts the backoff")
+	{
+		cancelledRunner := NewWorkflowRunner(RunConfig{}, nil)
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			cancelledRunner.cancellation.cancel()
+		}()
+
+		run, runs := flakyStep(5, 1)
+		startTime := time.Now()
+		exitCode, _, attempts, err := cancelledRunner.runStepWithRetry(&models.StepRetryModel{Attempts: 5, Backoff: 60}, "Script", run)
+		require.EqualError(t, err, "exit status 1")
+		require.Equal(t, 1, exitCode)
+		require.Less(t, time.Since(startTime), 10*time.Second)
+		require.Equal(t, 1, *runs)
+		require.Nil(t, attempts)
+	}
+}
+
+func TestStepFinishedParamsFromResults_Attempts(t *testing.T) {
+	params := stepFinishedParamsFromResults(models.StepRunResultsModel{
+		Status: models.StepRunStatusCodeSuccess,
+		Attempts: []models.StepRunAttemptModel{
+			{Status: models.StepRunStatusAbortedWithNoOutputTimeout, ExitCode: 1, ErrorStr: "timeout"},
+			{Status: models.StepRunStatusCodeSuccess},
+		},
+	}, "uuid", false)
+
+	require.Equal(t, 2, len(params.Attempts))
+	require.Equal(t, "aborted_with_no_output", params.Attempts[0].Status)
+	require.Equal(t, "timeout", params.Attempts[0].Error)
+	require.Equal(t, "success", params.Attempts[1].Status)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/bitrise-io/bitrise/configs"
+	"github.com/bitrise-io/bitrise/gitinfo"
 	"github.com/bitrise-io/bitrise/log"
 	"github.com/bitrise-io/bitrise/models"
 	"github.com/bitrise-io/go-utils/pointers"
@@ -29,6 +30,12 @@ var triggerCommand = cli.Command{
 		cli.StringFlag{Name: PRTargetBranchKey, Usage: "Git pull request target branch name."},
 		cli.StringFlag{Name: PRReadyStateKey, Usage: "Git pull request ready state. Options: ready_for_review draft converted_to_ready_for_review"},
 		cli.StringFlag{Name: TagKey, Usage: "Git tag name."},
+		cli.StringFlag{Name: CommitMessageKey, Usage: "Git commit message."},
+		cli.StringSliceFlag{Name: ChangedFilesKey, Usage: "Path of a file changed by the push or pull request. Can be specified multiple times."},
+		cli.StringSliceFlag{Name: PRLabelsKey, Usage: "Git pull request label. Can be specified multiple times."},
+		cli.StringFlag{Name: PRCommentKey, Usage: "Git pull request comment."},
+		cli.BoolFlag{Name: FromGitKey, Usage: "Derive the trigger params from the git repository of the current directory. Trigger param flags take precedence."},
+		cli.StringFlag{Name: BaseRefKey, Usage: "Git ref to diff HEAD against for the changed files, used with --from-git. Defaults to the parent of HEAD."},
 
 		// cli params used in CI mode
 		cli.StringFlag{Name: JSONParamsKey, Usage: "Specify command flags with json string-string hash."},
@@ -121,6 +128,17 @@ func trigger(c *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("Failed to parse trigger command params, error: %s", err)
 	}
+	triggerParams = triggerParams.withTriggerConditionParams(c.String(CommitMessageKey), c.StringSlice(ChangedFilesKey), c.StringSlice(PRLabelsKey), c.String(PRCommentKey))
+	if c.Bool(FromGitKey) {
+		var gitInfo gitinfo.Info
+		triggerParams, gitInfo, err = triggerParams.withGitRepository(".", c.String(BaseRefKey))
+		if err != nil {
+			failf("Failed to read git repository, error: %s", err)
+		}
+		if gitInfo.NearestTag != "" {
+			log.Printf("Nearest tag: %s", gitInfo.NearestTag)
+		}
+	}
 
 	// Inventory validation
 	inventoryEnvironments, err := CreateInventoryFromCLIParams(triggerParams.InventoryBase64Data, triggerParams.InventoryPath)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/bitrise-io/bitrise/gitinfo"
 	"github.com/bitrise-io/bitrise/log"
 	"github.com/bitrise-io/bitrise/models"
 	"github.com/bitrise-io/bitrise/output"
@@ -65,7 +66,32 @@ func getPipelineAndWorkflowIDByParamsInCompatibleMode(triggerMap models.TriggerM
 		params = migratePatternToParams(params, isPullRequestMode)
 	}
 
-	return triggerMap.FirstMatchingTarget(params.PushBranch, params.PRSourceBranch, params.PRTargetBranch, params.PRReadyState, params.Tag)
+	return triggerMap.FirstMatchingTargetWithParams(params.triggerParams())
+}
+
+// explainTriggerMapInCompatibleMode evaluates the trigger items like getPipelineAndWorkflowIDByParamsInCompatibleMode.
+func explainTriggerMapInCompatibleMode(triggerMap models.TriggerMapModel, params RunAndTriggerParamsModel, isPullRequestMode bool) ([]models.TriggerItemEvaluation, error) {
+	if params.TriggerPattern != "" {
+		params = migratePatternToParams(params, isPullRequestMode)
+	}
+
+	return triggerMap.Explain(params.triggerParams())
+}
+
+func printTriggerItemEvaluations(evaluations []models.TriggerItemEvaluation) {
+	for _, evaluation := range evaluations {
+		target := evaluation.WorkflowID
+		if evaluation.PipelineID != "" {
+			target = evaluation.PipelineID
+		}
+
+		result := colorstring.Red("no match")
+		if evaluation.Match {
+			result = colorstring.Green("match")
+		}
+
+		log.Printf("#%d %s -> %s: %s (%s)", evaluation.Item, evaluation.Conditions, target, result, evaluation.Reason)
+	}
 }
 
 // --------------------
@@ -114,6 +140,20 @@ func triggerCheck(c *cli.Context) error {
 	if err != nil {
 		registerFatal(fmt.Sprintf("Failed to parse trigger check params, err: %s", err), warnings, triggerParams.Format)
 	}
+	triggerParams = triggerParams.withTriggerConditionParams(c.String(CommitMessageKey), c.StringSlice(ChangedFilesKey), c.StringSlice(PRLabelsKey), c.String(PRCommentKey))
+	if webhookPayloadPath := c.String(WebhookPayloadKey); webhookPayloadPath != "" {
+		triggerParams, err = triggerParams.withWebhookPayload(webhookPayloadPath, c.String(ProviderKey))
+		if err != nil {
+			registerFatal(fmt.Sprintf("Failed to parse webhook payload, err: %s", err), warnings, triggerParams.Format)
+		}
+	}
+	var gitInfo gitinfo.Info
+	if c.Bool(FromGitKey) {
+		triggerParams, gitInfo, err = triggerParams.withGitRepository(".", c.String(BaseRefKey))
+		if err != nil {
+			registerFatal(fmt.Sprintf("Failed to read git repository, err: %s", err), warnings, triggerParams.Format)
+		}
+	}
 	//
 
 	// Inventory validation
@@ -150,6 +190,17 @@ func triggerCheck(c *cli.Context) error {
 		registerFatal(fmt.Sprintf("Failed to check  PR mode, err: %s", err), warnings, triggerParams.Format)
 	}
 
+	var evaluations []models.TriggerItemEvaluation
+	if c.Bool(ExplainKey) {
+		evaluations, err = explainTriggerMapInCompatibleMode(bitriseConfig.TriggerMap, triggerParams, isPRMode)
+		if err != nil {
+			registerFatal(err.Error(), warnings, triggerParams.Format)
+		}
+		if triggerParams.Format == output.FormatRaw {
+			printTriggerItemEvaluations(evaluations)
+		}
+	}
+
 	pipelineToRunID, workflowToRunID, err := getPipelineAndWorkflowIDByParamsInCompatibleMode(bitriseConfig.TriggerMap, triggerParams, isPRMode)
 	if err != nil {
 		registerFatal(err.Error(), warnings, triggerParams.Format)
@@ -179,6 +230,9 @@ func triggerCheck(c *cli.Context) error {
 			triggerModel["tag"] = triggerParams.Tag
 		}
 	}
+	if gitInfo.NearestTag != "" {
+		triggerModel["nearest-tag"] = gitInfo.NearestTag
+	}
 
 	switch triggerParams.Format {
 	case output.FormatRaw:
@@ -193,7 +247,16 @@ func triggerCheck(c *cli.Context) error {
 		log.Print(msg)
 		break
 	case output.FormatJSON:
-		bytes, err := json.Marshal(triggerModel)
+		var jsonModel interface{} = triggerModel
+		if evaluations != nil {
+			explainModel := map[string]interface{}{"evaluations": evaluations}
+			for key, value := range triggerModel {
+				explainModel[key] = value
+			}
+			jsonModel = explainModel
+		}
+
+		bytes, err := json.Marshal(jsonModel)
 		if err != nil {
 			registerFatal(fmt.Sprintf("Failed to parse trigger model, err: %s", err), warnings, triggerParams.Format)
 		}
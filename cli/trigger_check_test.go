@@ -1,9 +1,16 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/require"
 )
 
@@ -475,6 +482,260 @@ workflows:
 	}
 }
 
+func TestGetPipelineAndWorkflowIDByParamsInCompatibleMode_conditions_test(t *testing.T) {
+	configStr := `format_version: 11
+
+trigger_map:
+- push_branch:
+    regex: ^release/.*$
+  commit_message:
+    regex: \[deploy\]
+  workflow: deploy
+- type: push
+  changed_files:
+    regex: ^ios/
+  workflow: ios
+- pull_request_target_branch: main
+  pull_request_label: ci:*
+  pull_request_comment: /bitrise run*
+  workflow: ci
+- type: pull_request
+  workflow: primary
+- tag:
+    regex: ^v[0-9]+
+  workflow: deploy
+
+workflows:
+  ci:
+  deploy:
+  ios:
+  primary:
+`
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	for _, tt := range []struct {
+		params   RunAndTriggerParamsModel
+		workflow string
+	}{
+		{params: RunAndTriggerParamsModel{PushBranch: "release/1.0", CommitMessage: "Release [deploy]"}, workflow: "deploy"},
+		{params: RunAndTriggerParamsModel{PushBranch: "release/1.0", CommitMessage: "Release", ChangedFiles: []string{"ios/Podfile"}}, workflow: "ios"},
+		{params: RunAndTriggerParamsModel{PRSourceBranch: "feature", PRTargetBranch: "main", PRLabels: []string{"ci:full"}, PRComment: "/bitrise run"}, workflow: "ci"},
+		{params: RunAndTriggerParamsModel{PRSourceBranch: "feature", PRTargetBranch: "main", PRLabels: []string{"ci:full"}}, workflow: "primary"},
+		{params: RunAndTriggerParamsModel{Tag: "v2.0.0"}, workflow: "deploy"},
+	} {
+		pipelineID, workflowID, err := getPipelineAndWorkflowIDByParamsInCompatibleMode(config.TriggerMap, tt.params, false)
+		require.NoError(t, err)
+		require.Equal(t, "", pipelineID)
+		require.Equal(t, tt.workflow, workflowID)
+	}
+
+	_, _, err = getPipelineAndWorkflowIDByParamsInCompatibleMode(config.TriggerMap, RunAndTriggerParamsModel{PushBranch: "main"}, false)
+	require.EqualError(t, err, "no matching pipeline & workflow found with trigger params: push-branch: main, pr-source-branch: , pr-target-branch: , tag: ")
+}
+
+func TestGetPipelineAndWorkflowIDByParamsInCompatibleMode_webhook_payload_test(t *testing.T) {
+	configStr := `format_version: 11
+
+trigger_map:
+- push_branch: release/*
+  commit_message: "*[deploy]*"
+  pipeline: deploy
+- push_branch: main
+  changed_files:
+    regex: ^android/
+  pipeline: android
+- pull_request_target_branch: main
+  pull_request_label: ci:full
+  pipeline: full
+- type: pull_request
+  pull_request_comment: /bitrise run e2e
+  pipeline: e2e
+- tag: "*"
+  pipeline: deploy
+
+pipelines:
+  android:
+    stages:
+    - stage-1: {}
+  deploy:
+    stages:
+    - stage-1: {}
+  e2e:
+    stages:
+    - stage-1: {}
+  full:
+    stages:
+    - stage-1: {}
+stages:
+  stage-1:
+    workflows:
+    - test: {}
+workflows:
+  test:
+`
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	for _, tt := range []struct {
+		payload  string
+		provider string
+		params   RunAndTriggerParamsModel
+		pipeline string
+	}{
+		{payload: "github_push.json", provider: "github", pipeline: "deploy"},
+		{payload: "github_tag_push.json", provider: "github", pipeline: "deploy"},
+		{payload: "github_pull_request.json", provider: "github", pipeline: "full"},
+		{payload: "github_issue_comment.json", provider: "github", params: RunAndTriggerParamsModel{PRSourceBranch: "feature/login", PRTargetBranch: "develop"}, pipeline: "e2e"},
+		{payload: "gitlab_push.json", provider: "gitlab", pipeline: "android"},
+		{payload: "gitlab_note.json", provider: "gitlab", pipeline: "e2e"},
+		{payload: "bitbucket_push.json", provider: "bitbucket", pipeline: "deploy"},
+		{payload: "bitbucket_pull_request_comment.json", provider: "bitbucket", pipeline: "e2e"},
+	} {
+		params, err := tt.params.withWebhookPayload(filepath.Join("..", "webhook", "testdata", tt.payload), tt.provider)
+		require.NoError(t, err)
+
+		pipelineID, workflowID, err := getPipelineAndWorkflowIDByParamsInCompatibleMode(config.TriggerMap, params, false)
+		require.NoError(t, err, tt.payload)
+		require.Equal(t, tt.pipeline, pipelineID, tt.payload)
+		require.Equal(t, "", workflowID)
+	}
+
+	t.Log("trigger params take precedence over the webhook payload")
+	{
+		params, err := RunAndTriggerParamsModel{CommitMessage: "Release"}.withWebhookPayload(filepath.Join("..", "webhook", "testdata", "github_push.json"), "github")
+		require.NoError(t, err)
+		require.Equal(t, "release/1.2", params.PushBranch)
+		require.Equal(t, "Release", params.CommitMessage)
+
+		_, _, err = getPipelineAndWorkflowIDByParamsInCompatibleMode(config.TriggerMap, params, false)
+		require.EqualError(t, err, "no matching pipeline & workflow found with trigger params: push-branch: release/1.2, pr-source-branch: , pr-target-branch: , tag: ")
+	}
+
+	t.Log("pull request branches are required for the GitHub issue_comment event")
+	{
+		_, err := RunAndTriggerParamsModel{}.withWebhookPayload(filepath.Join("..", "webhook", "testdata", "github_issue_comment.json"), "github")
+		require.EqualError(t, err, "the webhook payload doesn't contain the pull request branches, set them with the --pr-source-branch and --pr-target-branch flags")
+	}
+
+	t.Log("provider is required")
+	{
+		_, err := RunAndTriggerParamsModel{}.withWebhookPayload(filepath.Join("..", "webhook", "testdata", "github_push.json"), "")
+		require.EqualError(t, err, "provider of the webhook payload is not specified")
+	}
+}
+
+func TestGetPipelineAndWorkflowIDByParamsInCompatibleMode_git_repository_test(t *testing.T) {
+	configStr := `format_version: 11
+
+trigger_map:
+- push_branch: release/*
+  commit_message: "*[deploy]*"
+  pipeline: deploy
+- push_branch: "*"
+  changed_files: ios/*
+  pipeline: ios
+- tag: "*"
+  pipeline: deploy
+
+pipelines:
+  deploy:
+    stages:
+    - stage-1: {}
+  ios:
+    stages:
+    - stage-1: {}
+stages:
+  stage-1:
+    workflows:
+    - test: {}
+workflows:
+  test:
+`
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	commit := func(message, file string) plumbing.Hash {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(message), 0644))
+		_, err := worktree.Add(file)
+		require.NoError(t, err)
+		hash, err := worktree.Commit(message, &git.CommitOptions{Author: &object.Signature{Name: "Bitrise", Email: "bot@bitrise.io", When: time.Now()}})
+		require.NoError(t, err)
+		return hash
+	}
+
+	commit("Initial commit", "README.md")
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("release/1.0"), Create: true}))
+	commit("Update Podfile", "ios/Podfile")
+	tagged := commit("Bump version [deploy]", "version.txt")
+	_, err = repo.CreateTag("1.0.0", tagged, nil)
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name     string
+		params   RunAndTriggerParamsModel
+		baseRef  string
+		checkout plumbing.Hash
+		pipeline string
+	}{
+		{name: "push of the current branch", pipeline: "deploy"},
+		{name: "trigger params take precedence", params: RunAndTriggerParamsModel{CommitMessage: "Bump version"}, baseRef: "master", pipeline: "ios"},
+		{name: "tag at detached HEAD", checkout: tagged, pipeline: "deploy"},
+	} {
+		if !tt.checkout.IsZero() {
+			require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Hash: tt.checkout}))
+		}
+
+		params, info, err := tt.params.withGitRepository(dir, tt.baseRef)
+		require.NoError(t, err, tt.name)
+		require.Equal(t, "1.0.0", info.NearestTag, tt.name)
+
+		pipelineID, workflowID, err := getPipelineAndWorkflowIDByParamsInCompatibleMode(config.TriggerMap, params, false)
+		require.NoError(t, err, tt.name)
+		require.Equal(t, tt.pipeline, pipelineID, tt.name)
+		require.Equal(t, "", workflowID)
+	}
+}
+
+func TestExplainTriggerMapInCompatibleMode(t *testing.T) {
+	configStr := `format_version: 1.4.0
+
+trigger_map:
+- pattern: master
+  workflow: master
+- pattern: "*"
+  is_pull_request_allowed: true
+  workflow: primary
+
+workflows:
+  master:
+  primary:
+`
+
+	config, warnings, err := bitrise.ConfigModelFromYAMLBytes([]byte(configStr))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(warnings))
+
+	evaluations, err := explainTriggerMapInCompatibleMode(config.TriggerMap, RunAndTriggerParamsModel{TriggerPattern: "master"}, true)
+	require.NoError(t, err)
+	require.Equal(t, []models.TriggerItemEvaluation{
+		{Item: 1, Conditions: "pattern: master", WorkflowID: "master", Reason: "code-push trigger item, but the params describe a pull-request event"},
+		{Item: 2, Conditions: "pattern: * & is_pull_request_allowed: true", WorkflowID: "primary", Match: true, Reason: "all conditions match"},
+	}, evaluations)
+}
+
 func TestGetPipelineAndWorkflowIDByParamsInCompatibleMode_migration_test(t *testing.T) {
 	t.Log("deprecated code push trigger item")
 	{
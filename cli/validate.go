@@ -1,14 +1,19 @@
 package cli
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/bitrise-io/bitrise/configmerge"
+	"github.com/bitrise-io/bitrise/models"
 	"github.com/bitrise-io/bitrise/output"
+	"github.com/bitrise-io/bitrise/schema"
 	"github.com/bitrise-io/go-utils/colorstring"
 	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
 )
 
 // ValidationItemModel ...
@@ -162,6 +167,10 @@ func validateBitriseYML(bitriseConfigPath string, bitriseConfigBase64Data string
 		if err != nil {
 			configValidation.IsValid = false
 			configValidation.Error = err.Error()
+
+			if schemaErr := configSchemaError(pth, bitriseConfigBase64Data); schemaErr != nil {
+				configValidation.Error = schemaErr.Error()
+			}
 		}
 
 		return &configValidation, nil
@@ -170,6 +179,82 @@ func validateBitriseYML(bitriseConfigPath string, bitriseConfigBase64Data string
 	return nil, nil
 }
 
+// configSchemaError returns the schema violations of the config with their positions,
+// if the config can't be parsed into the data model.
+// The violations of a modular config are positioned in the config module which set the invalid value.
+func configSchemaError(pth, base64Data string) error {
+	var content []byte
+	var provenance models.Provenance
+	if base64Data != "" {
+		decoded, err := base64.StdEncoding.DecodeString(base64Data)
+		if err != nil {
+			return nil
+		}
+		content = decoded
+	} else {
+		fileContent, err := os.ReadFile(pth)
+		if err != nil {
+			return nil
+		}
+		content = fileContent
+
+		isModularConfig, err := configmerge.IsModularConfig(pth)
+		if err != nil {
+			return nil
+		}
+		if isModularConfig {
+			merger, err := createDefaultMerger()
+			if err != nil {
+				return nil
+			}
+			if err := useConfigLock(merger, pth); err != nil {
+				return nil
+			}
+			mergedConfigContent, _, err := merger.MergeConfig(pth)
+			if err != nil {
+				return nil
+			}
+			content = []byte(mergedConfigContent)
+			provenance = merger.Provenance()
+		}
+	}
+
+	var config models.BitriseDataModel
+	if err := yaml.Unmarshal(content, &config); err == nil {
+		return nil
+	}
+
+	errs, err := schema.Validate(schema.Generate(), content)
+	if err != nil || len(errs) == 0 {
+		return nil
+	}
+
+	var messages []string
+	for _, e := range errs {
+		if provenance != nil {
+			messages = append(messages, schemaErrorWithOrigin(e, provenance))
+		} else {
+			messages = append(messages, e.Error())
+		}
+	}
+	if base64Data != "" {
+		return fmt.Errorf("config is not valid:\n%s", strings.Join(messages, "\n"))
+	}
+	return fmt.Errorf("config (%s) is not valid:\n%s", pth, strings.Join(messages, "\n"))
+}
+
+// schemaErrorWithOrigin cites the config module and line of the invalid value instead of its position in the merged config.
+func schemaErrorWithOrigin(e schema.ValidationError, provenance models.Provenance) string {
+	message := e.Message
+	if e.Path != "" {
+		message = e.Path + ": " + message
+	}
+	if origin, ok := provenance.Origin(e.Path); ok {
+		return origin.String() + ": " + message
+	}
+	return message
+}
+
 func validateInventory(inventoryPath string, inventoryBase64Data string) (*ValidationItemModel, error) {
 	pth, err := GetInventoryFilePath(inventoryPath)
 	if err != nil {
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateBitriseYML_SchemaErrors(t *testing.T) {
+	config := `format_version: "17"
+workflows:
+  primary:
+    timeout: 1h
+    steps:
+    - script@1:
+        inputs: echo
+`
+	pth := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, os.WriteFile(pth, []byte(config), 0644))
+
+	result, err := validateBitriseYML(pth, "")
+	require.NoError(t, err)
+	require.False(t, result.IsValid)
+	require.Equal(t, "config ("+pth+") is not valid:\n"+
+		"line 4, column 14: workflows.primary.timeout: expected an integer, got a string (1h)\n"+
+		"line 7, column 17: workflows.primary.steps[0].script@1.inputs: expected a list, got a string (echo)", result.Error)
+
+	result, err = validateBitriseYML("", base64.StdEncoding.EncodeToString([]byte(config)))
+	require.NoError(t, err)
+	require.False(t, result.IsValid)
+	require.Equal(t, "config is not valid:\n"+
+		"line 4, column 14: workflows.primary.timeout: expected an integer, got a string (1h)\n"+
+		"line 7, column 17: workflows.primary.steps[0].script@1.inputs: expected a list, got a string (echo)", result.Error)
+}
+
+func TestValidateBitriseYML_SemanticErrors(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, os.WriteFile(pth, []byte("workflows: {}\n"), 0644))
+
+	result, err := validateBitriseYML(pth, "")
+	require.NoError(t, err)
+	require.False(t, result.IsValid)
+	require.Equal(t, "config ("+pth+") is not valid: missing format_version", result.Error)
+}
+
+func TestValidateBitriseYML_ModularConfigSchemaErrors(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, "bitrise.yml")
+	require.NoError(t, os.WriteFile(pth, []byte(`format_version: "17"
+include:
+- path: `+filepath.Join(dir, "workflows.yml")+`
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "workflows.yml"), []byte(`workflows:
+  primary:
+    steps:
+    - script@1: {}
+
+  deploy:
+    timeout: 1h
+`), 0644))
+
+	result, err := validateBitriseYML(pth, "")
+	require.NoError(t, err)
+	require.False(t, result.IsValid)
+	require.Equal(t, "config ("+pth+") is not valid:\n"+
+		filepath.Join(dir, "workflows.yml")+":7: workflows.deploy.timeout: expected an integer, got a string (1h)", result.Error)
+}
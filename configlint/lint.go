@@ -0,0 +1,225 @@
+// Package configlint checks a bitrise.yml against a set of named, individually configurable conventions.
+package configlint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/bitrise-io/bitrise/configmigrate"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity of an issue, the values match the SARIF result levels.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityNote    Severity = "note"
+	// SeverityOff disables the rule.
+	SeverityOff Severity = "off"
+)
+
+// Issue is a rule violation with its position in the config.
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Path     string   `json:"path,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Fixed    bool     `json:"fixed,omitempty"`
+}
+
+// Rule ...
+type Rule struct {
+	ID              string
+	Description     string
+	DefaultSeverity Severity
+	// Fixable rules are fixed by Lint if Options.Fix is set.
+	Fixable bool
+
+	check func(l *linter) []Issue
+}
+
+// RuleConfig overrides the defaults of a rule.
+type RuleConfig struct {
+	Severity Severity `yaml:"severity,omitempty"`
+	// MaxDepth is the maximum before_run chain depth of the before-run-depth rule.
+	MaxDepth int `yaml:"max_depth,omitempty"`
+}
+
+// Config is the linter configuration, read from the .bitrise-lint.yml.
+type Config struct {
+	Rules map[string]RuleConfig `yaml:"rules,omitempty"`
+}
+
+// ReadConfig ...
+func ReadConfig(pth string) (Config, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read lint config: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to parse lint config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid lint config: %w", err)
+	}
+	return config, nil
+}
+
+// Validate ...
+func (c Config) Validate() error {
+	ids := make([]string, 0, len(c.Rules))
+	for id := range c.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if _, ok := ruleByID(id); !ok {
+			return fmt.Errorf("unknown rule (%s)", id)
+		}
+		switch severity := c.Rules[id].Severity; severity {
+		case "", SeverityError, SeverityWarning, SeverityNote, SeverityOff:
+		default:
+			return fmt.Errorf("rule (%s): invalid severity (%s), valid values are: error, warning, note and off", id, severity)
+		}
+		if c.Rules[id].MaxDepth < 0 {
+			return fmt.Errorf("rule (%s): invalid max_depth (%d)", id, c.Rules[id].MaxDepth)
+		}
+	}
+	return nil
+}
+
+func (c Config) severity(rule Rule) Severity {
+	if severity := c.Rules[rule.ID].Severity; severity != "" {
+		return severity
+	}
+	return rule.DefaultSeverity
+}
+
+// StepInputResolver resolves the inputs of a StepLib step from its step.yml.
+type StepInputResolver interface {
+	SensitiveInputs(stepLibSource, stepID, version string) ([]string, error)
+}
+
+// Options ...
+type Options struct {
+	Config Config
+	// Secrets are the keys of the inventory (.bitrise.secrets.yml), the secret references are checked only if it is not nil.
+	Secrets []string
+	// Fix fixes the issues of the fixable rules.
+	Fix bool
+	// StepVersionResolver is used to pin the step versions, steps are not pinned by Fix if it is nil.
+	StepVersionResolver configmigrate.StepVersionResolver
+	// StepInputResolver is used to find the inputs marked sensitive in the step.yml of the StepLib steps.
+	// Only the is_sensitive options of the config are checked if it is nil or the step can't be resolved.
+	StepInputResolver StepInputResolver
+}
+
+// Result is the list of issues and the fixed config.
+type Result struct {
+	Issues []Issue
+	// Content is the fixed config, it is the original config if nothing was fixed.
+	Content []byte
+}
+
+// Lint checks the config with the enabled rules and returns the issues ordered by their position.
+// The config is expected to be valid, see bitrise.ConfigModelFromYAMLBytes.
+func Lint(content []byte, opts Options) (Result, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return Result{}, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return Result{}, errors.New("config is not a YAML map")
+	}
+
+	if err := opts.Config.Validate(); err != nil {
+		return Result{}, fmt.Errorf("invalid lint config: %w", err)
+	}
+
+	l := &linter{root: document.Content[0], opts: opts}
+
+	var issues []Issue
+	for _, rule := range Rules {
+		severity := opts.Config.severity(rule)
+		if severity == SeverityOff {
+			continue
+		}
+
+		l.rule = rule
+		for _, issue := range rule.check(l) {
+			issue.Rule = rule.ID
+			issue.Severity = severity
+			issues = append(issues, issue)
+		}
+	}
+	if l.err != nil {
+		return Result{}, l.err
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+
+	result := Result{Issues: issues, Content: content}
+	if l.modified {
+		fixed, err := configmigrate.Encode(&document, content)
+		if err != nil {
+			return Result{}, err
+		}
+		result.Content = fixed
+	}
+	return result, nil
+}
+
+// HasErrors returns true if any of the not fixed issues is an error.
+func (r Result) HasErrors() bool {
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError && !issue.Fixed {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleByID(id string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// linter holds the state shared by the rules.
+type linter struct {
+	root *yaml.Node
+	opts Options
+	rule Rule
+
+	modified bool
+	err      error
+}
+
+func (l *linter) fix() bool {
+	return l.opts.Fix && l.rule.Fixable
+}
+
+func (l *linter) ruleConfig() RuleConfig {
+	return l.opts.Config.Rules[l.rule.ID]
+}
+
+func newIssue(node *yaml.Node, path, format string, args ...interface{}) Issue {
+	return Issue{Message: fmt.Sprintf(format, args...), Path: path, Line: node.Line, Column: node.Column}
+}
@@ -0,0 +1,312 @@
+package configlint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockStepVersionResolver map[string]string
+
+func (r mockStepVersionResolver) ResolveVersion(stepLibSource, stepID, versionConstraint string) (string, error) {
+	version, ok := r[stepLibSource+"::"+stepID+"@"+versionConstraint]
+	if !ok {
+		return "", errors.New("step not found")
+	}
+	return version, nil
+}
+
+type mockStepInputResolver map[string][]string
+
+func (r mockStepInputResolver) SensitiveInputs(stepLibSource, stepID, version string) ([]string, error) {
+	inputs, ok := r[stepLibSource+"::"+stepID+"@"+version]
+	if !ok {
+		return nil, errors.New("step not found")
+	}
+	return inputs, nil
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		opts   Options
+		want   []string
+	}{
+		{
+			name: "Unused utility workflows",
+			config: `format_version: "17"
+workflows:
+  _setup: {}
+  _cleanup: {}
+  _unused: {}
+  test:
+    before_run: [_setup]
+    after_run: [_cleanup]
+`,
+			want: []string{"5:3 warning unused-utility-workflow workflows._unused: utility workflow (_unused) is not referenced by any before_run or after_run"},
+		},
+		{
+			name: "Unpinned step versions",
+			config: `format_version: "17"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+workflows:
+  test:
+    steps:
+    - git-clone: {}
+    - script@1: {}
+    - path::./step: {}
+    - with:
+        steps:
+        - cache-pull@: {}
+step_bundles:
+  setup:
+    steps:
+    - https://github.com/bitrise-io/bitrise-steplib.git::activate-ssh-key: {}
+`,
+			want: []string{
+				"6:7 warning unpinned-step-version workflows.test.steps[0].git-clone: step (git-clone) has no pinned major version",
+				"11:11 warning unpinned-step-version workflows.test.steps[3].with.steps[0].cache-pull@: step (cache-pull@) has no pinned major version",
+				"15:7 warning unpinned-step-version step_bundles.setup.steps[0].https://github.com/bitrise-io/bitrise-steplib.git::activate-ssh-key: step (https://github.com/bitrise-io/bitrise-steplib.git::activate-ssh-key) has no pinned major version",
+			},
+		},
+		{
+			name: "Duplicate env keys",
+			config: `format_version: "17"
+app:
+  envs:
+  - PROJECT: app.xcodeproj
+  - PROJECT: app.xcodeproj
+workflows:
+  test:
+    envs:
+    - SCHEME: App
+    - PROJECT: other.xcodeproj
+`,
+			want: []string{
+				"5:5 warning duplicate-env-key app.envs[1]: env var (PROJECT) is already defined at app.envs[0]",
+				"10:7 warning duplicate-env-key workflows.test.envs[1]: env var (PROJECT) overrides the app env var defined at app.envs[0]",
+			},
+		},
+		{
+			name: "Invalid run_if expressions",
+			config: `format_version: "17"
+stages:
+  build:
+    run_if: '{{.IsCI'
+    workflows:
+    - test:
+        run_if: .IsPR
+pipelines:
+  release:
+    stages:
+    - deploy:
+        workflows:
+        - test:
+            run_if: '{{enveq "BRANCH"}}'
+workflows:
+  test:
+    steps:
+    - script@1:
+        run_if: .IsBuildBroken
+`,
+			want: []string{
+				"4:5 error invalid-run-if stages.build.run_if: invalid run_if expression ({{.IsCI): template: EvaluateTemplateToBool:1: unclosed action",
+				`14:13 error invalid-run-if pipelines.release.stages[0].deploy.workflows[0].test.run_if: invalid run_if expression ({{enveq "BRANCH"}}): template: EvaluateTemplateToBool:1:2: executing "EvaluateTemplateToBool" at <enveq>: wrong number of args for enveq: want 2 got 1`,
+				"19:9 error invalid-run-if workflows.test.steps[0].script@1.run_if: invalid run_if expression (.IsBuildBroken): template: EvaluateTemplateToBool:1:2: executing \"EvaluateTemplateToBool\" at <.IsBuildBroken>: can't evaluate field IsBuildBroken in type bitrise.TemplateDataModel",
+			},
+		},
+		{
+			name: "Secrets and sensitive inputs",
+			config: `format_version: "17"
+app:
+  envs:
+  - PROJECT: app.xcodeproj
+workflows:
+  deploy:
+    steps:
+    - deploy@1:
+        inputs:
+        - api_token: $API_TOKEN
+          opts:
+            is_sensitive: true
+        - password: ${PROJECT}
+          opts:
+            is_sensitive: true
+        - key: secret-key
+          opts:
+            is_sensitive: true
+        - slack_webhook: $SLACK_WEBHOOK
+        - branch: $BITRISE_GIT_BRANCH
+        - script: echo $NOT_CHECKED
+`,
+			opts: Options{Secrets: []string{"API_TOKEN"}},
+			want: []string{
+				"13:21 error sensitive-input-from-non-secret workflows.deploy.steps[0].deploy@1.inputs[1]: sensitive input (password) is fed from a non-secret env var (PROJECT) defined in the config",
+				"16:16 error sensitive-input-from-non-secret workflows.deploy.steps[0].deploy@1.inputs[2]: sensitive input (key) has a literal value, reference a secret instead",
+				"19:26 warning undefined-secret workflows.deploy.steps[0].deploy@1.inputs[3]: env var (SLACK_WEBHOOK) is not defined in the config or in the secrets",
+			},
+		},
+		{
+			name: "Sensitive inputs of the step.yml",
+			config: `format_version: "17"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+workflows:
+  deploy:
+    steps:
+    - deploy@1:
+        inputs:
+        - api_token: secret-token
+        - password: secret-password
+          opts:
+            is_sensitive: false
+        - branch: main
+    - unknown@1:
+        inputs:
+        - api_token: secret-token
+`,
+			opts: Options{StepInputResolver: mockStepInputResolver{
+				"https://github.com/bitrise-io/bitrise-steplib.git::deploy@1": {"api_token", "password"},
+			}},
+			want: []string{
+				"8:22 error sensitive-input-from-non-secret workflows.deploy.steps[0].deploy@1.inputs[0]: sensitive input (api_token) has a literal value, reference a secret instead",
+			},
+		},
+		{
+			name: "Secrets are not checked without inventory",
+			config: `format_version: "17"
+workflows:
+  deploy:
+    envs:
+    - TOKEN: $API_TOKEN
+`,
+		},
+		{
+			name: "before_run depth",
+			config: `format_version: "17"
+workflows:
+  _a:
+    before_run: [_b]
+  _b:
+    before_run: [_c]
+  _c: {}
+  test:
+    before_run: [_a]
+`,
+			opts: Options{Config: Config{Rules: map[string]RuleConfig{"before-run-depth": {MaxDepth: 2}}}},
+			want: []string{"9:5 warning before-run-depth workflows.test.before_run: before_run chain of workflow (test) is 3 deep, the maximum is 2"},
+		},
+		{
+			name: "Configured severities",
+			config: `format_version: "17"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+workflows:
+  _unused: {}
+  test:
+    steps:
+    - script: {}
+`,
+			opts: Options{Config: Config{Rules: map[string]RuleConfig{
+				"unused-utility-workflow": {Severity: SeverityOff},
+				"unpinned-step-version":   {Severity: SeverityError},
+			}}},
+			want: []string{"7:7 error unpinned-step-version workflows.test.steps[0].script: step (script) has no pinned major version"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Lint([]byte(tt.config), tt.opts)
+			require.NoError(t, err)
+			require.Equal(t, tt.config, string(result.Content))
+
+			var got []string
+			for _, issue := range result.Issues {
+				got = append(got, fmt.Sprintf("%d:%d %s %s %s: %s", issue.Line, issue.Column, issue.Severity, issue.Rule, issue.Path, issue.Message))
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLint_Fix(t *testing.T) {
+	config := `format_version: "17"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+app:
+  envs:
+  - PROJECT: app.xcodeproj
+  - PROJECT: app.xcodeproj
+workflows:
+  test:
+    envs:
+    - PROJECT: app.xcodeproj # same as the app env
+    - SCHEME: App
+    steps:
+    - git-clone: {}
+    - script@: {}
+`
+	want := `format_version: "17"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+app:
+  envs:
+    - PROJECT: app.xcodeproj
+workflows:
+  test:
+    envs:
+      - SCHEME: App
+    steps:
+      - git-clone@8: {}
+      - script@1: {}
+`
+
+	result, err := Lint([]byte(config), Options{
+		Fix: true,
+		StepVersionResolver: mockStepVersionResolver{
+			"https://github.com/bitrise-io/bitrise-steplib.git::git-clone@": "8.3.1",
+			"https://github.com/bitrise-io/bitrise-steplib.git::script@":    "1.2.0",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, want, string(result.Content))
+	require.Len(t, result.Issues, 4)
+	for _, issue := range result.Issues {
+		require.True(t, issue.Fixed, issue.Message)
+	}
+	require.False(t, result.HasErrors())
+
+	_, err = Lint([]byte(config), Options{Fix: true, StepVersionResolver: mockStepVersionResolver{}})
+	require.EqualError(t, err, "workflows.test.steps[0].git-clone: failed to resolve step (git-clone) version: step not found")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	require.NoError(t, Config{Rules: map[string]RuleConfig{"invalid-run-if": {Severity: SeverityWarning}}}.Validate())
+	require.EqualError(t, Config{Rules: map[string]RuleConfig{"unknown": {}}}.Validate(), "unknown rule (unknown)")
+	require.EqualError(t, Config{Rules: map[string]RuleConfig{"invalid-run-if": {Severity: "fatal"}}}.Validate(), "rule (invalid-run-if): invalid severity (fatal), valid values are: error, warning, note and off")
+}
+
+func TestSARIF(t *testing.T) {
+	content, err := SARIF([]Issue{
+		{Rule: "invalid-run-if", Severity: SeverityError, Message: "invalid run_if expression", Path: "workflows.test.steps[0].script.run_if", Line: 6, Column: 9},
+		{Rule: "duplicate-env-key", Severity: SeverityWarning, Message: "fixed", Fixed: true},
+	}, "bitrise.yml")
+	require.NoError(t, err)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(content, &log))
+	require.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Tool.Driver.Rules, len(Rules))
+	require.Equal(t, []sarifResult{{
+		RuleID:  "invalid-run-if",
+		Level:   SeverityError,
+		Message: sarifMessage{Text: "workflows.test.steps[0].script.run_if: invalid run_if expression"},
+		Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: "bitrise.yml"},
+			Region:           &sarifRegion{StartLine: 6, StartColumn: 9},
+		}}},
+	}}, log.Runs[0].Results)
+}
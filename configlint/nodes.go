@@ -0,0 +1,195 @@
+package configlint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/stepman/stepid"
+	"gopkg.in/yaml.v3"
+)
+
+// resolve follows the YAML aliases.
+func resolve(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	node = resolve(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], resolve(node.Content[i+1])
+		}
+	}
+	return nil, nil
+}
+
+// mappingPairs returns the key and the value nodes of a mapping.
+func mappingPairs(node *yaml.Node) [][2]*yaml.Node {
+	node = resolve(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var pairs [][2]*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "<<" {
+			continue
+		}
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], resolve(node.Content[i+1])})
+	}
+	return pairs
+}
+
+func sequenceItems(node *yaml.Node) []*yaml.Node {
+	node = resolve(node)
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var items []*yaml.Node
+	for _, item := range node.Content {
+		items = append(items, resolve(item))
+	}
+	return items
+}
+
+func removeSequenceItem(node *yaml.Node, item *yaml.Node) {
+	for i, child := range node.Content {
+		if resolve(child) == item {
+			node.Content = append(node.Content[:i], node.Content[i+1:]...)
+			return
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func indexPath(path string, idx int) string {
+	return fmt.Sprintf("%s[%d]", path, idx)
+}
+
+// envItem is an item of an env var list (envs, inputs or outputs).
+type envItem struct {
+	node    *yaml.Node
+	keyNode *yaml.Node
+	value   *yaml.Node
+	opts    *yaml.Node
+	path    string
+}
+
+func (e envItem) key() string {
+	return e.keyNode.Value
+}
+
+// sensitiveOpt returns the is_sensitive option of the env var, nil if it is not set.
+func (e envItem) sensitiveOpt() *bool {
+	_, isSensitive := mappingValue(e.opts, "is_sensitive")
+	var value bool
+	if isSensitive == nil || isSensitive.Decode(&value) != nil {
+		return nil
+	}
+	return &value
+}
+
+func envItems(list *yaml.Node, path string) []envItem {
+	var items []envItem
+	for idx, node := range sequenceItems(list) {
+		item := envItem{node: node, path: indexPath(path, idx)}
+		for _, pair := range mappingPairs(node) {
+			if pair[0].Value == "opts" {
+				item.opts = pair[1]
+			} else {
+				item.keyNode, item.value = pair[0], pair[1]
+			}
+		}
+		if item.keyNode != nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// stepItem is a step of a workflow, a step bundle or a with group.
+type stepItem struct {
+	keyNode *yaml.Node
+	value   *yaml.Node
+	path    string
+}
+
+// steps returns the steps of the workflows and the step bundles, including the steps of the with groups.
+func (l *linter) steps() []stepItem {
+	var steps []stepItem
+	for _, container := range []string{"workflows", "step_bundles"} {
+		_, containers := mappingValue(l.root, container)
+		for _, pair := range mappingPairs(containers) {
+			path := joinPath(container, pair[0].Value)
+			_, list := mappingValue(pair[1], "steps")
+			steps = append(steps, stepListItems(list, joinPath(path, "steps"))...)
+		}
+	}
+	return steps
+}
+
+func stepListItems(list *yaml.Node, path string) []stepItem {
+	var steps []stepItem
+	for idx, item := range sequenceItems(list) {
+		pairs := mappingPairs(item)
+		if len(pairs) != 1 {
+			continue
+		}
+		key, value := pairs[0][0], pairs[0][1]
+		itemPath := indexPath(path, idx)
+
+		switch {
+		case key.Value == models.StepListItemWithKey:
+			_, groupSteps := mappingValue(value, "steps")
+			steps = append(steps, stepListItems(groupSteps, joinPath(itemPath, "with.steps"))...)
+		case strings.HasPrefix(key.Value, models.StepListItemStepBundleKeyPrefix):
+		default:
+			steps = append(steps, stepItem{keyNode: key, value: value, path: joinPath(itemPath, key.Value)})
+		}
+	}
+	return steps
+}
+
+// stepLibStepID returns the ID of a StepLib step, it is false for the local, git and direct (_) steps.
+func (l *linter) stepLibStepID(step stepItem) (stepid.CanonicalID, bool) {
+	var defaultStepLibSource string
+	if _, value := mappingValue(l.root, "default_step_lib_source"); value != nil {
+		defaultStepLibSource = value.Value
+	}
+
+	stepID, err := stepid.CreateCanonicalIDFromString(step.keyNode.Value, defaultStepLibSource)
+	if err != nil || stepID.SteplibSource == "path" || stepID.SteplibSource == "git" || stepID.SteplibSource == "_" {
+		return stepid.CanonicalID{}, false
+	}
+	return stepID, true
+}
+
+var envReferencePattern = regexp.MustCompile(`^\$(?:([A-Za-z_][A-Za-z0-9_]*)|\{([A-Za-z_][A-Za-z0-9_]*)\})$`)
+
+// envReference returns the referenced env var's key if the value is a single env var reference, like $API_TOKEN.
+func envReference(value *yaml.Node) (string, bool) {
+	if value == nil || value.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	match := envReferencePattern.FindStringSubmatch(value.Value)
+	if match == nil {
+		return "", false
+	}
+	if match[1] != "" {
+		return match[1], true
+	}
+	return match[2], true
+}
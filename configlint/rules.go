@@ -0,0 +1,440 @@
+package configlint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/bitrise"
+	"github.com/bitrise-io/bitrise/models"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultMaxBeforeRunDepth = 3
+
+// Rules are the available lint rules, in the order they are checked.
+var Rules = []Rule{
+	{
+		ID:              "unused-utility-workflow",
+		Description:     "Utility workflows (workflows with an ID starting with _) should be referenced by before_run or after_run.",
+		DefaultSeverity: SeverityWarning,
+		check:           checkUnusedUtilityWorkflows,
+	},
+	{
+		ID:              "unpinned-step-version",
+		Description:     "StepLib steps should have at least their major version pinned (e.g. git-clone@8).",
+		DefaultSeverity: SeverityWarning,
+		Fixable:         true,
+		check:           checkUnpinnedStepVersions,
+	},
+	{
+		ID:              "duplicate-env-key",
+		Description:     "Env vars should not be defined twice in an env list or override an app env var in a workflow.",
+		DefaultSeverity: SeverityWarning,
+		Fixable:         true,
+		check:           checkDuplicateEnvKeys,
+	},
+	{
+		ID:              "invalid-run-if",
+		Description:     "run_if expressions should be valid templates.",
+		DefaultSeverity: SeverityError,
+		check:           checkRunIfExpressions,
+	},
+	{
+		ID:              "undefined-secret",
+		Description:     "Env vars referenced, but not defined in the config, should be defined in the secrets (inventory).",
+		DefaultSeverity: SeverityWarning,
+		check:           checkUndefinedSecrets,
+	},
+	{
+		ID:              "sensitive-input-from-non-secret",
+		Description:     "Sensitive (is_sensitive) step inputs should get their value from a secret. Inputs are sensitive if their opts in the config or the step.yml of the StepLib step mark them so.",
+		DefaultSeverity: SeverityError,
+		check:           checkSensitiveInputs,
+	},
+	{
+		ID:              "before-run-depth",
+		Description:     fmt.Sprintf("before_run chains should not be deeper than max_depth (default: %d).", defaultMaxBeforeRunDepth),
+		DefaultSeverity: SeverityWarning,
+		check:           checkBeforeRunDepth,
+	},
+}
+
+func checkUnusedUtilityWorkflows(l *linter) []Issue {
+	_, workflows := mappingValue(l.root, "workflows")
+
+	referenced := map[string]bool{}
+	for _, workflow := range mappingPairs(workflows) {
+		for _, key := range []string{"before_run", "after_run"} {
+			_, list := mappingValue(workflow[1], key)
+			for _, item := range sequenceItems(list) {
+				referenced[item.Value] = true
+			}
+		}
+	}
+
+	var issues []Issue
+	for _, workflow := range mappingPairs(workflows) {
+		id := workflow[0].Value
+		if strings.HasPrefix(id, "_") && !referenced[id] {
+			issues = append(issues, newIssue(workflow[0], joinPath("workflows", id), "utility workflow (%s) is not referenced by any before_run or after_run", id))
+		}
+	}
+	return issues
+}
+
+func checkUnpinnedStepVersions(l *linter) []Issue {
+	var issues []Issue
+	for _, step := range l.steps() {
+		stepID, ok := l.stepLibStepID(step)
+		if !ok {
+			continue
+		}
+		if stepID.Version != "" {
+			continue
+		}
+
+		issue := newIssue(step.keyNode, step.path, "step (%s) has no pinned major version", step.keyNode.Value)
+		if l.fix() && l.opts.StepVersionResolver != nil {
+			latest, err := l.opts.StepVersionResolver.ResolveVersion(stepID.SteplibSource, stepID.IDorURI, "")
+			if err != nil {
+				l.err = fmt.Errorf("%s: failed to resolve step (%s) version: %w", step.path, step.keyNode.Value, err)
+				return nil
+			}
+			step.keyNode.Value = strings.TrimSuffix(step.keyNode.Value, "@") + "@" + strings.Split(latest, ".")[0]
+			issue.Fixed = true
+			l.modified = true
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+func checkDuplicateEnvKeys(l *linter) []Issue {
+	var issues []Issue
+
+	// fixDuplicate removes the duplicate if it has the same value as the original, so removing it doesn't change the config.
+	fixDuplicate := func(list *yaml.Node, duplicate, original envItem, issue Issue) Issue {
+		if l.fix() && duplicate.opts == nil && original.opts == nil && sameScalar(duplicate.value, original.value) {
+			removeSequenceItem(list, duplicate.node)
+			issue.Fixed = true
+			l.modified = true
+		}
+		return issue
+	}
+
+	checkList := func(list *yaml.Node, path string, inherited map[string]envItem) {
+		defined := map[string]envItem{}
+		for _, env := range envItems(list, path) {
+			if original, ok := defined[env.key()]; ok {
+				issue := newIssue(env.keyNode, env.path, "env var (%s) is already defined at %s", env.key(), original.path)
+				issues = append(issues, fixDuplicate(list, env, original, issue))
+				continue
+			}
+			defined[env.key()] = env
+
+			if original, ok := inherited[env.key()]; ok {
+				issue := newIssue(env.keyNode, env.path, "env var (%s) overrides the app env var defined at %s", env.key(), original.path)
+				issues = append(issues, fixDuplicate(list, env, original, issue))
+			}
+		}
+	}
+
+	_, app := mappingValue(l.root, "app")
+	_, appEnvs := mappingValue(app, "envs")
+	checkList(appEnvs, "app.envs", nil)
+
+	appEnvsByKey := map[string]envItem{}
+	for _, env := range envItems(appEnvs, "app.envs") {
+		if _, ok := appEnvsByKey[env.key()]; !ok {
+			appEnvsByKey[env.key()] = env
+		}
+	}
+
+	_, workflows := mappingValue(l.root, "workflows")
+	for _, workflow := range mappingPairs(workflows) {
+		_, envs := mappingValue(workflow[1], "envs")
+		checkList(envs, joinPath(joinPath("workflows", workflow[0].Value), "envs"), appEnvsByKey)
+	}
+
+	return issues
+}
+
+func checkRunIfExpressions(l *linter) []Issue {
+	var issues []Issue
+	check := func(node *yaml.Node, path string) {
+		keyNode, value := mappingValue(node, "run_if")
+		if value == nil || value.Kind != yaml.ScalarNode || value.Value == "" {
+			return
+		}
+		if err := bitrise.CheckTemplate(value.Value); err != nil {
+			issues = append(issues, newIssue(keyNode, joinPath(path, "run_if"), "invalid run_if expression (%s): %s", value.Value, err))
+		}
+	}
+
+	for _, step := range l.steps() {
+		check(step.value, step.path)
+	}
+
+	checkStage := func(stage *yaml.Node, path string) {
+		check(stage, path)
+		_, workflows := mappingValue(stage, "workflows")
+		for idx, item := range sequenceItems(workflows) {
+			for _, workflow := range mappingPairs(item) {
+				check(workflow[1], joinPath(indexPath(joinPath(path, "workflows"), idx), workflow[0].Value))
+			}
+		}
+	}
+
+	_, stages := mappingValue(l.root, "stages")
+	for _, stage := range mappingPairs(stages) {
+		checkStage(stage[1], joinPath("stages", stage[0].Value))
+	}
+
+	_, pipelines := mappingValue(l.root, "pipelines")
+	for _, pipeline := range mappingPairs(pipelines) {
+		path := joinPath(joinPath("pipelines", pipeline[0].Value), "stages")
+		_, pipelineStages := mappingValue(pipeline[1], "stages")
+		for idx, item := range sequenceItems(pipelineStages) {
+			for _, stage := range mappingPairs(item) {
+				checkStage(stage[1], joinPath(indexPath(path, idx), stage[0].Value))
+			}
+		}
+	}
+
+	return issues
+}
+
+// Env vars exposed by the CLI and the build environment, they are not expected to be in the secrets.
+var (
+	exposedEnvPrefixes = []string{"BITRISE", "GIT_"}
+	exposedEnvs        = map[string]bool{"CI": true, "PR": true, "HOME": true, "PATH": true, "USER": true, "PWD": true, "TMPDIR": true, "SHELL": true, "LANG": true}
+)
+
+func checkUndefinedSecrets(l *linter) []Issue {
+	if l.opts.Secrets == nil {
+		return nil
+	}
+
+	defined := l.definedEnvKeys()
+	for _, secret := range l.opts.Secrets {
+		defined[secret] = true
+	}
+
+	var issues []Issue
+	for _, reference := range l.envReferences() {
+		key, _ := envReference(reference.value)
+		if defined[key] || isExposedEnv(key) {
+			continue
+		}
+		issues = append(issues, newIssue(reference.value, reference.path, "env var (%s) is not defined in the config or in the secrets", key))
+	}
+	return issues
+}
+
+func checkSensitiveInputs(l *linter) []Issue {
+	configEnvs := l.definedEnvKeys()
+
+	var issues []Issue
+	for _, step := range l.steps() {
+		var stepSensitiveInputs map[string]bool
+		isStepResolved := false
+
+		_, inputs := mappingValue(step.value, "inputs")
+		for _, input := range envItems(inputs, joinPath(step.path, "inputs")) {
+			if input.value == nil || input.value.Kind != yaml.ScalarNode || input.value.Tag == "!!null" || input.value.Value == "" {
+				continue
+			}
+
+			// The is_sensitive option of the config overrides the step.yml
+			var isSensitive bool
+			if opt := input.sensitiveOpt(); opt != nil {
+				isSensitive = *opt
+			} else {
+				if !isStepResolved {
+					stepSensitiveInputs = l.sensitiveStepInputs(step)
+					isStepResolved = true
+				}
+				isSensitive = stepSensitiveInputs[input.key()]
+			}
+			if !isSensitive {
+				continue
+			}
+
+			key, isReference := envReference(input.value)
+			switch {
+			case !isReference:
+				issues = append(issues, newIssue(input.value, input.path, "sensitive input (%s) has a literal value, reference a secret instead", input.key()))
+			case configEnvs[key]:
+				issues = append(issues, newIssue(input.value, input.path, "sensitive input (%s) is fed from a non-secret env var (%s) defined in the config", input.key(), key))
+			}
+		}
+	}
+	return issues
+}
+
+// sensitiveStepInputs returns the inputs marked sensitive in the step.yml of a StepLib step.
+func (l *linter) sensitiveStepInputs(step stepItem) map[string]bool {
+	if l.opts.StepInputResolver == nil {
+		return nil
+	}
+	stepID, ok := l.stepLibStepID(step)
+	if !ok {
+		return nil
+	}
+
+	keys, err := l.opts.StepInputResolver.SensitiveInputs(stepID.SteplibSource, stepID.IDorURI, stepID.Version)
+	if err != nil {
+		return nil
+	}
+	inputs := map[string]bool{}
+	for _, key := range keys {
+		inputs[key] = true
+	}
+	return inputs
+}
+
+func checkBeforeRunDepth(l *linter) []Issue {
+	maxDepth := l.ruleConfig().MaxDepth
+	if maxDepth == 0 {
+		maxDepth = defaultMaxBeforeRunDepth
+	}
+
+	_, workflows := mappingValue(l.root, "workflows")
+	beforeRuns := map[string][]string{}
+	for _, workflow := range mappingPairs(workflows) {
+		_, list := mappingValue(workflow[1], "before_run")
+		for _, item := range sequenceItems(list) {
+			beforeRuns[workflow[0].Value] = append(beforeRuns[workflow[0].Value], item.Value)
+		}
+	}
+
+	depths := map[string]int{}
+	var depth func(id string, visiting map[string]bool) int
+	depth = func(id string, visiting map[string]bool) int {
+		if d, ok := depths[id]; ok {
+			return d
+		}
+		if visiting[id] {
+			// Reference cycles are reported by the config validation
+			return 0
+		}
+		visiting[id] = true
+
+		d := 0
+		for _, before := range beforeRuns[id] {
+			if beforeDepth := depth(before, visiting) + 1; beforeDepth > d {
+				d = beforeDepth
+			}
+		}
+		depths[id] = d
+		return d
+	}
+
+	var issues []Issue
+	for _, workflow := range mappingPairs(workflows) {
+		id := workflow[0].Value
+		if d := depth(id, map[string]bool{}); d > maxDepth {
+			keyNode, _ := mappingValue(workflow[1], "before_run")
+			issues = append(issues, newIssue(keyNode, joinPath(joinPath("workflows", id), "before_run"), "before_run chain of workflow (%s) is %d deep, the maximum is %d", id, d, maxDepth))
+		}
+	}
+	return issues
+}
+
+// definedEnvKeys returns the keys of the env vars and the step outputs defined in the config.
+func (l *linter) definedEnvKeys() map[string]bool {
+	keys := map[string]bool{}
+	for _, list := range l.envLists() {
+		for _, env := range envItems(list.node, list.path) {
+			keys[env.key()] = true
+		}
+	}
+	for _, step := range l.steps() {
+		_, outputs := mappingValue(step.value, "outputs")
+		for _, output := range envItems(outputs, "") {
+			keys[output.key()] = true
+		}
+	}
+	return keys
+}
+
+type reference struct {
+	value *yaml.Node
+	path  string
+}
+
+// envReferences returns the env var and step input values which are a single env var reference.
+func (l *linter) envReferences() []reference {
+	var references []reference
+	add := func(list *yaml.Node, path string) {
+		for _, env := range envItems(list, path) {
+			if _, ok := envReference(env.value); ok {
+				references = append(references, reference{value: env.value, path: env.path})
+			}
+		}
+	}
+
+	for _, list := range l.envLists() {
+		add(list.node, list.path)
+	}
+	for _, step := range l.steps() {
+		_, inputs := mappingValue(step.value, "inputs")
+		add(inputs, joinPath(step.path, "inputs"))
+	}
+	return references
+}
+
+type envList struct {
+	node *yaml.Node
+	path string
+}
+
+// envLists returns the env lists of the app, the workflows, the step bundles (including the bundle references) and the containers.
+func (l *linter) envLists() []envList {
+	_, app := mappingValue(l.root, "app")
+	_, appEnvs := mappingValue(app, "envs")
+	lists := []envList{{node: appEnvs, path: "app.envs"}}
+
+	for _, container := range []string{"workflows", "step_bundles", "containers", "services"} {
+		_, containers := mappingValue(l.root, container)
+		for _, pair := range mappingPairs(containers) {
+			path := joinPath(container, pair[0].Value)
+			_, envs := mappingValue(pair[1], "envs")
+			lists = append(lists, envList{node: envs, path: joinPath(path, "envs")})
+
+			if container != "workflows" {
+				continue
+			}
+			_, steps := mappingValue(pair[1], "steps")
+			for idx, item := range sequenceItems(steps) {
+				for _, step := range mappingPairs(item) {
+					if strings.HasPrefix(step[0].Value, models.StepListItemStepBundleKeyPrefix) {
+						_, bundleEnvs := mappingValue(step[1], "envs")
+						lists = append(lists, envList{node: bundleEnvs, path: joinPath(joinPath(indexPath(joinPath(path, "steps"), idx), step[0].Value), "envs")})
+					}
+				}
+			}
+		}
+	}
+
+	return lists
+}
+
+func sameScalar(a, b *yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Kind == yaml.ScalarNode && b.Kind == yaml.ScalarNode && a.Value == b.Value
+}
+
+func isExposedEnv(key string) bool {
+	if exposedEnvs[key] {
+		return true
+	}
+	for _, prefix := range exposedEnvPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,32 +1,55 @@
 package configmerge
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
-	pathutilV2 "github.com/bitrise-io/go-utils/v2/pathutil"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
 )
 
+const fullCommitHashLength = 40
+
+// fileReader reads the local config modules from the file system and the remote ones through the include cache.
+//
+// The include cache is content-addressed: a remote file is stored under the hash of its repository,
+// the resolved commit and its path, so it never has to be fetched again. The commit a reference (RepoKey)
+// was last resolved to is stored next to it, it is used to resolve references without fetching in offline mode.
 type fileReader struct {
-	tmpDir    string
-	repoCache map[string]string
-	logger    Logger
+	cacheDir    string
+	offlineMode bool
+	depth       int
+	repoCache   map[string]*git.Repository
+	commitCache map[string]string
+	logger      Logger
 }
 
-func NewConfigReader(logger Logger) (ConfigReader, error) {
-	tmpDir, err := pathutilV2.NewPathProvider().CreateTempDir("config-merge")
-	if err != nil {
-		return nil, err
+// NewConfigReader creates a reader which caches the remote config modules in cacheDir.
+// In offline mode the cached config modules are used if fetching the repository fails.
+func NewConfigReader(cacheDir string, offlineMode bool, logger Logger) (ConfigReader, error) {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create include cache dir: %w", err)
 	}
 
+	return newFileReader(cacheDir, offlineMode, 1, logger), nil
+}
+
+func newFileReader(cacheDir string, offlineMode bool, depth int, logger Logger) fileReader {
 	return fileReader{
-		tmpDir:    tmpDir,
-		repoCache: map[string]string{},
-		logger:    logger,
-	}, nil
+		cacheDir:    cacheDir,
+		offlineMode: offlineMode,
+		depth:       depth,
+		repoCache:   map[string]*git.Repository{},
+		commitCache: map[string]string{},
+		logger:      logger,
+	}
 }
 
 func (f fileReader) Read(ref ConfigReference) ([]byte, error) {
@@ -34,24 +57,55 @@ func (f fileReader) Read(ref ConfigReference) ([]byte, error) {
 		return f.readFileFromFileSystem(ref.Path)
 	}
 
-	cachedRepoDir := f.getRepo(ref)
-	if cachedRepoDir != "" {
-		pth := filepath.Join(cachedRepoDir, ref.Path)
-		return f.readFileFromFileSystem(pth)
+	commit, err := f.resolveCommit(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	cachePth, err := f.objectPath(ref, commit)
+	if err != nil {
+		return nil, err
+	}
+	if content, err := os.ReadFile(cachePth); err == nil {
+		return content, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	repo, err := f.getRepo(ref)
+	if err != nil {
+		if f.offlineMode {
+			return nil, fmt.Errorf("%s is not cached and fetching the repository failed: %w", ref.Key(), err)
+		}
+		return nil, err
 	}
 
-	repoDir, err := f.cloneGitRepository(ref)
+	content, err := readFileAtCommit(repo, commit, ref.Path)
 	if err != nil {
 		return nil, err
 	}
 
-	f.setRepo(repoDir, ref)
-	pth := filepath.Join(repoDir, ref.Path)
-	return f.readFileFromFileSystem(pth)
+	if err := writeFileAtomically(cachePth, content); err != nil {
+		f.logger.Warnf("Failed to cache %s: %s", ref.Key(), err)
+	}
+
+	return content, nil
+}
+
+func (f fileReader) ResolvedCommit(ref ConfigReference) (string, error) {
+	commit, ok := f.commitCache[ref.RepoKey()]
+	if !ok {
+		return "", fmt.Errorf("repository (%s) is not fetched", ref.RepoKey())
+	}
+	return commit, nil
 }
 
+// CleanupRepoDirs releases the fetched repositories, the include cache is kept.
 func (f fileReader) CleanupRepoDirs() error {
-	return os.RemoveAll(f.tmpDir)
+	for key := range f.repoCache {
+		delete(f.repoCache, key)
+	}
+	return nil
 }
 
 func isLocalReference(reference ConfigReference) bool {
@@ -71,70 +125,213 @@ func (f fileReader) readFileFromFileSystem(name string) ([]byte, error) {
 	return io.ReadAll(file)
 }
 
-func (f fileReader) cloneGitRepository(ref ConfigReference) (string, error) {
-	opts := git.CloneOptions{
-		URL: ref.Repository,
+// resolveCommit returns the full commit hash the reference points to.
+// Full commit hashes are resolved without fetching, and so are the previously resolved short commit hashes,
+// branches and tags are fetched unless the fetch fails in offline mode.
+func (f fileReader) resolveCommit(ref ConfigReference) (string, error) {
+	if commit, ok := f.commitCache[ref.RepoKey()]; ok {
+		return commit, nil
+	}
+
+	commit := ""
+	if len(ref.Commit) == fullCommitHashLength {
+		commit = strings.ToLower(ref.Commit)
+	} else if ref.Commit != "" {
+		commit = f.cachedCommit(ref)
+	}
+
+	if commit == "" {
+		repo, err := f.getRepo(ref)
+		if err != nil {
+			cached := f.cachedCommit(ref)
+			if !f.offlineMode || cached == "" {
+				return "", err
+			}
+
+			f.logger.Warnf("Failed to fetch %s, using the cached commit (%s): %s", ref.RepoKey(), cached, err)
+			commit = cached
+		} else {
+			hash, err := resolveRevision(repo, ref)
+			if err != nil {
+				return "", err
+			}
+			commit = hash.String()
+			f.cacheCommit(ref, commit)
+		}
 	}
-	if ref.Branch != "" {
-		opts.ReferenceName = plumbing.NewBranchReferenceName(ref.Branch)
+
+	// The commit is part of the include cache path
+	if !isFullCommitHash(commit) {
+		return "", fmt.Errorf("invalid commit hash (%s) resolved for %s", commit, ref.RepoKey())
 	}
 
-	repoDir := filepath.Join(f.tmpDir, ref.RepoKey())
+	f.commitCache[ref.RepoKey()] = commit
+	return commit, nil
+}
+
+// isFullCommitHash reports whether the commit is a full, lowercase hex commit hash.
+func isFullCommitHash(commit string) bool {
+	return len(commit) == fullCommitHashLength && isHexString(commit)
+}
+
+// getRepo returns the fetched repository of the reference, fetching it if needed.
+func (f fileReader) getRepo(ref ConfigReference) (*git.Repository, error) {
+	if repo, ok := f.repoCache[ref.RepoKey()]; ok {
+		return repo, nil
+	}
 
-	repo, cloneErr := git.PlainClone(repoDir, false, &opts)
+	repo, err := f.fetchGitRepository(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	f.repoCache[ref.RepoKey()] = repo
+	return repo, nil
+}
+
+// fetchGitRepository fetches the repository into memory without checking out the files.
+// Branches and tags are fetched shallow, only their head commit is downloaded.
+func (f fileReader) fetchGitRepository(ref ConfigReference) (*git.Repository, error) {
+	opts := git.CloneOptions{
+		URL:        ref.Repository,
+		NoCheckout: true,
+	}
+	if ref.Commit == "" {
+		opts.SingleBranch = true
+		opts.Depth = f.depth
+		opts.Tags = git.NoTags
+		if ref.Tag != "" {
+			opts.ReferenceName = plumbing.NewTagReferenceName(ref.Tag)
+		} else {
+			opts.ReferenceName = plumbing.NewBranchReferenceName(ref.Branch)
+		}
+	}
+
+	repo, cloneErr := git.Clone(memory.NewStorage(), nil, &opts)
 	if cloneErr != nil {
 		if !isHttpFormatRepoURL(ref.Repository) {
-			return "", cloneErr
+			return nil, cloneErr
 		}
 
 		// Try repo url with ssh syntax
 		repoURL, err := parseGitRepoURL(ref.Repository)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		if repoURL.User == "" {
 			repoURL.User = "git"
 		}
 
 		opts.URL = generateSCPStyleSSHFormatRepoURL(repoURL)
-		repo, err = git.PlainClone(repoDir, false, &opts)
+		repo, err = git.Clone(memory.NewStorage(), nil, &opts)
 		if err != nil {
 			// Return the original error
-			return "", cloneErr
+			return nil, cloneErr
+		}
+	}
+
+	return repo, nil
+}
+
+func resolveRevision(repo *git.Repository, ref ConfigReference) (*plumbing.Hash, error) {
+	revision := plumbing.Revision(ref.Commit)
+	if ref.Commit == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return nil, err
 		}
+		revision = plumbing.Revision(head.Hash().String())
 	}
 
-	tree, err := repo.Worktree()
+	hash, err := repo.ResolveRevision(revision)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	if ref.Commit != "" {
-		h, err := repo.ResolveRevision(plumbing.Revision(ref.Commit))
+	// Annotated tags point to a tag object
+	if tag, err := repo.TagObject(*hash); err == nil {
+		commit, err := tag.Commit()
 		if err != nil {
-			return "", err
+			return nil, err
 		}
+		return &commit.Hash, nil
+	}
 
-		if err := tree.Checkout(&git.CheckoutOptions{
-			Hash: *h,
-		}); err != nil {
-			return "", err
-		}
-	} else if ref.Tag != "" {
-		if err := tree.Checkout(&git.CheckoutOptions{
-			Branch: plumbing.NewTagReferenceName(ref.Tag),
-		}); err != nil {
-			return "", err
+	return hash, nil
+}
+
+func readFileAtCommit(repo *git.Repository, commit, pth string) ([]byte, error) {
+	commitObject, err := repo.CommitObject(plumbing.NewHash(commit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find commit (%s): %w", commit, err)
+	}
+
+	file, err := commitObject.File(repoFilePath(pth))
+	if err != nil {
+		if errors.Is(err, object.ErrFileNotFound) {
+			return nil, fmt.Errorf("%s doesn't exist at commit %s", pth, commit)
 		}
+		return nil, err
 	}
 
-	return repoDir, nil
+	content, err := file.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// objectPath returns the cache path of a file at the given commit of the repository.
+func (f fileReader) objectPath(ref ConfigReference, commit string) (string, error) {
+	pth := repoFilePath(ref.Path)
+	if pth == "" {
+		return "", fmt.Errorf("invalid path in reference (%s)", ref.Key())
+	}
+	return filepath.Join(f.cacheDir, "objects", contentHash([]byte(ref.Repository)), commit, filepath.FromSlash(pth)), nil
 }
 
-func (f fileReader) getRepo(ref ConfigReference) string {
-	return f.repoCache[ref.RepoKey()]
+// repoFilePath returns the slash separated path relative to the repository root.
+func repoFilePath(pth string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(pth)), "/")
 }
 
-func (f fileReader) setRepo(dir string, ref ConfigReference) {
-	f.repoCache[ref.RepoKey()] = dir
+func (f fileReader) refPath(ref ConfigReference) string {
+	return filepath.Join(f.cacheDir, "refs", contentHash([]byte(ref.RepoKey())))
+}
+
+func (f fileReader) cachedCommit(ref ConfigReference) string {
+	content, err := os.ReadFile(f.refPath(ref))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
+func (f fileReader) cacheCommit(ref ConfigReference, commit string) {
+	if err := writeFileAtomically(f.refPath(ref), []byte(commit)); err != nil {
+		f.logger.Warnf("Failed to cache the commit of %s: %s", ref.RepoKey(), err)
+	}
+}
+
+// writeFileAtomically writes the file through a temporary file,
+// so that concurrent readers never see a partially written cache entry.
+func writeFileAtomically(pth string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(pth), 0755); err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(pth), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), pth)
 }
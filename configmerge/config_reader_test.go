@@ -0,0 +1,97 @@
+package configmerge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	logV2 "github.com/bitrise-io/go-utils/v2/log"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileReader_Read_IncludeCache(t *testing.T) {
+	repoDir := t.TempDir()
+	repo, err := git.PlainInit(repoDir, false)
+	require.NoError(t, err)
+
+	commit := func(content string) string {
+		require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "modules"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(repoDir, "modules", "steps.yml"), []byte(content), 0644))
+		worktree, err := repo.Worktree()
+		require.NoError(t, err)
+		_, err = worktree.Add("modules/steps.yml")
+		require.NoError(t, err)
+		hash, err := worktree.Commit(content, &git.CommitOptions{Author: &object.Signature{Name: "Bitrise", When: time.Now()}})
+		require.NoError(t, err)
+		return hash.String()
+	}
+	firstCommit := commit("first")
+	cacheDir := t.TempDir()
+	branchRef := ConfigReference{Repository: repoDir, Branch: "master", Path: "modules/steps.yml"}
+
+	reader := newFileReader(cacheDir, false, 0, logV2.NewLogger())
+	content, err := reader.Read(branchRef)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(content))
+	resolved, err := reader.ResolvedCommit(branchRef)
+	require.NoError(t, err)
+	require.Equal(t, firstCommit, resolved)
+	require.FileExists(t, filepath.Join(cacheDir, "objects", contentHash([]byte(repoDir)), firstCommit, "modules", "steps.yml"))
+
+	// Branches are fetched again by a new reader
+	secondCommit := commit("second")
+	reader = newFileReader(cacheDir, false, 0, logV2.NewLogger())
+	content, err = reader.Read(branchRef)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(content))
+
+	_, err = repo.CreateTag("1.0.0", plumbing.NewHash(firstCommit), &git.CreateTagOptions{Tagger: &object.Signature{Name: "Bitrise", When: time.Now()}, Message: "1.0.0"})
+	require.NoError(t, err)
+	tagRef := ConfigReference{Repository: repoDir, Tag: "1.0.0", Path: "modules/steps.yml"}
+	content, err = reader.Read(tagRef)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(content))
+	resolved, err = reader.ResolvedCommit(tagRef)
+	require.NoError(t, err)
+	require.Equal(t, firstCommit, resolved)
+
+	require.NoError(t, os.RemoveAll(repoDir))
+
+	// Full commit hashes are read from the cache without fetching
+	reader = newFileReader(cacheDir, false, 0, logV2.NewLogger())
+	content, err = reader.Read(ConfigReference{Repository: repoDir, Commit: firstCommit, Path: "./modules/steps.yml"})
+	require.NoError(t, err)
+	require.Equal(t, "first", string(content))
+
+	// Branches are resolved from the cache only in offline mode
+	reader = newFileReader(cacheDir, false, 0, logV2.NewLogger())
+	_, err = reader.Read(branchRef)
+	require.Error(t, err)
+
+	reader = newFileReader(cacheDir, true, 0, logV2.NewLogger())
+	content, err = reader.Read(branchRef)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(content))
+	resolved, err = reader.ResolvedCommit(branchRef)
+	require.NoError(t, err)
+	require.Equal(t, secondCommit, resolved)
+
+	// Not cached references fail in offline mode too
+	_, err = reader.Read(ConfigReference{Repository: repoDir, Branch: "develop", Path: "modules/steps.yml"})
+	require.Error(t, err)
+}
+
+func TestFileReader_Read_InvalidCommit(t *testing.T) {
+	cacheDir := t.TempDir()
+	reader := newFileReader(cacheDir, true, 0, logV2.NewLogger())
+
+	commit := strings.Repeat("../", 13) + "a"
+	ref := ConfigReference{Repository: "https://github.com/bitrise-io/example.git", Commit: commit, Path: "steps.yml"}
+	_, err := reader.Read(ref)
+	require.EqualError(t, err, "invalid commit hash ("+commit+") resolved for "+ref.RepoKey())
+}
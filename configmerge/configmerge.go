@@ -19,7 +19,8 @@ const (
 )
 
 type ConfigModule struct {
-	Include []ConfigReference `yaml:"include" json:"include"`
+	Include []ConfigReference      `yaml:"include" json:"include"`
+	Params  map[string]ConfigParam `yaml:"params" json:"params"`
 }
 
 func IsModularConfig(mainConfigPth string) (bool, error) {
@@ -41,6 +42,8 @@ func IsModularConfig(mainConfigPth string) (bool, error) {
 
 type ConfigReader interface {
 	Read(ref ConfigReference) ([]byte, error)
+	// ResolvedCommit returns the commit a previously read remote reference was checked out at.
+	ResolvedCommit(ref ConfigReference) (string, error)
 	CleanupRepoDirs() error
 }
 
@@ -52,7 +55,10 @@ type Merger struct {
 	configReader ConfigReader
 	logger       Logger
 
-	filesCount int
+	filesCount   int
+	lock         *Lock
+	resolvedLock Lock
+	provenance   models.Provenance
 }
 
 func NewMerger(configReader ConfigReader, logger Logger) Merger {
@@ -62,7 +68,24 @@ func NewMerger(configReader ConfigReader, logger Logger) Merger {
 	}
 }
 
+// UseLock makes the merger read the includes at their locked commit and fail if their content doesn't match the lock.
+func (m *Merger) UseLock(lock Lock) {
+	m.lock = &lock
+}
+
+// ResolvedLock returns the resolved commit and content hash of the includes read by the last merge.
+func (m *Merger) ResolvedLock() Lock {
+	return m.resolvedLock
+}
+
+// Provenance returns the origin (config reference key and line) of the values merged by the last merge.
+func (m *Merger) Provenance() models.Provenance {
+	return m.provenance
+}
+
 func (m *Merger) MergeConfig(mainConfigPth string) (string, *models.ConfigFileTreeModel, error) {
+	m.resolvedLock = Lock{}
+	m.provenance = nil
 	defer func() {
 		if err := m.configReader.CleanupRepoDirs(); err != nil {
 			m.logger.Warnf("Failed to cleanup repo dirs: %s", err)
@@ -83,10 +106,11 @@ func (m *Merger) MergeConfig(mainConfigPth string) (string, *models.ConfigFileTr
 		return "", nil, err
 	}
 
-	mergedConfigContent, err := configTree.Merge()
+	mergedConfigContent, provenance, err := configTree.MergeWithProvenance()
 	if err != nil {
 		return "", nil, err
 	}
+	m.provenance = provenance
 
 	return mergedConfigContent, configTree, nil
 }
@@ -97,6 +121,11 @@ func (m *Merger) buildConfigTree(configContent []byte, reference ConfigReference
 
 	m.filesCount++
 
+	configContent, err := applyParams(configContent, reference)
+	if err != nil {
+		return nil, err
+	}
+
 	var config ConfigModule
 	if err := yaml.Unmarshal(configContent, &config); err != nil {
 		return nil, err
@@ -119,7 +148,7 @@ func (m *Merger) buildConfigTree(configContent []byte, reference ConfigReference
 
 	var includedConfigTrees []models.ConfigFileTreeModel
 	for _, include := range config.Include {
-		moduleBytes, err := m.configReader.Read(include)
+		moduleBytes, err := m.readInclude(include)
 		if err != nil {
 			return nil, err
 		}
@@ -139,6 +168,44 @@ func (m *Merger) buildConfigTree(configContent []byte, reference ConfigReference
 	}, nil
 }
 
+func (m *Merger) readInclude(include ConfigReference) ([]byte, error) {
+	key := include.Key()
+
+	readReference := include
+	var locked *LockedInclude
+	if m.lock != nil {
+		locked = m.lock.include(key)
+		if locked == nil {
+			return nil, fmt.Errorf("include (%s) is missing from the lock file", key)
+		}
+		if locked.Commit != "" {
+			readReference.Commit = locked.Commit
+		}
+	}
+
+	content, err := m.configReader.Read(readReference)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := contentHash(content)
+	if locked != nil && locked.SHA256 != hash {
+		return nil, fmt.Errorf("include (%s) doesn't match the lock file: expected sha256 %s, got %s", key, locked.SHA256, hash)
+	}
+
+	resolved := LockedInclude{Key: key, SHA256: hash}
+	if !isLocalReference(include) {
+		commit, err := m.configReader.ResolvedCommit(readReference)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Commit = commit
+	}
+	m.resolvedLock.add(resolved)
+
+	return content, nil
+}
+
 func validateReference(reference ConfigReference, configContent []byte, config ConfigModule, filesCount int, depth int, keys []string) error {
 	key := reference.Key()
 
@@ -2,6 +2,7 @@ package configmerge
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -227,6 +228,113 @@ default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
 format_version: "15"
 `,
 		},
+		{
+			name: "Substitutes the parameters of the config module",
+			configReader: mockConfigReader{
+				fileSystemFiles: map[string][]byte{
+					"bitrise.yml": []byte(`format_version: "15"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+include:
+- path: android-build.yml
+  with:
+    module: app
+- path: android-build.yml
+  with:
+    module: wear
+    flavor: debug
+    is_always_run: true`),
+					"android-build.yml": []byte(`params:
+  module:
+    required: true
+  flavor:
+    default: release
+  is_always_run:
+    default: false
+
+workflows:
+  build_${{ params.module }}:
+    steps:
+    - android-build:
+        is_always_run: ${{ params.is_always_run }}
+        inputs:
+        - module: ${{ params.module }}
+        - variant: "${{ params.flavor }}"
+        - arguments: --flavor=${{params.flavor}}`),
+				},
+			},
+			mainConfigPth: "bitrise.yml",
+			wantConfig: `default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+format_version: "15"
+workflows:
+  build_app:
+    steps:
+    - android-build:
+        inputs:
+        - module: app
+        - variant: release
+        - arguments: --flavor=release
+        is_always_run: false
+  build_wear:
+    steps:
+    - android-build:
+        inputs:
+        - module: wear
+        - variant: debug
+        - arguments: --flavor=debug
+        is_always_run: true
+`,
+		},
+		{
+			name: "Required parameters have to be set",
+			configReader: mockConfigReader{
+				fileSystemFiles: map[string][]byte{
+					"bitrise.yml": []byte(`format_version: "15"
+include:
+- path: module.yml`),
+					"module.yml": []byte(`params:
+  module:
+    required: true`),
+				},
+			},
+			mainConfigPth: "bitrise.yml",
+			wantErr:       "required parameter (module) is not set for module.yml",
+		},
+		{
+			name: "Parameters have to be declared",
+			configReader: mockConfigReader{
+				fileSystemFiles: map[string][]byte{
+					"bitrise.yml": []byte(`format_version: "15"
+include:
+- path: module.yml
+  with:
+    flavor: debug`),
+					"module.yml": []byte(`params:
+  module:
+    default: app`),
+				},
+			},
+			mainConfigPth: "bitrise.yml",
+			wantErr:       "parameter (flavor) is not declared in module.yml@with:flavor=debug",
+		},
+		{
+			name: "Referenced parameters have to be declared",
+			configReader: mockConfigReader{
+				fileSystemFiles: map[string][]byte{
+					"bitrise.yml": []byte(`format_version: "15"
+include:
+- path: module.yml`),
+					"module.yml": []byte(`params:
+  module:
+    default: app
+app:
+  envs:
+  - FLAVOR: ${{ params.flavor }}`),
+				},
+			},
+			mainConfigPth: "bitrise.yml",
+			wantErr:       "undeclared parameter (flavor) is referenced in module.yml at line 6",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -246,11 +354,114 @@ format_version: "15"
 	}
 }
 
+func TestMerger_MergeConfig_Lock(t *testing.T) {
+	const (
+		repo      = "https://github.com/bitrise-io/examples-yamls.git"
+		oldCommit = "1f2e3d4c5b6a79881f2e3d4c5b6a79881f2e3d4c"
+		newCommit = "9a8b7c6d5e4f30219a8b7c6d5e4f30219a8b7c6d"
+	)
+	oldContainers := []byte(`containers:
+  golang:
+    image: golang:1.21`)
+	newContainers := []byte(`containers:
+  golang:
+    image: golang:1.22`)
+	steps := []byte(`step_bundles:
+  test: {}`)
+
+	configReader := mockConfigReader{
+		fileSystemFiles: map[string][]byte{
+			"bitrise.yml": []byte(`format_version: "15"
+include:
+- path: steps.yml
+- path: containers.yml
+  repository: https://github.com/bitrise-io/examples-yamls.git
+  branch: main`),
+			"steps.yml": steps,
+		},
+		repoFilesOnBranch: map[string]map[string]map[string][]byte{
+			repo: {"main": {"containers.yml": newContainers}},
+		},
+		repoFilesOnCommit: map[string]map[string]map[string][]byte{
+			repo: {
+				oldCommit: {"containers.yml": oldContainers},
+				newCommit: {"containers.yml": newContainers},
+			},
+		},
+		branchHeads: map[string]map[string]string{
+			repo: {"main": newCommit},
+		},
+	}
+	remoteKey := "repo:" + repo + ",containers.yml@branch:main"
+
+	t.Run("Resolves the includes", func(t *testing.T) {
+		m := NewMerger(configReader, logV2.NewLogger())
+		_, _, err := m.MergeConfig("bitrise.yml")
+		require.NoError(t, err)
+		require.Equal(t, Lock{Includes: []LockedInclude{
+			{Key: "steps.yml", SHA256: contentHash(steps)},
+			{Key: remoteKey, Commit: newCommit, SHA256: contentHash(newContainers)},
+		}}, m.ResolvedLock())
+	})
+
+	t.Run("Reads the includes at the locked commit", func(t *testing.T) {
+		m := NewMerger(configReader, logV2.NewLogger())
+		m.UseLock(Lock{Includes: []LockedInclude{
+			{Key: "steps.yml", SHA256: contentHash(steps)},
+			{Key: remoteKey, Commit: oldCommit, SHA256: contentHash(oldContainers)},
+		}})
+		got, _, err := m.MergeConfig("bitrise.yml")
+		require.NoError(t, err)
+		require.Contains(t, got, "golang:1.21")
+		require.Equal(t, oldCommit, m.ResolvedLock().Includes[1].Commit)
+	})
+
+	t.Run("Content hash mismatch", func(t *testing.T) {
+		m := NewMerger(configReader, logV2.NewLogger())
+		m.UseLock(Lock{Includes: []LockedInclude{
+			{Key: "steps.yml", SHA256: contentHash([]byte("modified"))},
+			{Key: remoteKey, Commit: oldCommit, SHA256: contentHash(oldContainers)},
+		}})
+		_, _, err := m.MergeConfig("bitrise.yml")
+		require.EqualError(t, err, fmt.Sprintf("include (steps.yml) doesn't match the lock file: expected sha256 %s, got %s", contentHash([]byte("modified")), contentHash(steps)))
+	})
+
+	t.Run("Include missing from the lock", func(t *testing.T) {
+		m := NewMerger(configReader, logV2.NewLogger())
+		m.UseLock(Lock{Includes: []LockedInclude{{Key: "steps.yml", SHA256: contentHash(steps)}}})
+		_, _, err := m.MergeConfig("bitrise.yml")
+		require.EqualError(t, err, "include ("+remoteKey+") is missing from the lock file")
+	})
+}
+
+func TestLock_ReadWrite(t *testing.T) {
+	pth := LockPath(filepath.Join(t.TempDir(), "bitrise.yml"))
+
+	lock, err := ReadLock(pth)
+	require.NoError(t, err)
+	require.Nil(t, lock)
+
+	want := Lock{Includes: []LockedInclude{{Key: "steps.yml", SHA256: contentHash([]byte("steps"))}}}
+	require.NoError(t, want.Write(pth))
+
+	lock, err = ReadLock(pth)
+	require.NoError(t, err)
+	require.Equal(t, &want, lock)
+
+	invalid := Lock{Includes: []LockedInclude{{Key: "steps.yml", Commit: "../../steps", SHA256: contentHash([]byte("steps"))}}}
+	require.NoError(t, invalid.Write(pth))
+
+	_, err = ReadLock(pth)
+	require.EqualError(t, err, "invalid commit hash of include (steps.yml) in lock file ("+pth+"): ../../steps")
+}
+
 type mockConfigReader struct {
 	fileSystemFiles   map[string][]byte
 	repoFilesOnCommit map[string]map[string]map[string][]byte
 	repoFilesOnTag    map[string]map[string]map[string][]byte
 	repoFilesOnBranch map[string]map[string]map[string][]byte
+	// branchHeads are the commits of the repository branches
+	branchHeads map[string]map[string]string
 }
 
 func (m mockConfigReader) Read(ref ConfigReference) ([]byte, error) {
@@ -261,6 +472,13 @@ func (m mockConfigReader) Read(ref ConfigReference) ([]byte, error) {
 
 }
 
+func (m mockConfigReader) ResolvedCommit(ref ConfigReference) (string, error) {
+	if ref.Commit != "" {
+		return ref.Commit, nil
+	}
+	return m.branchHeads[ref.Repository][ref.Branch], nil
+}
+
 func (m mockConfigReader) CleanupRepoDirs() error {
 	return nil
 }
@@ -0,0 +1,87 @@
+package configmerge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LockFileName is the name of the lock file, it is stored next to the main config.
+const LockFileName = "bitrise.lock"
+
+// Lock records the resolved commit and the content hash of the included config modules,
+// so that the same config is merged until the lock is updated.
+type Lock struct {
+	Includes []LockedInclude `yaml:"includes" json:"includes"`
+}
+
+// LockedInclude is the resolved state of an include, identified by its reference key.
+type LockedInclude struct {
+	Key string `yaml:"key" json:"key"`
+	// Commit is the commit the remote include was read from, empty for local includes.
+	Commit string `yaml:"commit,omitempty" json:"commit,omitempty"`
+	SHA256 string `yaml:"sha256" json:"sha256"`
+}
+
+// LockPath returns the path of the lock file belonging to the main config.
+func LockPath(mainConfigPth string) string {
+	return filepath.Join(filepath.Dir(mainConfigPth), LockFileName)
+}
+
+// ReadLock reads the lock file, it returns nil if the lock file doesn't exist.
+func ReadLock(pth string) (*Lock, error) {
+	content, err := os.ReadFile(pth)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var lock Lock
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file (%s): %w", pth, err)
+	}
+
+	for _, include := range lock.Includes {
+		if include.Commit != "" && !isFullCommitHash(include.Commit) {
+			return nil, fmt.Errorf("invalid commit hash of include (%s) in lock file (%s): %s", include.Key, pth, include.Commit)
+		}
+	}
+	return &lock, nil
+}
+
+// Write ...
+func (l Lock) Write(pth string) error {
+	content, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	content = append([]byte("# This file is generated by 'bitrise merge --lock', do not edit it manually.\n"), content...)
+	return os.WriteFile(pth, content, 0644)
+}
+
+func (l Lock) include(key string) *LockedInclude {
+	for i, include := range l.Includes {
+		if include.Key == key {
+			return &l.Includes[i]
+		}
+	}
+	return nil
+}
+
+func (l *Lock) add(include LockedInclude) {
+	if l.include(include.Key) == nil {
+		l.Includes = append(l.Includes, include)
+	}
+}
+
+func contentHash(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,203 @@
+package configmerge
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParamsKey is the root key of a config module, where the module declares its parameters.
+const ParamsKey = "params"
+
+// ConfigParam is a parameter of a config module, its value is set by the include's with map.
+// A parameter is either required or has a default value (empty by default).
+type ConfigParam struct {
+	Default  string `yaml:"default" json:"default"`
+	Required bool   `yaml:"required" json:"required"`
+}
+
+// paramReferencePattern matches the parameter references, like ${{ params.flavor }}.
+var paramReferencePattern = regexp.MustCompile(`\$\{\{\s*params\.([A-Za-z0-9_-]*)\s*\}\}`)
+
+// applyParams substitutes the parameter references of the config module with the values of the include's with map
+// and the declared defaults, and removes the parameter declarations.
+// Config modules without parameters are returned unchanged.
+// The lines of the config module are kept if possible, so that the merged values can be traced back to their line.
+func applyParams(content []byte, reference ConfigReference) ([]byte, error) {
+	key := reference.Key()
+	if len(reference.With) == 0 && !bytes.Contains(content, []byte(ParamsKey)) {
+		return content, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		if len(reference.With) > 0 {
+			return nil, fmt.Errorf("parameters are set for %s, but it doesn't declare parameters", key)
+		}
+		return content, nil
+	}
+	root := document.Content[0]
+
+	declared := map[string]ConfigParam{}
+	hasParams := false
+	// paramsLines are the first and the last line of the parameter declarations
+	var paramsLines [2]int
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != ParamsKey {
+			continue
+		}
+		if err := root.Content[i+1].Decode(&declared); err != nil {
+			return nil, fmt.Errorf("invalid parameters in %s: %w", key, err)
+		}
+		paramsLines = [2]int{root.Content[i].Line, bytes.Count(content, []byte("\n")) + 1}
+		if i+2 < len(root.Content) {
+			paramsLines[1] = root.Content[i+2].Line - 1
+		}
+		root.Content = append(root.Content[:i], root.Content[i+2:]...)
+		hasParams = true
+		break
+	}
+
+	if !hasParams && len(reference.With) == 0 && !paramReferencePattern.Match(content) {
+		return content, nil
+	}
+
+	values, err := paramValues(declared, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := substituteParams(root, values, key); err != nil {
+		return nil, err
+	}
+
+	// Substituting the references in place keeps the lines, unless it changes the meaning of the YML,
+	// for example when a value contains a colon.
+	substituted := substituteParamsInText(content, values, paramsLines)
+	var substitutedDocument yaml.Node
+	if err := yaml.Unmarshal(substituted, &substitutedDocument); err == nil && equalNodes(&document, &substitutedDocument) {
+		return substituted, nil
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func paramValues(declared map[string]ConfigParam, reference ConfigReference) (map[string]string, error) {
+	key := reference.Key()
+
+	for name := range reference.With {
+		if _, ok := declared[name]; !ok {
+			return nil, fmt.Errorf("parameter (%s) is not declared in %s", name, key)
+		}
+	}
+
+	values := map[string]string{}
+	for name, param := range declared {
+		if param.Required && param.Default != "" {
+			return nil, fmt.Errorf("required parameter (%s) has a default value in %s", name, key)
+		}
+
+		value, ok := reference.With[name]
+		if !ok {
+			if param.Required {
+				return nil, fmt.Errorf("required parameter (%s) is not set for %s", name, key)
+			}
+			value = param.Default
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// substituteParams substitutes the parameter references in the scalar keys and values.
+// A value which is a single parameter reference takes the type of the parameter value (for example a boolean),
+// otherwise the result is a string.
+func substituteParams(node *yaml.Node, values map[string]string, key string) error {
+	if node.Kind == yaml.ScalarNode {
+		if !strings.Contains(node.Value, "${{") {
+			return nil
+		}
+
+		var undeclared []string
+		isSingleReference := paramReferencePattern.FindString(node.Value) == node.Value
+		node.Value = paramReferencePattern.ReplaceAllStringFunc(node.Value, func(reference string) string {
+			name := paramReferencePattern.FindStringSubmatch(reference)[1]
+			value, ok := values[name]
+			if !ok {
+				undeclared = append(undeclared, name)
+			}
+			return value
+		})
+		if len(undeclared) > 0 {
+			sort.Strings(undeclared)
+			return fmt.Errorf("undeclared parameter (%s) is referenced in %s at line %d", strings.Join(undeclared, ", "), key, node.Line)
+		}
+
+		if isSingleReference && node.Value != "" && node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			node.Tag = ""
+		}
+		return nil
+	}
+
+	for _, child := range node.Content {
+		if err := substituteParams(child, values, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// substituteParamsInText substitutes the parameter references in the text of the config module
+// and empties the lines of the parameter declarations.
+func substituteParamsInText(content []byte, values map[string]string, paramsLines [2]int) []byte {
+	lines := strings.Split(string(content), "\n")
+	for idx := range lines {
+		if lineNumber := idx + 1; paramsLines[0] <= lineNumber && lineNumber <= paramsLines[1] {
+			lines[idx] = ""
+			continue
+		}
+
+		lines[idx] = paramReferencePattern.ReplaceAllStringFunc(lines[idx], func(reference string) string {
+			value, ok := values[paramReferencePattern.FindStringSubmatch(reference)[1]]
+			if !ok {
+				return reference
+			}
+			return value
+		})
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+func equalNodes(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || len(a.Content) != len(b.Content) {
+		return false
+	}
+	if a.Kind == yaml.ScalarNode && (a.Value != b.Value || a.ShortTag() != b.ShortTag()) {
+		return false
+	}
+	if a.Kind == yaml.AliasNode && a.Value != b.Value {
+		return false
+	}
+	for i := range a.Content {
+		if !equalNodes(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package configmerge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_applyParams(t *testing.T) {
+	module := `params:
+  module:
+    required: true
+  flavor:
+    default: release
+
+workflows:
+  build:
+    envs:
+    - MODULE: ${{ params.module }}
+    - TASK: assemble${{ params.flavor }}
+`
+
+	tests := []struct {
+		name    string
+		with    map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "Keeps the lines of the config module",
+			with: map[string]string{"module": "app"},
+			want: `
+
+
+
+
+
+workflows:
+  build:
+    envs:
+    - MODULE: app
+    - TASK: assemblerelease
+`,
+		},
+		{
+			name: "Re-encodes the config module if substituting in place changes the YML",
+			with: map[string]string{"module": "app: wear", "flavor": "#debug"},
+			want: `workflows:
+  build:
+    envs:
+      - MODULE: 'app: wear'
+      - TASK: assemble#debug
+`,
+		},
+		{
+			name:    "Required parameter is missing",
+			wantErr: "required parameter (module) is not set for module.yml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyParams([]byte(module), ConfigReference{Path: "module.yml", With: tt.with})
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got))
+		})
+	}
+}
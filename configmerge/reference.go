@@ -3,6 +3,8 @@ package configmerge
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 type ConfigReference struct {
@@ -11,6 +13,8 @@ type ConfigReference struct {
 	Commit     string `yaml:"commit" json:"commit"`
 	Tag        string `yaml:"tag" json:"tag"`
 	Path       string `yaml:"path" json:"path"`
+	// With sets the parameters of the config module.
+	With map[string]string `yaml:"with,omitempty" json:"with,omitempty"`
 }
 
 func (r ConfigReference) Key() string {
@@ -27,6 +31,15 @@ func (r ConfigReference) Key() string {
 		key += "@branch:" + r.Branch
 	}
 
+	if len(r.With) > 0 {
+		var params []string
+		for name, value := range r.With {
+			params = append(params, name+"="+value)
+		}
+		sort.Strings(params)
+		key += "@with:" + strings.Join(params, ",")
+	}
+
 	return key
 }
 
@@ -69,6 +82,7 @@ func (r ConfigReference) Validate() error {
 		} else if len(includeCommit) == 40 {
 			isCommitValid = true
 		}
+		isCommitValid = isCommitValid && isHexString(strings.ToLower(includeCommit))
 	}
 	if !isCommitValid {
 		return fmt.Errorf("invalid commit hash in reference (%s): %s", key, includeCommit)
@@ -84,3 +98,12 @@ func (r ConfigReference) Validate() error {
 
 	return nil
 }
+
+func isHexString(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,356 @@
+// Package configmigrate rewrites the deprecated constructs of a bitrise.yml, preserving its comments and key order.
+package configmigrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/bitrise-io/stepman/stepid"
+	"github.com/hashicorp/go-version"
+	"gopkg.in/yaml.v3"
+)
+
+// StepVersionResolver resolves the step version constraint (e.g. 8 or 8.1) to the latest matching step version.
+type StepVersionResolver interface {
+	ResolveVersion(stepLibSource, stepID, versionConstraint string) (string, error)
+}
+
+// Options ...
+type Options struct {
+	// StepVersionResolver is used to pin the floating step versions, they are kept as is if it is nil.
+	StepVersionResolver StepVersionResolver
+}
+
+// Result is the migrated config and the list of changes made.
+type Result struct {
+	Content []byte
+	Changes []string
+}
+
+// Migrate converts the deprecated trigger map items into typed items, bumps the format version
+// and pins the step versions if requested.
+func Migrate(content []byte, opts Options) (Result, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return Result{}, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return Result{}, errors.New("config is not a YAML map")
+	}
+	root := document.Content[0]
+
+	var changes []string
+
+	separatedKeys := keysAfterBlankLine(content, root)
+
+	change, err := migrateFormatVersion(root)
+	if err != nil {
+		return Result{}, err
+	}
+	if change != "" {
+		changes = append(changes, change)
+	}
+
+	triggerMapChanges, err := migrateTriggerMap(root)
+	if err != nil {
+		return Result{}, err
+	}
+	changes = append(changes, triggerMapChanges...)
+
+	if opts.StepVersionResolver != nil {
+		stepChanges, err := pinStepVersions(root, opts.StepVersionResolver)
+		if err != nil {
+			return Result{}, err
+		}
+		changes = append(changes, stepChanges...)
+	}
+
+	if len(changes) == 0 {
+		return Result{Content: content}, nil
+	}
+
+	migrated, err := encode(&document, content, separatedKeys)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{Content: migrated, Changes: changes}, nil
+}
+
+// Encode writes the modified document of the original config, keeping the original indentation
+// and the blank lines between the top level sections.
+func Encode(document *yaml.Node, original []byte) ([]byte, error) {
+	var originalDocument yaml.Node
+	if err := yaml.Unmarshal(original, &originalDocument); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var separatedKeys map[string]bool
+	if len(originalDocument.Content) > 0 {
+		separatedKeys = keysAfterBlankLine(original, originalDocument.Content[0])
+	}
+
+	return encode(document, original, separatedKeys)
+}
+
+func encode(document *yaml.Node, original []byte, separatedKeys map[string]bool) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(detectIndent(original))
+	if err := encoder.Encode(document); err != nil {
+		return nil, fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return restoreBlankLines(buffer.Bytes(), separatedKeys), nil
+}
+
+func migrateFormatVersion(root *yaml.Node) (string, error) {
+	_, value := mappingValue(root, "format_version")
+	if value == nil {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "format_version"}
+		value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: models.FormatVersion, Style: yaml.DoubleQuotedStyle}
+		root.Content = append([]*yaml.Node{key, value}, root.Content...)
+		return fmt.Sprintf("format_version: set to %s", models.FormatVersion), nil
+	}
+
+	current, err := version.NewVersion(value.Value)
+	if err != nil {
+		return "", fmt.Errorf("invalid format_version (%s): %w", value.Value, err)
+	}
+	latest, err := version.NewVersion(models.FormatVersion)
+	if err != nil {
+		return "", err
+	}
+	if !current.LessThan(latest) {
+		return "", nil
+	}
+
+	change := fmt.Sprintf("format_version: %s -> %s", value.Value, models.FormatVersion)
+	value.Value = models.FormatVersion
+	return change, nil
+}
+
+// migrateTriggerMap converts the pattern items the same way the trigger map matching migrates them:
+// every pattern item becomes a push_branch item, followed by a pull_request_source_branch item if pull requests are allowed.
+func migrateTriggerMap(root *yaml.Node) ([]string, error) {
+	_, triggerMap := mappingValue(root, "trigger_map")
+	if triggerMap == nil || triggerMap.Kind != yaml.SequenceNode {
+		return nil, nil
+	}
+
+	var changes []string
+	var items []*yaml.Node
+	for idx, item := range triggerMap.Content {
+		patternKey, pattern := mappingValue(item, "pattern")
+		if pattern == nil {
+			items = append(items, item)
+			continue
+		}
+
+		isPullRequestAllowed := false
+		if _, value := mappingValue(item, "is_pull_request_allowed"); value != nil {
+			if err := value.Decode(&isPullRequestAllowed); err != nil {
+				return nil, fmt.Errorf("trigger item #%d: invalid is_pull_request_allowed: %w", idx+1, err)
+			}
+		}
+		removeMappingKey(item, "is_pull_request_allowed")
+
+		var prItem *yaml.Node
+		if isPullRequestAllowed {
+			prItem = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Style: item.Style}
+			for i := 0; i+1 < len(item.Content); i += 2 {
+				key, value := copyNode(item.Content[i]), copyNode(item.Content[i+1])
+				if item.Content[i] == patternKey {
+					key.Value = "pull_request_source_branch"
+				}
+				prItem.Content = append(prItem.Content, key, value)
+			}
+		}
+
+		patternKey.Value = "push_branch"
+		items = append(items, item)
+		if prItem != nil {
+			items = append(items, prItem)
+			changes = append(changes, fmt.Sprintf("trigger_map: pattern item #%d (%s) converted to push_branch and pull_request_source_branch items", idx+1, pattern.Value))
+		} else {
+			changes = append(changes, fmt.Sprintf("trigger_map: pattern item #%d (%s) converted to push_branch item", idx+1, pattern.Value))
+		}
+	}
+	triggerMap.Content = items
+
+	return changes, nil
+}
+
+func pinStepVersions(root *yaml.Node, resolver StepVersionResolver) ([]string, error) {
+	var defaultStepLibSource string
+	if _, value := mappingValue(root, "default_step_lib_source"); value != nil {
+		defaultStepLibSource = value.Value
+	}
+
+	var changes []string
+	for _, container := range []string{"workflows", "step_bundles"} {
+		_, containers := mappingValue(root, container)
+		if containers == nil || containers.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for i := 0; i+1 < len(containers.Content); i += 2 {
+			id, value := containers.Content[i].Value, containers.Content[i+1]
+			_, steps := mappingValue(value, "steps")
+			stepChanges, err := pinStepListVersions(steps, defaultStepLibSource, resolver)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", container, id, err)
+			}
+			for _, change := range stepChanges {
+				changes = append(changes, fmt.Sprintf("%s.%s: %s", container, id, change))
+			}
+		}
+	}
+	return changes, nil
+}
+
+func pinStepListVersions(steps *yaml.Node, defaultStepLibSource string, resolver StepVersionResolver) ([]string, error) {
+	if steps == nil || steps.Kind != yaml.SequenceNode {
+		return nil, nil
+	}
+
+	var changes []string
+	for _, step := range steps.Content {
+		if step.Kind != yaml.MappingNode || len(step.Content) != 2 {
+			continue
+		}
+		key := step.Content[0]
+
+		if key.Value == "with" {
+			// Steps of a with group
+			_, groupSteps := mappingValue(step.Content[1], "steps")
+			groupChanges, err := pinStepListVersions(groupSteps, defaultStepLibSource, resolver)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, groupChanges...)
+			continue
+		}
+		if strings.HasPrefix(key.Value, "bundle::") {
+			continue
+		}
+
+		stepID, err := stepid.CreateCanonicalIDFromString(key.Value, defaultStepLibSource)
+		if err != nil || stepID.SteplibSource == "path" || stepID.SteplibSource == "git" || stepID.SteplibSource == "_" {
+			continue
+		}
+
+		constraint, err := stepmanModels.ParseRequiredVersion(stepID.Version)
+		if err != nil {
+			return nil, fmt.Errorf("step (%s): %w", key.Value, err)
+		}
+		if constraint.VersionLockType == stepmanModels.Fixed {
+			continue
+		}
+
+		resolved, err := resolver.ResolveVersion(stepID.SteplibSource, stepID.IDorURI, stepID.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve step (%s) version: %w", key.Value, err)
+		}
+
+		pinned := strings.SplitN(key.Value, "@", 2)[0] + "@" + resolved
+		changes = append(changes, fmt.Sprintf("%s -> %s", key.Value, pinned))
+		key.Value = pinned
+	}
+	return changes, nil
+}
+
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func removeMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// copyNode copies the node without its comments.
+func copyNode(node *yaml.Node) *yaml.Node {
+	copied := &yaml.Node{Kind: node.Kind, Tag: node.Tag, Value: node.Value, Style: node.Style}
+	for _, child := range node.Content {
+		copied.Content = append(copied.Content, copyNode(child))
+	}
+	return copied
+}
+
+// keysAfterBlankLine returns the top level keys separated from the previous section by a blank line,
+// as the YAML encoder drops the blank lines.
+func keysAfterBlankLine(content []byte, root *yaml.Node) map[string]bool {
+	lines := strings.Split(string(content), "\n")
+	keys := map[string]bool{}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i]
+		firstLine := key.Line - 1
+		if key.HeadComment != "" {
+			firstLine -= strings.Count(key.HeadComment, "\n") + 1
+		}
+		if firstLine > 0 && firstLine-1 < len(lines) && strings.TrimSpace(lines[firstLine-1]) == "" {
+			keys[key.Value] = true
+		}
+	}
+	return keys
+}
+
+func restoreBlankLines(content []byte, keys map[string]bool) []byte {
+	lines := strings.Split(string(content), "\n")
+	var restored []string
+	sectionStart := 0
+	for _, line := range lines {
+		if line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			if strings.HasPrefix(line, "#") {
+				if sectionStart < 0 {
+					sectionStart = len(restored)
+				}
+			} else {
+				key := strings.SplitN(line, ":", 2)[0]
+				if sectionStart < 0 {
+					sectionStart = len(restored)
+				}
+				if keys[key] && sectionStart > 0 {
+					restored = append(restored[:sectionStart], append([]string{""}, restored[sectionStart:]...)...)
+				}
+				sectionStart = -1
+			}
+		} else {
+			sectionStart = -1
+		}
+		restored = append(restored, line)
+	}
+	return []byte(strings.Join(restored, "\n"))
+}
+
+// detectIndent returns the indentation of the first indented line, so the rewritten config keeps its indentation.
+func detectIndent(content []byte) int {
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == line || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		return len(line) - len(trimmed)
+	}
+	return 2
+}
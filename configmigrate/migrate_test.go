@@ -0,0 +1,171 @@
+package configmigrate
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockStepVersionResolver map[string]string
+
+func (r mockStepVersionResolver) ResolveVersion(stepLibSource, stepID, versionConstraint string) (string, error) {
+	version, ok := r[stepLibSource+"::"+stepID+"@"+versionConstraint]
+	if !ok {
+		return "", errors.New("step not found")
+	}
+	return version, nil
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		opts        Options
+		want        string
+		wantChanges []string
+		wantErr     string
+	}{
+		{
+			name: "Legacy trigger items",
+			config: `format_version: "11"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+# Triggers
+trigger_map:
+# Deploy the main branch
+- pattern: main
+  workflow: deploy
+- pattern: "*"
+  is_pull_request_allowed: true
+  workflow: test # run the tests
+- tag: "*"
+  workflow: deploy
+
+workflows:
+  deploy: {}
+  test: {}
+`,
+			want: `format_version: "17"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+# Triggers
+trigger_map:
+  # Deploy the main branch
+  - push_branch: main
+    workflow: deploy
+  - push_branch: "*"
+    workflow: test # run the tests
+  - pull_request_source_branch: "*"
+    workflow: test
+  - tag: "*"
+    workflow: deploy
+
+workflows:
+  deploy: {}
+  test: {}
+`,
+			wantChanges: []string{
+				"format_version: 11 -> 17",
+				"trigger_map: pattern item #1 (main) converted to push_branch item",
+				"trigger_map: pattern item #2 (*) converted to push_branch and pull_request_source_branch items",
+			},
+		},
+		{
+			name: "Step versions are pinned",
+			config: `format_version: 1.4.0
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+workflows:
+    test:
+        steps:
+        - git-clone@8: {}
+        - path::./steps/local: {}
+        - script@1.2.0:
+            title: Test
+        - with:
+            container: ruby
+            steps:
+            - https://github.com/bitrise-io/bitrise-steplib.git::cache-pull: {}
+        - bundle::setup: {}
+step_bundles:
+    setup:
+        steps:
+        - activate-ssh-key@4.1: {}
+`,
+			opts: Options{StepVersionResolver: mockStepVersionResolver{
+				"https://github.com/bitrise-io/bitrise-steplib.git::git-clone@8":          "8.3.1",
+				"https://github.com/bitrise-io/bitrise-steplib.git::cache-pull@":          "2.7.2",
+				"https://github.com/bitrise-io/bitrise-steplib.git::activate-ssh-key@4.1": "4.1.1",
+			}},
+			want: `format_version: "17"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+workflows:
+    test:
+        steps:
+            - git-clone@8.3.1: {}
+            - path::./steps/local: {}
+            - script@1.2.0:
+                title: Test
+            - with:
+                container: ruby
+                steps:
+                    - https://github.com/bitrise-io/bitrise-steplib.git::cache-pull@2.7.2: {}
+            - bundle::setup: {}
+step_bundles:
+    setup:
+        steps:
+            - activate-ssh-key@4.1.1: {}
+`,
+			wantChanges: []string{
+				"format_version: 1.4.0 -> 17",
+				"workflows.test: git-clone@8 -> git-clone@8.3.1",
+				"workflows.test: https://github.com/bitrise-io/bitrise-steplib.git::cache-pull -> https://github.com/bitrise-io/bitrise-steplib.git::cache-pull@2.7.2",
+				"step_bundles.setup: activate-ssh-key@4.1 -> activate-ssh-key@4.1.1",
+			},
+		},
+		{
+			name: "Up to date config is not changed",
+			config: `format_version: "17"
+trigger_map:
+-   push_branch: main
+    workflow: test
+workflows:
+    test: {}
+`,
+			want: `format_version: "17"
+trigger_map:
+-   push_branch: main
+    workflow: test
+workflows:
+    test: {}
+`,
+		},
+		{
+			name: "Step version can't be resolved",
+			config: `format_version: "17"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+workflows:
+  test:
+    steps:
+    - script: {}
+`,
+			opts:    Options{StepVersionResolver: mockStepVersionResolver{}},
+			wantErr: "workflows.test: failed to resolve step (script) version: step not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Migrate([]byte(tt.config), tt.opts)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(result.Content), fmt.Sprintf("migrated config:\n%s", result.Content))
+			require.Equal(t, tt.wantChanges, result.Changes)
+		})
+	}
+}
@@ -15,8 +15,9 @@ const defaultDeployDir = "$BITRISE_APP_SLUG/$BITRISE_BUILD_SLUG/artifacts"
 const defaultTestDeployDir = "$BITRISE_APP_SLUG/$BITRISE_BUILD_SLUG/test_results"
 
 type AgentConfig struct {
-	BitriseDirs BitriseDirs `yaml:"bitrise_dirs"`
-	Hooks       AgentHooks  `yaml:"hooks"`
+	BitriseDirs BitriseDirs    `yaml:"bitrise_dirs"`
+	Hooks       AgentHooks     `yaml:"hooks"`
+	Analytics   AgentAnalytics `yaml:"analytics"`
 }
 
 type BitriseDirs struct {
@@ -61,6 +62,19 @@ type AgentHooks struct {
 	DoOnBuildEnd string `yaml:"do_on_build_end"`
 }
 
+// AgentAnalytics selects the backends receiving the analytics events, the BITRISE_ANALYTICS_* envs take precedence.
+type AgentAnalytics struct {
+	// Backends is the list of the backends: bitrise, file and webhook.
+	// Defaults to the configured local sinks, or to bitrise if none is configured.
+	Backends []string `yaml:"backends"`
+
+	// File is the path of the NDJSON file the events are appended to.
+	File string `yaml:"file"`
+
+	// WebhookURL is the URL the events are posted to.
+	WebhookURL string `yaml:"webhook_url"`
+}
+
 func GetAgentConfigPath() string {
 	return filepath.Join(GetBitriseHomeDirPath(), agentConfigFileName)
 }
@@ -149,6 +163,14 @@ func ReadAgentConfig(configFile string) (AgentConfig, error) {
 		config.Hooks.DoOnBuildEnd = doOnBuildEnd
 	}
 
+	if config.Analytics.File != "" {
+		analyticsFile, err := normalizePath(config.Analytics.File)
+		if err != nil {
+			return AgentConfig{}, fmt.Errorf("expand analytics file value: %s", err)
+		}
+		config.Analytics.File = analyticsFile
+	}
+
 	return config, nil
 }
 
@@ -38,6 +38,11 @@ func TestReadAgentConfig(t *testing.T) {
 					DoOnBuildStart:      filepath.Join(tempDir, "cleanup.sh"),
 					DoOnBuildEnd:        filepath.Join(tempDir, "cleanup.sh"),
 				},
+				AgentAnalytics{
+					Backends:   []string{"file", "webhook"},
+					File:       "/opt/bitrise/analytics/events.ndjson",
+					WebhookURL: "http://localhost:8080/events",
+				},
 			},
 			expectedErr: false,
 		},
@@ -52,6 +57,7 @@ func TestReadAgentConfig(t *testing.T) {
 					TestDeployDir:      "/opt/bitrise/ef7a9665e8b6408b/80b66786-d011-430f-9c68-00e9416a7325/test_results",
 				},
 				AgentHooks{},
+				AgentAnalytics{},
 			},
 			expectedErr: false,
 		},
@@ -56,11 +56,20 @@ const (
 	IsSecretEnvsFilteringKey = "BITRISE_SECRET_ENVS_FILTERING"
 	// NoOutputTimeoutEnvKey ...
 	NoOutputTimeoutEnvKey = "BITRISE_NO_OUTPUT_TIMEOUT"
+	// BuildTimeoutEnvKey ...
+	BuildTimeoutEnvKey = "BITRISE_BUILD_TIMEOUT"
+	// CancelGracePeriodEnvKey ...
+	CancelGracePeriodEnvKey = "BITRISE_CANCEL_GRACE_PERIOD"
+	// StepLogDirEnvKey ...
+	StepLogDirEnvKey = "BITRISE_STEP_LOG_DIR"
+	// OTLPEndpointEnvKey ...
+	OTLPEndpointEnvKey = "OTEL_EXPORTER_OTLP_ENDPOINT"
 	// IsSteplibOfflineModeEnvKey when set to true:
 	// - StepLib update will be disabled when using non-exact step version (latest minor or major).
 	// - When a step or step version is not found in the cache, will not be downloaded. Instead will log
 	//  a error message (including what other Step versions are available).
 	// - Analytics will be disabled.
+	// - Remote config modules are read from the include cache when fetching their repository fails.
 	IsSteplibOfflineModeEnvKey = "BITRISE_OFFLINE_MODE"
 
 	// --- Debug Options
@@ -36,6 +36,17 @@ func GetBitriseHomeDirPath() string {
 	return filepath.Join(pathutil.UserHomeDir(), ".bitrise")
 }
 
+// GetBitriseRunStatesDirPath returns the dir of the persisted workflow run states, used to resume failed builds.
+// Unlike BitriseWorkDirPath, it is kept between the CLI invocations.
+func GetBitriseRunStatesDirPath() string {
+	return filepath.Join(GetBitriseHomeDirPath(), "run_states")
+}
+
+// GetConfigIncludeCacheDirPath returns the dir of the cached remote config modules, shared by the CLI invocations.
+func GetConfigIncludeCacheDirPath() string {
+	return filepath.Join(GetBitriseHomeDirPath(), "include_cache")
+}
+
 func getBitriseConfigFilePath() string {
 	return filepath.Join(GetBitriseHomeDirPath(), bitriseConfigFileName)
 }
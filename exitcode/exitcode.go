@@ -5,4 +5,5 @@ const (
 	CLIFailed                     = 1
 	CLIAbortedWithCustomTimeout   = 91
 	CLIAbortedWithNoOutputTimeout = 92
+	CLIAbortedWithRunTimeout      = 93
 )
@@ -0,0 +1,204 @@
+// Package gitinfo derives the trigger params from the state of a local git repository.
+package gitinfo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// Info is the state of the repository relevant for the trigger map.
+type Info struct {
+	// Branch is the checked out branch, empty if HEAD is detached.
+	Branch string
+	// Tag is the tag pointing to HEAD.
+	Tag string
+	// NearestTag is the most recent tag reachable from HEAD.
+	NearestTag    string
+	CommitMessage string
+	ChangedFiles  []string
+}
+
+// Read reads the repository containing dir.
+// The changed files are the diff of HEAD against its merge base with baseRef,
+// or against the parent of HEAD if baseRef is empty.
+func Read(dir, baseRef string) (Info, error) {
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to open git repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	headCommit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to read HEAD commit: %w", err)
+	}
+
+	info := Info{CommitMessage: strings.TrimRight(headCommit.Message, "\n")}
+	if head.Name().IsBranch() {
+		info.Branch = head.Name().Short()
+	}
+
+	tagsByCommit, err := tagsByCommit(repo)
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to read tags: %w", err)
+	}
+	if tags := tagsByCommit[headCommit.Hash]; len(tags) > 0 {
+		info.Tag = tags[0]
+	}
+	info.NearestTag, err = nearestTag(repo, headCommit, tagsByCommit)
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to find nearest tag: %w", err)
+	}
+
+	baseCommit, err := diffBase(repo, headCommit, baseRef)
+	if err != nil {
+		return Info{}, err
+	}
+	info.ChangedFiles, err = changedFiles(baseCommit, headCommit)
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to diff HEAD: %w", err)
+	}
+
+	return info, nil
+}
+
+// TriggerParams returns the params of a push of the checked out branch,
+// or the params of a tag push if HEAD is detached at a tag.
+func (i Info) TriggerParams() (models.TriggerParams, error) {
+	params := models.TriggerParams{
+		PushBranch:    i.Branch,
+		CommitMessage: i.CommitMessage,
+		ChangedFiles:  i.ChangedFiles,
+	}
+	if params.PushBranch == "" {
+		if i.Tag == "" {
+			return models.TriggerParams{}, errors.New("HEAD is detached and not tagged")
+		}
+		params.Tag = i.Tag
+	}
+	return params, nil
+}
+
+func tagsByCommit(repo *git.Repository) (map[plumbing.Hash][]string, error) {
+	iter, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[plumbing.Hash][]string{}
+	if err := iter.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+		// Annotated tags point to a tag object instead of the commit
+		if tag, err := repo.TagObject(hash); err == nil {
+			commit, err := tag.Commit()
+			if err != nil {
+				return nil
+			}
+			hash = commit.Hash
+		} else if !errors.Is(err, plumbing.ErrObjectNotFound) {
+			return err
+		}
+		tags[hash] = append(tags[hash], ref.Name().Short())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, names := range tags {
+		sort.Strings(names)
+	}
+	return tags, nil
+}
+
+func nearestTag(repo *git.Repository, head *object.Commit, tagsByCommit map[plumbing.Hash][]string) (string, error) {
+	if len(tagsByCommit) == 0 {
+		return "", nil
+	}
+
+	iter, err := repo.Log(&git.LogOptions{From: head.Hash, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		return "", err
+	}
+
+	var nearest string
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if tags := tagsByCommit[commit.Hash]; len(tags) > 0 {
+			nearest = tags[0]
+			return storer.ErrStop
+		}
+		return nil
+	})
+	return nearest, err
+}
+
+func diffBase(repo *git.Repository, head *object.Commit, baseRef string) (*object.Commit, error) {
+	if baseRef == "" {
+		if head.NumParents() == 0 {
+			return nil, nil
+		}
+		parent, err := head.Parent(0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parent of HEAD: %w", err)
+		}
+		return parent, nil
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(baseRef))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve base ref (%s): %w", baseRef, err)
+	}
+	base, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base ref (%s) commit: %w", baseRef, err)
+	}
+
+	mergeBases, err := head.MergeBase(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find merge base of HEAD and %s: %w", baseRef, err)
+	}
+	if len(mergeBases) == 0 {
+		return nil, fmt.Errorf("HEAD and %s have no common ancestor", baseRef)
+	}
+	return mergeBases[0], nil
+}
+
+// changedFiles returns the files changed between the commits, base is nil for the root commit.
+func changedFiles(base, head *object.Commit) ([]string, error) {
+	var baseTree *object.Tree
+	if base != nil {
+		var err error
+		if baseTree, err = base.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, change := range changes {
+		name := change.To.Name
+		if name == "" {
+			name = change.From.Name
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
@@ -0,0 +1,141 @@
+package gitinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepo struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+	when time.Time
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	return &testRepo{t: t, dir: dir, repo: repo, when: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (r *testRepo) signature() *object.Signature {
+	r.when = r.when.Add(time.Minute)
+	return &object.Signature{Name: "Bitrise", Email: "bot@bitrise.io", When: r.when}
+}
+
+func (r *testRepo) commit(message string, files ...string) plumbing.Hash {
+	worktree, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+
+	for _, file := range files {
+		pth := filepath.Join(r.dir, file)
+		require.NoError(r.t, os.MkdirAll(filepath.Dir(pth), 0755))
+		require.NoError(r.t, os.WriteFile(pth, []byte(message), 0644))
+		_, err := worktree.Add(file)
+		require.NoError(r.t, err)
+	}
+
+	hash, err := worktree.Commit(message, &git.CommitOptions{Author: r.signature()})
+	require.NoError(r.t, err)
+	return hash
+}
+
+func (r *testRepo) checkout(opts *git.CheckoutOptions) {
+	worktree, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+	require.NoError(r.t, worktree.Checkout(opts))
+}
+
+func TestRead(t *testing.T) {
+	repo := newTestRepo(t)
+	repo.commit("Initial commit\n", "README.md", "ios/Podfile")
+	tagged := repo.commit("Release 1.0.0\n", "version.txt")
+	_, err := repo.repo.CreateTag("1.0.0", tagged, &git.CreateTagOptions{Tagger: repo.signature(), Message: "1.0.0"})
+	require.NoError(t, err)
+
+	repo.checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature/login"), Create: true})
+	repo.commit("Add login screen\n", "ios/Login.swift")
+	repo.commit("Fix login [skip tests]\n", "ios/Login.swift", "android/Login.kt")
+
+	t.Run("Branch against its parent", func(t *testing.T) {
+		info, err := Read(filepath.Join(repo.dir, "ios"), "")
+		require.NoError(t, err)
+		require.Equal(t, Info{
+			Branch:        "feature/login",
+			NearestTag:    "1.0.0",
+			CommitMessage: "Fix login [skip tests]",
+			ChangedFiles:  []string{"android/Login.kt", "ios/Login.swift"},
+		}, info)
+
+		params, err := info.TriggerParams()
+		require.NoError(t, err)
+		require.Equal(t, models.TriggerParams{
+			PushBranch:    "feature/login",
+			CommitMessage: "Fix login [skip tests]",
+			ChangedFiles:  []string{"android/Login.kt", "ios/Login.swift"},
+		}, params)
+	})
+
+	t.Run("Branch against base ref", func(t *testing.T) {
+		repo.checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("master")})
+		repo.commit("Update readme\n", "README.md")
+		repo.checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature/login")})
+
+		info, err := Read(repo.dir, "master")
+		require.NoError(t, err)
+		require.Equal(t, []string{"android/Login.kt", "ios/Login.swift"}, info.ChangedFiles)
+	})
+
+	t.Run("Detached HEAD at a tag", func(t *testing.T) {
+		repo.checkout(&git.CheckoutOptions{Hash: tagged})
+
+		info, err := Read(repo.dir, "")
+		require.NoError(t, err)
+		require.Equal(t, Info{
+			Tag:           "1.0.0",
+			NearestTag:    "1.0.0",
+			CommitMessage: "Release 1.0.0",
+			ChangedFiles:  []string{"version.txt"},
+		}, info)
+
+		params, err := info.TriggerParams()
+		require.NoError(t, err)
+		require.Equal(t, models.TriggerParams{
+			Tag:           "1.0.0",
+			CommitMessage: "Release 1.0.0",
+			ChangedFiles:  []string{"version.txt"},
+		}, params)
+	})
+}
+
+func TestRead_RootCommit(t *testing.T) {
+	repo := newTestRepo(t)
+	root := repo.commit("Initial commit\n", "README.md", "bitrise.yml")
+	repo.checkout(&git.CheckoutOptions{Hash: root})
+
+	info, err := Read(repo.dir, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"README.md", "bitrise.yml"}, info.ChangedFiles)
+
+	_, err = info.TriggerParams()
+	require.EqualError(t, err, "HEAD is detached and not tagged")
+}
+
+func TestRead_Errors(t *testing.T) {
+	_, err := Read(t.TempDir(), "")
+	require.EqualError(t, err, "failed to open git repository: repository does not exist")
+
+	repo := newTestRepo(t)
+	repo.commit("Initial commit\n", "README.md")
+	_, err = Read(repo.dir, "origin/main")
+	require.EqualError(t, err, "failed to resolve base ref (origin/main): reference not found")
+}
@@ -99,6 +99,9 @@ func generateStepFinishedFooterLines(params StepFinishedParams) []string {
 	if params.StatusReason != "" {
 		lines = append(lines, colorstring.Blue(params.StatusReason))
 	}
+	for idx, attempt := range params.Attempts {
+		lines = append(lines, colorstring.Yellow(fmt.Sprintf("Attempt %d of %d: %s (exit code: %d)", idx+1, len(params.Attempts), attempt.Status, attempt.ExitCode)))
+	}
 	lines = append(lines, sectionSeparator)
 	lines = append(lines, mainSeparator)
 	status := models.NewStepRunStatus(params.Status)
@@ -149,6 +152,74 @@ func getSummaryFooterRow(status models.StepRunStatus, title, reason string, dura
 	return fmt.Sprintf("|%s|%s|%s|", icon, footerTitle, executionTime)
 }
 
+// This is the main entry point to generate the workflow finished event console log lines (pipeline runs only).
+func generateWorkflowFinishedLines(params WorkflowFinishedParams) []string {
+	_, level := transformWorkflowStatusToIconAndLevel(params.Status)
+	executionTime := strings.TrimSpace(getFooterExecutionTime(params.RunTime))
+
+	line := fmt.Sprintf("Workflow %s finished with status: %s (%s)", params.WorkflowID, corelog.AddColor(level, params.Status), executionTime)
+	lines := []string{"", line}
+	if params.StatusReason != "" {
+		lines = append(lines, corelog.AddColor(corelog.InfoLevel, params.StatusReason))
+	}
+
+	return lines
+}
+
+// This is the main entry point to generate the stage finished event console log lines (pipeline runs only).
+func generateStageFinishedLines(params StageFinishedParams) []string {
+	separator := fmt.Sprintf("+%s+", strings.Repeat("-", stepRunSummaryBoxWidthInChars-2))
+	rowSeparator := fmt.Sprintf("+%s+%s+%s+", strings.Repeat("-", footerIconBoxWidth), strings.Repeat("-", footerTitleBoxWidth), strings.Repeat("-", footerExecutionTimeBoxWidth))
+
+	title := fmt.Sprintf("pipeline: %s", params.PipelineID)
+	if params.StageID != "" {
+		title = fmt.Sprintf("%s, stage: %s", title, params.StageID)
+	}
+	title = fmt.Sprintf("%s (%s)", title, params.Status)
+
+	lines := []string{"", separator, getHeaderLine(title), rowSeparator}
+	for _, workflow := range params.Workflows {
+		icon, level := transformWorkflowStatusToIconAndLevel(workflow.Status)
+		reason := ""
+		if workflow.Status != models.WorkflowRunStatusSuccess.String() {
+			reason = workflow.Status
+		}
+		workflowTitle := getFooterTitle(level, workflow.WorkflowID, reason, false, footerTitleBoxWidth)
+		lines = append(lines, fmt.Sprintf("|%s|%s|%s|", icon, workflowTitle, getFooterExecutionTime(workflow.RunTime)))
+		lines = append(lines, rowSeparator)
+	}
+	if params.StatusReason != "" {
+		lines = append(lines, corelog.AddColor(corelog.InfoLevel, params.StatusReason))
+	}
+
+	return lines
+}
+
+func transformWorkflowStatusToIconAndLevel(status string) (string, corelog.Level) {
+	var icon string
+	var level corelog.Level
+
+	switch status {
+	case models.WorkflowRunStatusSuccess.String():
+		icon = "✓"
+		level = corelog.DoneLevel
+	case models.WorkflowRunStatusFailed.String():
+		icon = "x"
+		level = corelog.ErrorLevel
+	case models.WorkflowRunStatusAborted.String():
+		icon = "/"
+		level = corelog.ErrorLevel
+	case models.WorkflowRunStatusSkipped.String():
+		icon = "-"
+		level = corelog.InfoLevel
+	default:
+		icon = " "
+		level = corelog.NormalLevel
+	}
+
+	return fmt.Sprintf(" %s ", corelog.AddColor(level, icon)), level
+}
+
 func transformStatusToIconAndLevel(status models.StepRunStatus) (string, corelog.Level) {
 	var icon string
 	var level corelog.Level
@@ -160,7 +231,7 @@ func transformStatusToIconAndLevel(status models.StepRunStatus) (string, corelog
 	case models.StepRunStatusCodeFailed, models.StepRunStatusCodePreparationFailed:
 		icon = "x"
 		level = corelog.ErrorLevel
-	case models.StepRunStatusAbortedWithCustomTimeout, models.StepRunStatusAbortedWithNoOutputTimeout:
+	case models.StepRunStatusAbortedWithCustomTimeout, models.StepRunStatusAbortedWithNoOutputTimeout, models.StepRunStatusAbortedWithRunTimeout, models.StepRunStatusAborted:
 		icon = "/"
 		level = corelog.ErrorLevel
 	case models.StepRunStatusCodeFailedSkippable:
@@ -201,6 +201,32 @@ func (m *defaultLogger) PrintStepFinishedEvent(params StepFinishedParams) {
 	}
 }
 
+func (m *defaultLogger) PrintWorkflowFinishedEvent(params WorkflowFinishedParams) {
+	if m.opts.LoggerType == JSONLogger {
+		m.logger.LogEvent(params, corelog.EventLogFields{
+			Timestamp: m.opts.TimeProvider().Format(rfc3339MicroTimeLayout),
+			EventType: "workflow_finished",
+		})
+	} else {
+		for _, line := range generateWorkflowFinishedLines(params) {
+			m.Print(line)
+		}
+	}
+}
+
+func (m *defaultLogger) PrintStageFinishedEvent(params StageFinishedParams) {
+	if m.opts.LoggerType == JSONLogger {
+		m.logger.LogEvent(params, corelog.EventLogFields{
+			Timestamp: m.opts.TimeProvider().Format(rfc3339MicroTimeLayout),
+			EventType: "stage_finished",
+		})
+	} else {
+		for _, line := range generateStageFinishedLines(params) {
+			m.Print(line)
+		}
+	}
+}
+
 func (m *defaultLogger) logMessage(message string, level corelog.Level) {
 	fields := m.createMessageFields(level)
 	m.logger.LogMessage(message, corelog.MessageLogFields(fields))
@@ -110,3 +110,11 @@ func PrintStepStartedEvent(params StepStartedParams) {
 func PrintStepFinishedEvent(params StepFinishedParams) {
 	getGlobalLogger().PrintStepFinishedEvent(params)
 }
+
+func PrintWorkflowFinishedEvent(params WorkflowFinishedParams) {
+	getGlobalLogger().PrintWorkflowFinishedEvent(params)
+}
+
+func PrintStageFinishedEvent(params StageFinishedParams) {
+	getGlobalLogger().PrintStageFinishedEvent(params)
+}
@@ -25,4 +25,6 @@ type Logger interface {
 	PrintBitriseStartedEvent(plan models.WorkflowRunPlan)
 	PrintStepStartedEvent(params StepStartedParams)
 	PrintStepFinishedEvent(params StepFinishedParams)
+	PrintWorkflowFinishedEvent(params WorkflowFinishedParams)
+	PrintStageFinishedEvent(params StageFinishedParams)
 }
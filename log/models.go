@@ -42,4 +42,34 @@ type StepFinishedParams struct {
 	Update      *StepUpdate      `json:"update_available,omitempty"`
 	Deprecation *StepDeprecation `json:"deprecation,omitempty"`
 	LastStep    bool             `json:"last_step"`
+	// Attempts are only set if the step was retried.
+	Attempts []StepAttempt `json:"attempts,omitempty"`
+	LogPath  string        `json:"log_path,omitempty"`
+}
+
+// StepAttempt ...
+type StepAttempt struct {
+	Status   string `json:"status"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+	RunTime  int64  `json:"run_time_in_ms"`
+}
+
+// WorkflowFinishedParams ...
+type WorkflowFinishedParams struct {
+	WorkflowID   string `json:"workflow_id"`
+	StageID      string `json:"stage_id,omitempty"`
+	Status       string `json:"status"`
+	StatusReason string `json:"status_reason,omitempty"`
+	RunTime      int64  `json:"run_time_in_ms"`
+}
+
+// StageFinishedParams ...
+type StageFinishedParams struct {
+	PipelineID   string                   `json:"pipeline_id"`
+	StageID      string                   `json:"stage_id,omitempty"`
+	Status       string                   `json:"status"`
+	StatusReason string                   `json:"status_reason,omitempty"`
+	RunTime      int64                    `json:"run_time_in_ms"`
+	Workflows    []WorkflowFinishedParams `json:"workflows"`
 }
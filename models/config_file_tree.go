@@ -2,8 +2,12 @@ package models
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 
+	"github.com/bitrise-io/go-utils/sliceutil"
 	"gopkg.in/yaml.v2"
+	yamlV3 "gopkg.in/yaml.v3"
 )
 
 type ConfigFileTreeModel struct {
@@ -12,43 +16,116 @@ type ConfigFileTreeModel struct {
 	Includes []ConfigFileTreeModel `json:"includes,omitempty" yaml:"includes,omitempty"`
 }
 
+// ConfigOrigin is the config file (its path in the config file tree) and the line, where a merged value was set.
+type ConfigOrigin struct {
+	File string `json:"file" yaml:"file"`
+	Line int    `json:"line" yaml:"line"`
+	// Overrides are the origins of the earlier values overridden by this value, the latest first.
+	Overrides []ConfigOrigin `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+}
+
+func (o ConfigOrigin) String() string {
+	if o.Line == 0 {
+		return o.File
+	}
+	return fmt.Sprintf("%s:%d", o.File, o.Line)
+}
+
+// Provenance maps the paths of the merged config's values (like workflows.primary.steps[0].script.inputs[0])
+// to their origin.
+type Provenance map[string]ConfigOrigin
+
+// Origin returns the origin of the value at path, or of its closest parent with a known origin.
+func (p Provenance) Origin(path string) (ConfigOrigin, bool) {
+	for path != "" {
+		if origin, ok := p[path]; ok {
+			return origin, true
+		}
+		path = parentPath(path)
+	}
+	return ConfigOrigin{}, false
+}
+
 func (configTree *ConfigFileTreeModel) Merge() (string, error) {
-	result, err := merge(configTree)
+	mergedYml, _, err := configTree.merge(false)
+	return mergedYml, err
+}
+
+// MergeWithProvenance merges the config file tree and returns the origin of the merged values.
+func (configTree *ConfigFileTreeModel) MergeWithProvenance() (string, Provenance, error) {
+	return configTree.merge(true)
+}
+
+func (configTree *ConfigFileTreeModel) merge(trackProvenance bool) (string, Provenance, error) {
+	m := treeMerger{}
+	if trackProvenance {
+		m.provenance = Provenance{}
+	}
+
+	result, err := m.merge(configTree)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	mergedYml, err := yaml.Marshal(result)
 	if err != nil {
-		return "", fmt.Errorf("failed to create YML result, error: %s", err)
+		return "", nil, fmt.Errorf("failed to create YML result, error: %s", err)
 	}
 
-	return string(mergedYml), nil
+	return string(mergedYml), m.provenance, nil
 }
 
 type yamlMap = map[any]any
 
-func merge(ymlTree *ConfigFileTreeModel) (yamlMap, error) {
+// Merge directives are YML tags of the lists in a config file, which control how the list is merged with
+// the same list of the earlier merged config files.
+const (
+	// MergeDirectiveAppend appends the items to the existing list, this is the default.
+	MergeDirectiveAppend = "!append"
+	// MergeDirectivePrepend inserts the items before the items of the existing list.
+	MergeDirectivePrepend = "!prepend"
+	// MergeDirectiveReplace replaces the existing list.
+	MergeDirectiveReplace = "!replace"
+	// MergeDirectiveMerge merges the items with the matching items of the existing list and appends the rest.
+	// Steps match by step ID (ignoring the version) and by title if the item sets it, env vars match by key,
+	// trigger map items match by their conditions and simple values by value.
+	MergeDirectiveMerge = "!merge"
+)
+
+var mergeDirectives = []string{MergeDirectiveAppend, MergeDirectivePrepend, MergeDirectiveReplace, MergeDirectiveMerge}
+
+// treeMerger merges the config files and records the origin of the merged values, if provenance is not nil.
+type treeMerger struct {
+	provenance Provenance
+	// file, lines and directives are the path, the value lines and the list merge directives (by path)
+	// of the config file being merged
+	file       string
+	lines      map[string]int
+	directives map[string]string
+}
+
+func (m *treeMerger) merge(ymlTree *ConfigFileTreeModel) (yamlMap, error) {
 	// Initial state is an empty map (YAML root)
 	initial := make(yamlMap)
 
-	result, err := mergeTree(initial, ymlTree)
+	result, err := m.mergeTree(initial, ymlTree)
 	if err != nil {
 		return nil, fmt.Errorf("failed to merge YML files, error: %s", err)
 	}
 
 	// Remove include list from result
 	delete(result, "include")
+	m.removeChildren("include")
 
 	return result, nil
 }
 
-func mergeTree(existingValue yamlMap, treeToMerge *ConfigFileTreeModel) (yamlMap, error) {
+func (m *treeMerger) mergeTree(existingValue yamlMap, treeToMerge *ConfigFileTreeModel) (yamlMap, error) {
 	var err error
 
 	// DFS: first the includes in the specified order, then the including file
 	for _, includedTree := range treeToMerge.Includes {
-		existingValue, err = mergeTree(existingValue, &includedTree)
+		existingValue, err = m.mergeTree(existingValue, &includedTree)
 		if err != nil {
 			return nil, fmt.Errorf("failed to merge YML file %s, error: %s", includedTree.Path, err)
 		}
@@ -66,10 +143,17 @@ func mergeTree(existingValue yamlMap, treeToMerge *ConfigFileTreeModel) (yamlMap
 		config = make(yamlMap)
 	}
 
-	return mergeMap(existingValue, config), nil
+	m.file = treeToMerge.Path
+	m.lines, m.directives, err = indexNodes(treeToMerge.Contents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse YML file %s, error: %s", treeToMerge.Path, err)
+	}
+
+	return m.mergeMap(existingValue, config, "", ""), nil
 }
 
-func mergeValue(existingValue any, valueToMerge any) any {
+// mergeValue merges the value at path of the merged config with the value at localPath of the file being merged.
+func (m *treeMerger) mergeValue(existingValue any, valueToMerge any, path, localPath string) any {
 
 	switch valueToMerge.(type) {
 	case yamlMap:
@@ -77,37 +161,340 @@ func mergeValue(existingValue any, valueToMerge any) any {
 		if !ok {
 			// Existing value is not a map, replace with new value
 			existingMap = make(yamlMap)
+			m.override(path, localPath)
 		}
-		return mergeMap(existingMap, valueToMerge.(yamlMap))
+		return m.mergeMap(existingMap, valueToMerge.(yamlMap), path, localPath)
 	case []any:
 		existingSlice, ok := existingValue.([]any)
 		if !ok {
 			// Existing value is not a slice, replace with new value
 			existingSlice = nil
+			m.override(path, localPath)
 		}
-		return mergeSlice(existingSlice, valueToMerge.([]any))
+		return m.mergeSlice(existingSlice, valueToMerge.([]any), path, localPath)
 	default:
 		// Simple types
+		m.override(path, localPath)
 		return valueToMerge
 	}
 }
 
-func mergeMap(existingMap yamlMap, mapToMerge yamlMap) yamlMap {
+func (m *treeMerger) mergeMap(existingMap yamlMap, mapToMerge yamlMap, path, localPath string) yamlMap {
 	for key, valueToMerge := range mapToMerge {
+		keyPath, localKeyPath := joinPath(path, key), joinPath(localPath, key)
+
 		existingValue, exists := existingMap[key]
 		if exists {
 			// Key exists in result and merged maps
-			existingMap[key] = mergeValue(existingValue, valueToMerge)
+			existingMap[key] = m.mergeValue(existingValue, valueToMerge, keyPath, localKeyPath)
 		} else {
 			// Key doesn't exist in result yet
 			existingMap[key] = valueToMerge
+			m.record(valueToMerge, keyPath, localKeyPath)
 		}
 	}
 
 	return existingMap
 }
 
-func mergeSlice(existingArray []any, arrayToAppend []any) []any {
-	existingArray = append(existingArray, arrayToAppend...)
+func (m *treeMerger) mergeSlice(existingArray []any, arrayToMerge []any, path, localPath string) []any {
+	switch m.directives[localPath] {
+	case MergeDirectivePrepend:
+		m.moveItems(path, len(existingArray), len(arrayToMerge))
+		for idx, item := range arrayToMerge {
+			m.record(item, indexPath(path, idx), indexPath(localPath, idx))
+		}
+
+		return append(append([]any{}, arrayToMerge...), existingArray...)
+	case MergeDirectiveReplace:
+		if existingArray != nil {
+			m.override(path, localPath)
+		}
+		for idx, item := range arrayToMerge {
+			m.record(item, indexPath(path, idx), indexPath(localPath, idx))
+		}
+
+		return arrayToMerge
+	case MergeDirectiveMerge:
+		return m.mergeSliceItems(existingArray, arrayToMerge, path, localPath)
+	default:
+		for idx, item := range arrayToMerge {
+			m.record(item, indexPath(path, len(existingArray)+idx), indexPath(localPath, idx))
+		}
+
+		return append(existingArray, arrayToMerge...)
+	}
+}
+
+// mergeSliceItems merges the items with the first matching existing item, the items without a match are appended.
+func (m *treeMerger) mergeSliceItems(existingArray []any, arrayToMerge []any, path, localPath string) []any {
+	merged := map[int]bool{}
+	for idx, item := range arrayToMerge {
+		itemLocalPath := indexPath(localPath, idx)
+
+		existingIdx := -1
+		for i, existingItem := range existingArray {
+			if !merged[i] && itemsMatch(existingItem, item) {
+				existingIdx = i
+				break
+			}
+		}
+		if existingIdx < 0 {
+			m.record(item, indexPath(path, len(existingArray)), itemLocalPath)
+			existingArray = append(existingArray, item)
+			continue
+		}
+
+		merged[existingIdx] = true
+		itemPath := indexPath(path, existingIdx)
+		existingItem := existingArray[existingIdx]
+
+		existingMap, isExistingMap := existingItem.(yamlMap)
+		itemMap, isMap := item.(yamlMap)
+		if !isExistingMap || !isMap {
+			m.override(itemPath, itemLocalPath)
+			existingArray[existingIdx] = item
+			continue
+		}
+
+		existingKey, isExistingKeyed := itemKey(existingMap)
+		key, isKeyed := itemKey(itemMap)
+		if isExistingKeyed && isKeyed && existingKey != key {
+			// The key of a step changes with its version
+			m.movePath(joinPath(itemPath, existingKey), joinPath(itemPath, key))
+			m.setOrigin(joinPath(itemPath, key), joinPath(itemLocalPath, key))
+			existingMap[key] = existingMap[existingKey]
+			delete(existingMap, existingKey)
+		}
+		existingArray[existingIdx] = m.mergeMap(existingMap, itemMap, itemPath, itemLocalPath)
+	}
+
 	return existingArray
 }
+
+// itemsMatch tells if an item of a list merged with the MergeDirectiveMerge directive matches an existing item.
+func itemsMatch(existingItem, item any) bool {
+	existingMap, isExistingMap := existingItem.(yamlMap)
+	itemMap, isMap := item.(yamlMap)
+	if !isExistingMap || !isMap {
+		return isExistingMap == isMap && reflect.DeepEqual(existingItem, item)
+	}
+
+	existingKey, isExistingKeyed := itemKey(existingMap)
+	key, isKeyed := itemKey(itemMap)
+	if isExistingKeyed || isKeyed {
+		if !isExistingKeyed || !isKeyed || stepIDWithoutVersion(existingKey) != stepIDWithoutVersion(key) {
+			return false
+		}
+
+		// Steps with a title match only the step with the same title
+		title, hasTitle := itemValueMap(itemMap, key)["title"]
+		return !hasTitle || reflect.DeepEqual(itemValueMap(existingMap, existingKey)["title"], title)
+	}
+
+	// Trigger map items match by their conditions
+	return reflect.DeepEqual(triggerConditions(existingMap), triggerConditions(itemMap))
+}
+
+// itemKey returns the key of a single key list item, like a step, a step bundle or an env var (which may have options).
+func itemKey(item yamlMap) (string, bool) {
+	var keys []string
+	for key := range item {
+		if key != "opts" {
+			keys = append(keys, fmt.Sprint(key))
+		}
+	}
+	if len(keys) != 1 {
+		return "", false
+	}
+	return keys[0], true
+}
+
+func itemValueMap(item yamlMap, key string) yamlMap {
+	value, _ := item[key].(yamlMap)
+	return value
+}
+
+func stepIDWithoutVersion(id string) string {
+	if idx := strings.LastIndex(id, "@"); idx > 0 && !strings.Contains(id[idx:], "/") {
+		return id[:idx]
+	}
+	return id
+}
+
+func triggerConditions(item yamlMap) yamlMap {
+	conditions := yamlMap{}
+	for key, value := range item {
+		switch key {
+		case "pipeline", "workflow", "enabled":
+		default:
+			conditions[key] = value
+		}
+	}
+	return conditions
+}
+
+// record sets the origin of a new value and its children.
+func (m *treeMerger) record(value any, path, localPath string) {
+	if m.provenance == nil {
+		return
+	}
+
+	m.provenance[path] = ConfigOrigin{File: m.file, Line: m.lines[localPath]}
+
+	switch value := value.(type) {
+	case yamlMap:
+		for key, child := range value {
+			m.record(child, joinPath(path, key), joinPath(localPath, key))
+		}
+	case []any:
+		for idx, item := range value {
+			m.record(item, indexPath(path, idx), indexPath(localPath, idx))
+		}
+	}
+}
+
+// override sets the origin of a value replacing the existing one, the children of the existing value are dropped.
+func (m *treeMerger) override(path, localPath string) {
+	if m.provenance == nil {
+		return
+	}
+
+	origin := m.overridingOrigin(path, localPath)
+	m.removeChildren(path)
+	m.provenance[path] = origin
+}
+
+// setOrigin sets the origin of a value overriding the existing origin, the children of the value are kept.
+func (m *treeMerger) setOrigin(path, localPath string) {
+	if m.provenance == nil {
+		return
+	}
+
+	m.provenance[path] = m.overridingOrigin(path, localPath)
+}
+
+func (m *treeMerger) overridingOrigin(path, localPath string) ConfigOrigin {
+	origin := ConfigOrigin{File: m.file, Line: m.lines[localPath]}
+	if existing, ok := m.provenance[path]; ok {
+		origin.Overrides = append([]ConfigOrigin{{File: existing.File, Line: existing.Line}}, existing.Overrides...)
+	}
+	return origin
+}
+
+// movePath moves the origin of a value and its children to a new path.
+func (m *treeMerger) movePath(from, to string) {
+	moved := Provenance{}
+	for childPath, origin := range m.provenance {
+		if childPath == from || strings.HasPrefix(childPath, from+".") || strings.HasPrefix(childPath, from+"[") {
+			moved[to+strings.TrimPrefix(childPath, from)] = origin
+			delete(m.provenance, childPath)
+		}
+	}
+	for childPath, origin := range moved {
+		m.provenance[childPath] = origin
+	}
+}
+
+// moveItems shifts the origins of the first count items of the list at path by offset.
+func (m *treeMerger) moveItems(path string, count, offset int) {
+	if m.provenance == nil {
+		return
+	}
+
+	moved := Provenance{}
+	for idx := 0; idx < count; idx++ {
+		from := indexPath(path, idx)
+		for childPath, origin := range m.provenance {
+			if childPath == from || strings.HasPrefix(childPath, from+".") || strings.HasPrefix(childPath, from+"[") {
+				moved[indexPath(path, idx+offset)+strings.TrimPrefix(childPath, from)] = origin
+				delete(m.provenance, childPath)
+			}
+		}
+	}
+	for childPath, origin := range moved {
+		m.provenance[childPath] = origin
+	}
+}
+
+func (m *treeMerger) removeChildren(path string) {
+	for childPath := range m.provenance {
+		if childPath == path || strings.HasPrefix(childPath, path+".") || strings.HasPrefix(childPath, path+"[") {
+			delete(m.provenance, childPath)
+		}
+	}
+}
+
+// indexNodes returns the lines of the values in the YML by their path (the line of a map value is the line of its key)
+// and the merge directives of the lists.
+func indexNodes(contents string) (map[string]int, map[string]string, error) {
+	lines := map[string]int{}
+	directives := map[string]string{}
+
+	var document yamlV3.Node
+	if err := yamlV3.Unmarshal([]byte(contents), &document); err != nil || len(document.Content) == 0 {
+		return lines, directives, nil
+	}
+
+	var walk func(node *yamlV3.Node, path string) error
+	walk = func(node *yamlV3.Node, path string) error {
+		for node.Kind == yamlV3.AliasNode {
+			node = node.Alias
+		}
+
+		if directive := node.Tag; directive != "" && !strings.HasPrefix(directive, "!!") {
+			if !sliceutil.IsStringInSlice(directive, mergeDirectives) {
+				return fmt.Errorf("unknown merge directive (%s) at line %d, valid directives are: %s", directive, node.Line, strings.Join(mergeDirectives, ", "))
+			}
+			if node.Kind != yamlV3.SequenceNode {
+				return fmt.Errorf("merge directive (%s) at line %d is only supported for lists", directive, node.Line)
+			}
+			directives[path] = directive
+		}
+
+		switch node.Kind {
+		case yamlV3.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				keyPath := joinPath(path, node.Content[i].Value)
+				lines[keyPath] = node.Content[i].Line
+				if err := walk(node.Content[i+1], keyPath); err != nil {
+					return err
+				}
+			}
+		case yamlV3.SequenceNode:
+			for idx, item := range node.Content {
+				itemPath := indexPath(path, idx)
+				lines[itemPath] = item.Line
+				if err := walk(item, itemPath); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(document.Content[0], ""); err != nil {
+		return nil, nil, err
+	}
+
+	return lines, directives, nil
+}
+
+func joinPath(path string, key any) string {
+	if path == "" {
+		return fmt.Sprint(key)
+	}
+	return fmt.Sprintf("%s.%v", path, key)
+}
+
+func indexPath(path string, idx int) string {
+	return fmt.Sprintf("%s[%d]", path, idx)
+}
+
+// parentPath returns the path of the map or list containing the value at path.
+func parentPath(path string) string {
+	idx := strings.LastIndexAny(path, ".[")
+	if idx < 0 {
+		return ""
+	}
+	return path[:idx]
+}
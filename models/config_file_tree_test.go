@@ -558,3 +558,279 @@ workflows:
 			})
 	}
 }
+
+func TestMergeWithProvenance(t *testing.T) {
+	ymlTree := ConfigFileTreeModel{
+		Path: "bitrise.yml",
+		Contents: `format_version: "15"
+include:
+- path: module.yml
+
+workflows:
+  primary:
+    envs:
+    - NAME: John Doe
+    steps:
+    - script: {}`,
+		Includes: []ConfigFileTreeModel{
+			{
+				Path: "module.yml",
+				Contents: `workflows:
+  primary:
+    envs:
+    - NAME: Bitrise
+    steps:
+    - git-clone: {}
+  deploy:
+    title: Deploy`,
+			},
+		},
+	}
+
+	_, provenance, err := ymlTree.MergeWithProvenance()
+	require.NoError(t, err)
+
+	require.Equal(t, Provenance{
+		"format_version":                       {File: "bitrise.yml", Line: 1},
+		"workflows":                            {File: "module.yml", Line: 1},
+		"workflows.primary":                    {File: "module.yml", Line: 2},
+		"workflows.primary.envs":               {File: "module.yml", Line: 3},
+		"workflows.primary.envs[0]":            {File: "module.yml", Line: 4},
+		"workflows.primary.envs[0].NAME":       {File: "module.yml", Line: 4},
+		"workflows.primary.envs[1]":            {File: "bitrise.yml", Line: 8},
+		"workflows.primary.envs[1].NAME":       {File: "bitrise.yml", Line: 8},
+		"workflows.primary.steps":              {File: "module.yml", Line: 5},
+		"workflows.primary.steps[0]":           {File: "module.yml", Line: 6},
+		"workflows.primary.steps[0].git-clone": {File: "module.yml", Line: 6},
+		"workflows.primary.steps[1]":           {File: "bitrise.yml", Line: 10},
+		"workflows.primary.steps[1].script":    {File: "bitrise.yml", Line: 10},
+		"workflows.deploy":                     {File: "module.yml", Line: 7},
+		"workflows.deploy.title":               {File: "module.yml", Line: 8},
+	}, provenance)
+
+	origin, ok := provenance.Origin("workflows.deploy.title.unknown")
+	require.True(t, ok)
+	require.Equal(t, "module.yml:8", origin.String())
+}
+
+func TestMergeWithProvenance_Overrides(t *testing.T) {
+	ymlTree := ConfigFileTreeModel{
+		Path:     "bitrise.yml",
+		Contents: "app:\n  envs: []\ntitle: Main\n",
+		Includes: []ConfigFileTreeModel{
+			{Path: "module_1.yml", Contents: "title: Module 1\napp:\n  envs:\n  - A: a\n"},
+			{Path: "module_2.yml", Contents: "\ntitle: Module 2\napp: none\n"},
+		},
+	}
+
+	_, provenance, err := ymlTree.MergeWithProvenance()
+	require.NoError(t, err)
+
+	require.Equal(t, ConfigOrigin{File: "bitrise.yml", Line: 3, Overrides: []ConfigOrigin{{File: "module_2.yml", Line: 2}, {File: "module_1.yml", Line: 1}}}, provenance["title"])
+	// The map overrides the string, the earlier list items are dropped
+	require.Equal(t, ConfigOrigin{File: "bitrise.yml", Line: 1, Overrides: []ConfigOrigin{{File: "module_2.yml", Line: 3}, {File: "module_1.yml", Line: 2}}}, provenance["app"])
+	require.NotContains(t, provenance, "app.envs[0]")
+	require.Equal(t, ConfigOrigin{File: "bitrise.yml", Line: 2}, provenance["app.envs"])
+}
+
+func TestMerge_Directives(t *testing.T) {
+	t.Parallel()
+
+	module := ConfigFileTreeModel{
+		Path: "module.yml",
+		Contents: `
+trigger_map:
+- push_branch: main
+  workflow: primary
+- pull_request_source_branch: "*"
+  workflow: primary
+workflows:
+  primary:
+    before_run:
+    - setup
+    envs:
+    - NAME: Bitrise
+    - EMAIL: bot@bitrise.io
+      opts:
+        is_expand: false
+    steps:
+    - git-clone@8: {}
+    - script@1:
+        title: Build
+        inputs:
+        - content: make build
+    - script@1:
+        title: Test
+        inputs:
+        - content: make test
+        - working_dir: src
+`,
+	}
+
+	for _, test := range []struct {
+		name     string
+		contents string
+		expected string
+	}{
+		{
+			name: "lists are appended by default",
+			contents: `
+workflows:
+  primary:
+    before_run: !append
+    - lint
+    envs:
+    - NAME: John Doe`,
+			expected: `
+trigger_map:
+- push_branch: main
+  workflow: primary
+- pull_request_source_branch: "*"
+  workflow: primary
+workflows:
+  primary:
+    before_run: [setup, lint]
+    envs:
+    - NAME: Bitrise
+    - EMAIL: bot@bitrise.io
+      opts:
+        is_expand: false
+    - NAME: John Doe
+    steps:
+    - git-clone@8: {}
+    - script@1:
+        title: Build
+        inputs:
+        - content: make build
+    - script@1:
+        title: Test
+        inputs:
+        - content: make test
+        - working_dir: src`,
+		},
+		{
+			name: "prepend and replace",
+			contents: `
+trigger_map: !replace
+- tag: "*"
+  workflow: primary
+workflows:
+  primary:
+    before_run: !prepend
+    - lint
+    - cache-pull
+    envs: !replace []`,
+			expected: `
+trigger_map:
+- tag: "*"
+  workflow: primary
+workflows:
+  primary:
+    before_run: [lint, cache-pull, setup]
+    envs: []
+    steps:
+    - git-clone@8: {}
+    - script@1:
+        title: Build
+        inputs:
+        - content: make build
+    - script@1:
+        title: Test
+        inputs:
+        - content: make test
+        - working_dir: src`,
+		},
+		{
+			name: "keyed merge",
+			contents: `
+trigger_map: !merge
+- push_branch: main
+  workflow: deploy
+- tag: "*"
+  workflow: deploy
+workflows:
+  primary:
+    before_run: !merge
+    - setup
+    - lint
+    envs: !merge
+    - EMAIL: admin@bitrise.io
+    steps: !merge
+    - script@2:
+        title: Test
+        inputs: !merge
+        - content: make test-all
+    - git-clone:
+        is_skippable: true
+    - deploy-to-bitrise-io: {}`,
+			expected: `
+trigger_map:
+- push_branch: main
+  workflow: deploy
+- pull_request_source_branch: "*"
+  workflow: primary
+- tag: "*"
+  workflow: deploy
+workflows:
+  primary:
+    before_run: [setup, lint]
+    envs:
+    - NAME: Bitrise
+    - EMAIL: admin@bitrise.io
+      opts:
+        is_expand: false
+    steps:
+    - git-clone:
+        is_skippable: true
+    - script@1:
+        title: Build
+        inputs:
+        - content: make build
+    - script@2:
+        title: Test
+        inputs:
+        - content: make test-all
+        - working_dir: src
+    - deploy-to-bitrise-io: {}`,
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ymlTree := ConfigFileTreeModel{Path: "bitrise.yml", Contents: test.contents, Includes: []ConfigFileTreeModel{module}}
+			result, err := ymlTree.Merge()
+			require.NoError(t, err)
+			require.YAMLEq(t, test.expected, result)
+		})
+	}
+}
+
+func TestMerge_InvalidDirectives(t *testing.T) {
+	ymlTree := ConfigFileTreeModel{Path: "bitrise.yml", Contents: "workflows:\n  primary:\n    steps: !insert []\n"}
+	_, err := ymlTree.Merge()
+	require.EqualError(t, err, "failed to merge YML files, error: failed to parse YML file bitrise.yml, error: unknown merge directive (!insert) at line 3, valid directives are: !append, !prepend, !replace, !merge")
+
+	ymlTree = ConfigFileTreeModel{Path: "bitrise.yml", Contents: "workflows: !replace\n  primary: {}\n"}
+	_, err = ymlTree.Merge()
+	require.EqualError(t, err, "failed to merge YML files, error: failed to parse YML file bitrise.yml, error: merge directive (!replace) at line 1 is only supported for lists")
+}
+
+func TestMergeWithProvenance_Directives(t *testing.T) {
+	ymlTree := ConfigFileTreeModel{
+		Path:     "bitrise.yml",
+		Contents: "steps: !prepend\n- git-clone: {}\n",
+		Includes: []ConfigFileTreeModel{
+			{Path: "module_1.yml", Contents: "steps:\n- script@1:\n    title: Test\n"},
+			{Path: "module_2.yml", Contents: "steps: !merge\n- script@2: {}\n"},
+		},
+	}
+
+	_, provenance, err := ymlTree.MergeWithProvenance()
+	require.NoError(t, err)
+
+	require.Equal(t, ConfigOrigin{File: "bitrise.yml", Line: 2}, provenance["steps[0].git-clone"])
+	require.Equal(t, ConfigOrigin{File: "module_2.yml", Line: 2, Overrides: []ConfigOrigin{{File: "module_1.yml", Line: 2}}}, provenance["steps[1].script@2"])
+	require.Equal(t, ConfigOrigin{File: "module_1.yml", Line: 3}, provenance["steps[1].script@2.title"])
+	require.NotContains(t, provenance, "steps[0].script@1")
+}
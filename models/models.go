@@ -34,7 +34,13 @@ type WithModel struct {
 
 type StepListWithItemModel map[string]WithModel
 
-type StepListStepItemModel map[string]stepmanModels.StepModel
+// StepListStepModel is a step of a step list: the step model extended with the bitrise.yml only step properties.
+type StepListStepModel struct {
+	stepmanModels.StepModel `yaml:",inline"`
+	Retry                   *StepRetryModel `json:"retry,omitempty" yaml:"retry,omitempty"`
+}
+
+type StepListStepItemModel map[string]StepListStepModel
 
 type StepListItemModel map[string]interface{}
 
@@ -70,6 +76,14 @@ type DagWorkflowModel struct {
 	DependsOn []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
 }
 
+// WorkflowShareModel declares the outputs of a workflow, which are passed to the dependent workflows of a pipeline.
+type WorkflowShareModel struct {
+	// Envs are the keys of the env vars whose values are shared.
+	Envs []string `json:"envs,omitempty" yaml:"envs,omitempty"`
+	// Paths are the keys of the env vars pointing to the shared files or directories (intermediate files).
+	Paths []string `json:"paths,omitempty" yaml:"paths,omitempty"`
+}
+
 type WorkflowListItemModel map[string]WorkflowModel
 
 type WorkflowModel struct {
@@ -81,6 +95,9 @@ type WorkflowModel struct {
 	Environments []envmanModels.EnvironmentItemModel `json:"envs,omitempty" yaml:"envs,omitempty"`
 	Steps        []StepListItemModel                 `json:"steps,omitempty" yaml:"steps,omitempty"`
 	Meta         map[string]interface{}              `json:"meta,omitempty" yaml:"meta,omitempty"`
+	Share        *WorkflowShareModel                 `json:"share,omitempty" yaml:"share,omitempty"`
+	// Timeout (in seconds) limits the run time of the workflow's steps, 0 means no limit.
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 type DockerCredentials struct {
@@ -140,8 +157,16 @@ type BuildRunResultsModel struct {
 	FailedSteps          []StepRunResultsModel `json:"failed_steps" yaml:"failed_steps"`
 	FailedSkippableSteps []StepRunResultsModel `json:"failed_skippable_steps" yaml:"failed_skippable_steps"`
 	SkippedSteps         []StepRunResultsModel `json:"skipped_steps" yaml:"skipped_steps"`
+	// Status is set when the build finished, see BuildRunStatus.
+	Status string `json:"status,omitempty" yaml:"status,omitempty"`
 }
 
+const (
+	BuildRunStatusSuccess = "success"
+	BuildRunStatusFailed  = "failed"
+	BuildRunStatusAborted = "aborted"
+)
+
 type StepRunResultsModel struct {
 	StepInfo   stepmanModels.StepInfoModel `json:"step_info" yaml:"step_info"`
 	StepInputs map[string]string           `json:"step_inputs" yaml:"step_inputs"`
@@ -151,6 +176,10 @@ type StepRunResultsModel struct {
 	StartTime  time.Time                   `json:"start_time" yaml:"start_time"`
 	ErrorStr   string                      `json:"error_str" yaml:"error_str"`
 	ExitCode   int                         `json:"exit_code" yaml:"exit_code"`
+	// Attempts holds the results of every run of a retried step.
+	Attempts []StepRunAttemptModel `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	// LogPath is the path of the file containing the step's output.
+	LogPath string `json:"log_path,omitempty" yaml:"log_path,omitempty"`
 
 	Timeout         time.Duration `json:"-"`
 	NoOutputTimeout time.Duration `json:"-"`
@@ -179,6 +208,10 @@ func (s StepRunResultsModel) StatusReasonAndErrors() (string, []StepError) {
 		return "", s.error()
 	case StepRunStatusAbortedWithNoOutputTimeout:
 		return "", s.error()
+	case StepRunStatusAbortedWithRunTimeout:
+		return "", s.error()
+	case StepRunStatusAborted:
+		return "", s.error()
 	default:
 		return "", nil
 	}
@@ -190,7 +223,9 @@ func (s StepRunResultsModel) statusReason() string {
 		StepRunStatusCodeFailed,
 		StepRunStatusCodePreparationFailed,
 		StepRunStatusAbortedWithCustomTimeout,
-		StepRunStatusAbortedWithNoOutputTimeout:
+		StepRunStatusAbortedWithNoOutputTimeout,
+		StepRunStatusAbortedWithRunTimeout,
+		StepRunStatusAborted:
 		return ""
 	case StepRunStatusCodeFailedSkippable:
 		return `This Step failed, but it was marked as "is_skippable", so the build continued.`
@@ -214,7 +249,9 @@ func (s StepRunResultsModel) error() []StepError {
 		return nil
 	case StepRunStatusCodeFailedSkippable,
 		StepRunStatusCodeFailed,
-		StepRunStatusCodePreparationFailed:
+		StepRunStatusCodePreparationFailed,
+		StepRunStatusAbortedWithRunTimeout,
+		StepRunStatusAborted:
 		message = s.ErrorStr
 	case StepRunStatusAbortedWithCustomTimeout:
 		message = fmt.Sprintf("This Step timed out after %s.", formatStatusReasonTimeInterval(s.Timeout))
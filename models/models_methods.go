@@ -119,7 +119,7 @@ func (bundle *StepBundleModel) Normalize() error {
 		if err := step.Normalize(); err != nil {
 			return err
 		}
-		stepListItem[stepID] = step
+		stepListItem[stepID] = StepListStepModel{StepModel: step, Retry: stepListItem[stepID].Retry}
 		bundle.Steps[idx] = stepListItem
 	}
 
@@ -152,7 +152,7 @@ func (with *WithModel) Normalize() error {
 		if err := step.Normalize(); err != nil {
 			return err
 		}
-		stepListItem[stepID] = step
+		stepListItem[stepID] = StepListStepModel{StepModel: step, Retry: stepListItem[stepID].Retry}
 		with.Steps[idx] = stepListItem
 	}
 	return nil
@@ -190,7 +190,7 @@ func (workflow *WorkflowModel) Normalize() error {
 				return err
 			}
 
-			stepListItem[key] = *step
+			stepListItem[key] = StepListStepModel{StepModel: *step, Retry: stepListItem.GetRetry()}
 			workflow.Steps[idx] = stepListItem
 		} else if t == StepListItemTypeBundle {
 			bundle, err := stepListItem.GetBundle()
@@ -312,7 +312,7 @@ func (bundle *StepBundleModel) Validate() ([]string, error) {
 			return warnings, err
 		}
 
-		warns, err := validateStep(stepID, step)
+		warns, err := validateStep(stepID, step, stepListItem[stepID].Retry)
 		warnings = append(warnings, warns...)
 		if err != nil {
 			return warnings, err
@@ -357,7 +357,7 @@ func (with *WithModel) Validate(workflowID string, containers, services map[stri
 			return warnings, err
 		}
 
-		warns, err := validateStep(stepID, step)
+		warns, err := validateStep(stepID, step, stepListItem[stepID].Retry)
 		warnings = append(warnings, warns...)
 		if err != nil {
 			return warnings, err
@@ -374,6 +374,31 @@ func (workflow *WorkflowModel) Validate() error {
 			return err
 		}
 	}
+
+	if workflow.Share != nil {
+		if err := workflow.Share.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if workflow.Timeout < 0 {
+		return fmt.Errorf("timeout (%d) can't be negative", workflow.Timeout)
+	}
+
+	return nil
+}
+
+func (share *WorkflowShareModel) Validate() error {
+	keys := map[string]bool{}
+	for _, key := range append(append([]string{}, share.Envs...), share.Paths...) {
+		if key == "" {
+			return errors.New("shared env key is empty")
+		}
+		if keys[key] {
+			return fmt.Errorf("env (%s) is shared multiple times", key)
+		}
+		keys[key] = true
+	}
 	return nil
 }
 
@@ -496,7 +521,7 @@ func validateStepBundles(config BitriseDataModel) ([]string, error) {
 	return warnings, nil
 }
 
-func validateStep(stepID string, step stepmanModels.StepModel) ([]string, error) {
+func validateStep(stepID string, step stepmanModels.StepModel, retry *StepRetryModel) ([]string, error) {
 	var warnings []string
 
 	if err := stepid.Validate(stepID); err != nil {
@@ -507,6 +532,12 @@ func validateStep(stepID string, step stepmanModels.StepModel) ([]string, error)
 		return warnings, err
 	}
 
+	if retry != nil {
+		if err := retry.Validate(); err != nil {
+			return warnings, fmt.Errorf("invalid retry policy of step (%s): %w", stepID, err)
+		}
+	}
+
 	stepInputMap := map[string]bool{}
 	for _, input := range step.Inputs {
 		key, _, err := input.GetKeyValuePair()
@@ -709,14 +740,15 @@ func validateWorkflows(config *BitriseDataModel) ([]string, error) {
 					return warnings, err
 				}
 				stepID := key
-				warns, err := validateStep(stepID, *step)
+				retry := stepListItem.GetRetry()
+				warns, err := validateStep(stepID, *step, retry)
 				warnings = append(warnings, warns...)
 				if err != nil {
 					return warnings, err
 				}
 
 				// TODO: Why is this assignment needed?
-				stepListItem[stepID] = *step
+				stepListItem[stepID] = StepListStepModel{StepModel: *step, Retry: retry}
 			} else if t == StepListItemTypeWith {
 				with, err := stepListItem.GetWith()
 				if err != nil {
@@ -1160,6 +1192,15 @@ func getWorkflowID(workflowListItem StageWorkflowListItemModel) (string, error)
 	return "", errors.New("StageWorkflowListItemModel does not contain a key-value pair")
 }
 
+// GetWorkflowIDAndWorkflow ...
+func (workflowListItem StageWorkflowListItemModel) GetWorkflowIDAndWorkflow() (string, StageWorkflowModel, error) {
+	workflowID, err := getWorkflowID(workflowListItem)
+	if err != nil {
+		return "", StageWorkflowModel{}, err
+	}
+	return workflowID, workflowListItem[workflowID], nil
+}
+
 // ----------------------------
 // --- StageIDData
 
@@ -1173,6 +1214,11 @@ func getStageID(stageListItem StageListItemModel) (string, error) {
 	return "", errors.New("StageListItemModel does not contain a key-value pair")
 }
 
+// GetStageID ...
+func (stageListItem StageListItemModel) GetStageID() (string, error) {
+	return getStageID(stageListItem)
+}
+
 // ----------------------------
 // --- StepIDData
 
@@ -1287,7 +1333,7 @@ func (stepListStepItem *StepListStepItemModel) GetStepIDAndStep() (string, stepm
 	var step stepmanModels.StepModel
 	for k, v := range *stepListStepItem {
 		stepID = k
-		step = v
+		step = v.StepModel
 		break
 	}
 
@@ -1369,10 +1415,11 @@ func (stepListItem *StepListItemModel) GetStep() (*stepmanModels.StepModel, erro
 
 	var stepPtr *stepmanModels.StepModel
 	for _, value := range *stepListItem {
-		s, ok := value.(stepmanModels.StepModel)
-		if ok {
+		switch s := value.(type) {
+		case StepListStepModel:
+			stepPtr = &s.StepModel
+		case stepmanModels.StepModel:
 			stepPtr = &s
-			break
 		}
 
 		break
@@ -1385,6 +1432,22 @@ func (stepListItem *StepListItemModel) GetStep() (*stepmanModels.StepModel, erro
 	return stepPtr, nil
 }
 
+// GetRetry returns the retry policy of the step, or nil if the item is not a step or the step has none.
+func (stepListItem *StepListItemModel) GetRetry() *StepRetryModel {
+	if stepListItem == nil {
+		return nil
+	}
+
+	for _, value := range *stepListItem {
+		if step, ok := value.(StepListStepModel); ok {
+			return step.Retry
+		}
+		break
+	}
+
+	return nil
+}
+
 // ----------------------------
 // --- BuildRunResults
 
@@ -1396,11 +1459,37 @@ func (buildRes BuildRunResultsModel) IsBuildFailed() bool {
 	return len(buildRes.FailedSteps) > 0
 }
 
+// IsBuildAborted returns true if the build was cancelled.
+func (buildRes BuildRunResultsModel) IsBuildAborted() bool {
+	for _, stepResult := range buildRes.FailedSteps {
+		if stepResult.Status == StepRunStatusAborted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BuildRunStatus returns the status of the finished build.
+func (buildRes BuildRunResultsModel) BuildRunStatus() string {
+	if buildRes.IsBuildAborted() {
+		return BuildRunStatusAborted
+	}
+	if buildRes.IsBuildFailed() {
+		return BuildRunStatusFailed
+	}
+	return BuildRunStatusSuccess
+}
+
 func (buildRes BuildRunResultsModel) ExitCode() int {
 	if !buildRes.IsBuildFailed() {
 		return 0
 	}
 
+	if buildRes.isBuildAbortedWithRunTimeout() {
+		return exitcode.CLIAbortedWithRunTimeout
+	}
+
 	if buildRes.isBuildAbortedWithNoOutputTimeout() {
 		return exitcode.CLIAbortedWithNoOutputTimeout
 	}
@@ -1440,6 +1529,16 @@ func (buildRes BuildRunResultsModel) isBuildAbortedWithNoOutputTimeout() bool {
 	return false
 }
 
+func (buildRes BuildRunResultsModel) isBuildAbortedWithRunTimeout() bool {
+	for _, stepResult := range buildRes.FailedSteps {
+		if stepResult.Status == StepRunStatusAbortedWithRunTimeout {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (buildRes BuildRunResultsModel) unorderedResults() []StepRunResultsModel {
 	results := append([]StepRunResultsModel{}, buildRes.SuccessSteps...)
 	results = append(results, buildRes.FailedSteps...)
@@ -114,6 +114,7 @@ workflows:
   deploy:
   test:
   check:`,
+			wantWarns: []string{"trigger item #1: disabled (enabled: false), it never triggers a build"},
 		},
 		{
 			name: "Containers are normalized",
@@ -232,7 +233,11 @@ workflows:
 			require.NoError(t, yaml.Unmarshal([]byte(tt.config), &config))
 
 			warns, err := config.Validate()
-			require.Empty(t, warns)
+			if len(tt.wantWarns) > 0 {
+				require.Equal(t, tt.wantWarns, warns)
+			} else {
+				require.Empty(t, warns)
+			}
 			require.NoError(t, err)
 
 			err = config.Normalize()
@@ -888,6 +893,18 @@ workflows:
 		require.NoError(t, err)
 		require.Equal(t, 1, len(warnings))
 	}
+
+	t.Log("shared outputs")
+	{
+		workflow := WorkflowModel{Share: &WorkflowShareModel{Envs: []string{"BUILD_NUMBER"}, Paths: []string{"BITRISE_IPA_PATH"}}}
+		require.NoError(t, workflow.Validate())
+
+		workflow = WorkflowModel{Share: &WorkflowShareModel{Envs: []string{"BITRISE_IPA_PATH"}, Paths: []string{"BITRISE_IPA_PATH"}}}
+		require.EqualError(t, workflow.Validate(), "env (BITRISE_IPA_PATH) is shared multiple times")
+
+		workflow = WorkflowModel{Share: &WorkflowShareModel{Envs: []string{""}}}
+		require.EqualError(t, workflow.Validate(), "shared env key is empty")
+	}
 }
 
 // Trigger map
@@ -975,6 +992,7 @@ workflows:
   test:
   check:
 `,
+			wantWarns: []string{"trigger item #1: disabled (enabled: false), it never triggers a build"},
 		},
 	}
 	for _, tt := range tests {
@@ -1611,3 +1629,20 @@ func createConfig(t *testing.T, yamlContent string) BitriseDataModel {
 	require.NoError(t, yaml.Unmarshal([]byte(yamlContent), &config))
 	return config
 }
+
+func TestBuildRunResultsModel_BuildRunStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		buildResults BuildRunResultsModel
+		want         string
+	}{
+		{name: "Success", buildResults: BuildRunResultsModel{SuccessSteps: []StepRunResultsModel{{Status: StepRunStatusCodeSuccess}}}, want: BuildRunStatusSuccess},
+		{name: "Failed", buildResults: BuildRunResultsModel{FailedSteps: []StepRunResultsModel{{Status: StepRunStatusCodeFailed}}}, want: BuildRunStatusFailed},
+		{name: "Aborted", buildResults: BuildRunResultsModel{FailedSteps: []StepRunResultsModel{{Status: StepRunStatusCodeFailed}, {Status: StepRunStatusAborted}}}, want: BuildRunStatusAborted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.buildResults.BuildRunStatus())
+		})
+	}
+}
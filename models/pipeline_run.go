@@ -0,0 +1,146 @@
+package models
+
+import (
+	"time"
+
+	"github.com/bitrise-io/bitrise/exitcode"
+)
+
+// WorkflowRunStatus is the outcome of a single workflow run as part of a pipeline.
+type WorkflowRunStatus int
+
+const (
+	WorkflowRunStatusSuccess WorkflowRunStatus = 0
+	WorkflowRunStatusFailed  WorkflowRunStatus = 1
+	WorkflowRunStatusSkipped WorkflowRunStatus = 2 // the workflow (or its stage) was not started
+	WorkflowRunStatusAborted WorkflowRunStatus = 3 // the workflow was not started because of an abort_on_fail stage or the build cancellation
+)
+
+func (s WorkflowRunStatus) String() string {
+	switch s {
+	case WorkflowRunStatusSuccess:
+		return "success"
+	case WorkflowRunStatusFailed:
+		return "failed"
+	case WorkflowRunStatusSkipped:
+		return "skipped"
+	case WorkflowRunStatusAborted:
+		return "aborted"
+	default:
+		return "unknown"
+	}
+}
+
+// PipelineWorkflowRunPlan describes a single workflow of a pipeline.
+type PipelineWorkflowRunPlan struct {
+	WorkflowID string   `json:"workflow_id"`
+	DependsOn  []string `json:"depends_on,omitempty"`
+	RunIf      string   `json:"run_if,omitempty"`
+}
+
+// PipelineStageRunPlan groups the workflows of a pipeline which are started together.
+// DAG pipelines are represented by a single stage without ID, where workflows are ordered to satisfy their dependencies.
+type PipelineStageRunPlan struct {
+	StageID         string                    `json:"stage_id,omitempty"`
+	ShouldAlwaysRun bool                      `json:"should_always_run"`
+	AbortOnFail     bool                      `json:"abort_on_fail"`
+	RunIf           string                    `json:"run_if,omitempty"`
+	Workflows       []PipelineWorkflowRunPlan `json:"workflows"`
+}
+
+type PipelineRunPlan struct {
+	PipelineID string                 `json:"pipeline_id"`
+	Stages     []PipelineStageRunPlan `json:"stages"`
+}
+
+type WorkflowRunResultsModel struct {
+	WorkflowID      string                `json:"workflow_id" yaml:"workflow_id"`
+	Status          WorkflowRunStatus     `json:"status" yaml:"status"`
+	StatusReason    string                `json:"status_reason,omitempty" yaml:"status_reason,omitempty"`
+	StartTime       time.Time             `json:"start_time" yaml:"start_time"`
+	RunTime         time.Duration         `json:"run_time" yaml:"run_time"`
+	BuildRunResults *BuildRunResultsModel `json:"build_run_results,omitempty" yaml:"build_run_results,omitempty"`
+}
+
+type StageRunResultsModel struct {
+	StageID      string                    `json:"stage_id,omitempty" yaml:"stage_id,omitempty"`
+	Status       WorkflowRunStatus         `json:"status" yaml:"status"`
+	StatusReason string                    `json:"status_reason,omitempty" yaml:"status_reason,omitempty"`
+	StartTime    time.Time                 `json:"start_time" yaml:"start_time"`
+	RunTime      time.Duration             `json:"run_time" yaml:"run_time"`
+	Workflows    []WorkflowRunResultsModel `json:"workflows" yaml:"workflows"`
+}
+
+type PipelineRunResultsModel struct {
+	PipelineID string                 `json:"pipeline_id" yaml:"pipeline_id"`
+	StartTime  time.Time              `json:"start_time" yaml:"start_time"`
+	Stages     []StageRunResultsModel `json:"stages" yaml:"stages"`
+}
+
+// IsFailed returns true if any of the workflows failed or was aborted.
+func (stageRes StageRunResultsModel) IsFailed() bool {
+	for _, workflowRes := range stageRes.Workflows {
+		if workflowRes.Status == WorkflowRunStatusFailed || workflowRes.Status == WorkflowRunStatusAborted {
+			return true
+		}
+	}
+	return false
+}
+
+func (pipelineRes PipelineRunResultsModel) IsBuildFailed() bool {
+	for _, stageRes := range pipelineRes.Stages {
+		if stageRes.IsFailed() {
+			return true
+		}
+	}
+	return false
+}
+
+// ExitCode returns the exit code of the first failed workflow.
+func (pipelineRes PipelineRunResultsModel) ExitCode() int {
+	if !pipelineRes.IsBuildFailed() {
+		return exitcode.CLISuccess
+	}
+
+	for _, stageRes := range pipelineRes.Stages {
+		for _, workflowRes := range stageRes.Workflows {
+			if workflowRes.BuildRunResults != nil && workflowRes.BuildRunResults.IsBuildFailed() {
+				return workflowRes.BuildRunResults.ExitCode()
+			}
+		}
+	}
+
+	return exitcode.CLIFailed
+}
+
+// WorkflowResults returns the results of every workflow in execution order.
+func (pipelineRes PipelineRunResultsModel) WorkflowResults() []WorkflowRunResultsModel {
+	var results []WorkflowRunResultsModel
+	for _, stageRes := range pipelineRes.Stages {
+		results = append(results, stageRes.Workflows...)
+	}
+	return results
+}
+
+// FailedSteps collects the failed steps of every workflow run so far,
+// used to evaluate pipeline level run_if expressions.
+func (pipelineRes PipelineRunResultsModel) FailedSteps() []StepRunResultsModel {
+	var failedSteps []StepRunResultsModel
+	for _, workflowRes := range pipelineRes.WorkflowResults() {
+		if workflowRes.BuildRunResults != nil {
+			failedSteps = append(failedSteps, workflowRes.BuildRunResults.FailedSteps...)
+		}
+	}
+	return failedSteps
+}
+
+// BuildRunResults returns the build results of the started workflows in execution order.
+func (pipelineRes PipelineRunResultsModel) BuildRunResults() []BuildRunResultsModel {
+	var results []BuildRunResultsModel
+	for _, workflowRes := range pipelineRes.WorkflowResults() {
+		if workflowRes.BuildRunResults != nil {
+			results = append(results, *workflowRes.BuildRunResults)
+		}
+	}
+	return results
+}
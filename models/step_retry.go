@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StepRetryModel is the retry policy of a step.
+type StepRetryModel struct {
+	// Attempts is the maximum number of step runs, including the first one.
+	Attempts int `json:"attempts" yaml:"attempts"`
+	// Backoff is the wait time (in seconds) before the first retry, it doubles before each further retry.
+	Backoff int `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	// Statuses lists the step run statuses to retry, all the failed statuses are retried by default.
+	Statuses []string `json:"statuses,omitempty" yaml:"statuses,omitempty"`
+	// ExitCodes limits retrying the failed status to the given exit codes.
+	ExitCodes []int `json:"exit_codes,omitempty" yaml:"exit_codes,omitempty"`
+}
+
+const maxBackoffExponent = 10
+
+var retryableStepRunStatuses = []StepRunStatus{
+	StepRunStatusCodeFailed,
+	StepRunStatusAbortedWithCustomTimeout,
+	StepRunStatusAbortedWithNoOutputTimeout,
+}
+
+// StepRunAttemptModel is the result of a single run of a retried step.
+type StepRunAttemptModel struct {
+	Status    StepRunStatus `json:"status" yaml:"status"`
+	ExitCode  int           `json:"exit_code" yaml:"exit_code"`
+	ErrorStr  string        `json:"error_str,omitempty" yaml:"error_str,omitempty"`
+	StartTime time.Time     `json:"start_time" yaml:"start_time"`
+	RunTime   time.Duration `json:"run_time" yaml:"run_time"`
+}
+
+// Validate ...
+func (retry StepRetryModel) Validate() error {
+	if retry.Attempts < 1 {
+		return errors.New("retry attempts should be at least 1")
+	}
+	if retry.Backoff < 0 {
+		return errors.New("retry backoff can't be negative")
+	}
+
+	for _, statusName := range retry.Statuses {
+		status := NewStepRunStatus(statusName)
+		isRetryable := false
+		for _, retryableStatus := range retryableStepRunStatuses {
+			if status == retryableStatus {
+				isRetryable = true
+				break
+			}
+		}
+		if !isRetryable {
+			return fmt.Errorf("step run status (%s) can't be retried", statusName)
+		}
+	}
+
+	return nil
+}
+
+// ShouldRetry returns if the step finished with the given status and exit code should run again.
+func (retry StepRetryModel) ShouldRetry(status StepRunStatus, exitCode int) bool {
+	statuses := retryableStepRunStatuses
+	if len(retry.Statuses) > 0 {
+		statuses = nil
+		for _, statusName := range retry.Statuses {
+			statuses = append(statuses, NewStepRunStatus(statusName))
+		}
+	}
+
+	isRetried := false
+	for _, retriedStatus := range statuses {
+		if status == retriedStatus {
+			isRetried = true
+			break
+		}
+	}
+	if !isRetried {
+		return false
+	}
+
+	if status == StepRunStatusCodeFailed && len(retry.ExitCodes) > 0 {
+		for _, code := range retry.ExitCodes {
+			if exitCode == code {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// BackoffBefore returns the wait time before the given attempt (the first attempt is 1).
+func (retry StepRetryModel) BackoffBefore(attempt int) time.Duration {
+	if attempt < 2 || retry.Backoff == 0 {
+		return 0
+	}
+
+	exponent := attempt - 2
+	if exponent > maxBackoffExponent {
+		exponent = maxBackoffExponent
+	}
+	return time.Duration(retry.Backoff) * time.Second * time.Duration(1<<exponent)
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestStepRetries(t *testing.T) {
+	config := createConfig(t, `
+format_version: "15"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+step_bundles:
+  deploy:
+    steps:
+    - deploy-to-bitrise-io:
+        retry:
+          attempts: 4
+
+workflows:
+  primary:
+    steps:
+    - script:
+        retry:
+          attempts: 3
+          backoff: 10
+          statuses: [aborted_with_no_output]
+    - with:
+        steps:
+        - script:
+            retry:
+              attempts: 2
+              exit_codes: [75]
+    - deploy-to-bitrise-io:
+        meta:
+          retry: custom
+    - bundle::deploy: {}
+`)
+	warns, err := config.Validate()
+	require.NoError(t, err)
+	require.Empty(t, warns)
+
+	workflow := config.Workflows["primary"]
+	require.Equal(t, &StepRetryModel{Attempts: 3, Backoff: 10, Statuses: []string{"aborted_with_no_output"}}, workflow.Steps[0].GetRetry())
+	require.Nil(t, workflow.Steps[1].GetRetry())
+	require.Nil(t, workflow.Steps[2].GetRetry())
+
+	with, err := workflow.Steps[1].GetWith()
+	require.NoError(t, err)
+	require.Equal(t, &StepRetryModel{Attempts: 2, ExitCodes: []int{75}}, with.Steps[0]["script"].Retry)
+
+	require.Equal(t, &StepRetryModel{Attempts: 4}, config.StepBundles["deploy"].Steps[0]["deploy-to-bitrise-io"].Retry)
+
+	t.Log("the retry policy is not stored in the step meta")
+	{
+		step, err := workflow.Steps[0].GetStep()
+		require.NoError(t, err)
+		require.Nil(t, step.Meta)
+
+		step, err = workflow.Steps[2].GetStep()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"retry": "custom"}, step.Meta)
+	}
+
+	t.Log("the retry policy is kept when the config is serialized")
+	{
+		b, err := json.Marshal(config)
+		require.NoError(t, err)
+		require.Contains(t, string(b), `"script":{"retry":{"attempts":3,"backoff":10,"statuses":["aborted_with_no_output"]}}`)
+
+		var parsedConfig BitriseDataModel
+		require.NoError(t, json.Unmarshal(b, &parsedConfig))
+		require.Equal(t, config.Workflows["primary"], parsedConfig.Workflows["primary"])
+		require.Equal(t, config.StepBundles["deploy"], parsedConfig.StepBundles["deploy"])
+
+		b, err = yaml.Marshal(config)
+		require.NoError(t, err)
+
+		parsedConfig = createConfig(t, string(b))
+		require.Equal(t, config.Workflows["primary"], parsedConfig.Workflows["primary"])
+		require.Equal(t, config.StepBundles["deploy"], parsedConfig.StepBundles["deploy"])
+	}
+
+	t.Log("the retry policy moves together with its step when the step list is edited")
+	{
+		workflow.Steps = []StepListItemModel{workflow.Steps[2], workflow.Steps[1], workflow.Steps[0]}
+		config.Workflows["primary"] = workflow
+
+		b, err := yaml.Marshal(config)
+		require.NoError(t, err)
+
+		parsedWorkflow := createConfig(t, string(b)).Workflows["primary"]
+		require.Nil(t, parsedWorkflow.Steps[0].GetRetry())
+		require.Equal(t, &StepRetryModel{Attempts: 3, Backoff: 10, Statuses: []string{"aborted_with_no_output"}}, parsedWorkflow.Steps[2].GetRetry())
+	}
+}
+
+func TestStepRetryModel_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   StepRetryModel
+		wantErr string
+	}{
+		{name: "Valid", retry: StepRetryModel{Attempts: 2, Backoff: 5, Statuses: []string{"failed", "aborted_with_custom_timeout"}}},
+		{name: "Missing attempts", retry: StepRetryModel{}, wantErr: "retry attempts should be at least 1"},
+		{name: "Negative backoff", retry: StepRetryModel{Attempts: 2, Backoff: -1}, wantErr: "retry backoff can't be negative"},
+		{name: "Not retryable status", retry: StepRetryModel{Attempts: 2, Statuses: []string{"skipped"}}, wantErr: "step run status (skipped) can't be retried"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.retry.Validate()
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStepRetryModel_ShouldRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		retry    StepRetryModel
+		status   StepRunStatus
+		exitCode int
+		want     bool
+	}{
+		{name: "Failed status is retried by default", retry: StepRetryModel{Attempts: 2}, status: StepRunStatusCodeFailed, exitCode: 1, want: true},
+		{name: "Timeout is retried by default", retry: StepRetryModel{Attempts: 2}, status: StepRunStatusAbortedWithNoOutputTimeout, want: true},
+		{name: "Not listed status", retry: StepRetryModel{Attempts: 2, Statuses: []string{"aborted_with_no_output"}}, status: StepRunStatusCodeFailed, exitCode: 1, want: false},
+		{name: "Listed status", retry: StepRetryModel{Attempts: 2, Statuses: []string{"aborted_with_no_output"}}, status: StepRunStatusAbortedWithNoOutputTimeout, want: true},
+		{name: "Listed exit code", retry: StepRetryModel{Attempts: 2, ExitCodes: []int{75}}, status: StepRunStatusCodeFailed, exitCode: 75, want: true},
+		{name: "Not listed exit code", retry: StepRetryModel{Attempts: 2, ExitCodes: []int{75}}, status: StepRunStatusCodeFailed, exitCode: 1, want: false},
+		{name: "Success", retry: StepRetryModel{Attempts: 2}, status: StepRunStatusCodeSuccess, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.retry.ShouldRetry(tt.status, tt.exitCode))
+		})
+	}
+}
+
+func TestStepRetryModel_BackoffBefore(t *testing.T) {
+	retry := StepRetryModel{Attempts: 4, Backoff: 5}
+	require.Equal(t, time.Duration(0), retry.BackoffBefore(1))
+	require.Equal(t, 5*time.Second, retry.BackoffBefore(2))
+	require.Equal(t, 10*time.Second, retry.BackoffBefore(3))
+	require.Equal(t, 20*time.Second, retry.BackoffBefore(4))
+	require.Equal(t, time.Duration(0), StepRetryModel{Attempts: 2}.BackoffBefore(2))
+}
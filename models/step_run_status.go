@@ -10,8 +10,10 @@ const (
 	StepRunStatusCodeSkipped                StepRunStatus = 3
 	StepRunStatusCodeSkippedWithRunIf       StepRunStatus = 4
 	StepRunStatusCodePreparationFailed      StepRunStatus = 5
-	StepRunStatusAbortedWithCustomTimeout   StepRunStatus = 7 // step times out due to a custom timeout
-	StepRunStatusAbortedWithNoOutputTimeout StepRunStatus = 8 // step times out due to no output received (hang)
+	StepRunStatusAbortedWithCustomTimeout   StepRunStatus = 7  // step times out due to a custom timeout
+	StepRunStatusAbortedWithNoOutputTimeout StepRunStatus = 8  // step times out due to no output received (hang)
+	StepRunStatusAbortedWithRunTimeout      StepRunStatus = 9  // step is aborted or not started due to the workflow or build timeout
+	StepRunStatusAborted                    StepRunStatus = 10 // step is aborted or not started due to the build cancellation
 )
 
 func NewStepRunStatus(status string) StepRunStatus {
@@ -32,6 +34,10 @@ func NewStepRunStatus(status string) StepRunStatus {
 		return StepRunStatusAbortedWithCustomTimeout
 	case "aborted_with_no_output":
 		return StepRunStatusAbortedWithNoOutputTimeout
+	case "aborted_with_run_timeout":
+		return StepRunStatusAbortedWithRunTimeout
+	case "aborted":
+		return StepRunStatusAborted
 	default:
 		return -1
 	}
@@ -55,6 +61,10 @@ func (s StepRunStatus) String() string {
 		return "aborted_with_custom_timeout"
 	case StepRunStatusAbortedWithNoOutputTimeout:
 		return "aborted_with_no_output"
+	case StepRunStatusAbortedWithRunTimeout:
+		return "aborted_with_run_timeout"
+	case StepRunStatusAborted:
+		return "aborted"
 	default:
 		return "unknown"
 	}
@@ -68,8 +78,11 @@ func (s StepRunStatus) Name() string {
 		StepRunStatusCodePreparationFailed,
 		StepRunStatusCodeFailedSkippable,
 		StepRunStatusAbortedWithCustomTimeout,
-		StepRunStatusAbortedWithNoOutputTimeout:
+		StepRunStatusAbortedWithNoOutputTimeout,
+		StepRunStatusAbortedWithRunTimeout:
 		return "Failed"
+	case StepRunStatusAborted:
+		return "Aborted"
 	case StepRunStatusCodeSkipped,
 		StepRunStatusCodeSkippedWithRunIf:
 		return "Skipped"
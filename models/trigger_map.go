@@ -33,12 +33,29 @@ func (triggerMap TriggerMapModel) Validate(workflows, pipelines []string) ([]str
 		}
 	}
 
+	warnings = append(warnings, triggerMap.checkUnreachableTriggerMapItems()...)
+
 	return warnings, nil
 }
 
 func (triggerMap TriggerMapModel) FirstMatchingTarget(pushBranch, prSourceBranch, prTargetBranch string, prReadyState PullRequestReadyState, tag string) (string, string, error) {
+	return triggerMap.FirstMatchingTargetWithParams(TriggerParams{
+		PushBranch:     pushBranch,
+		PRSourceBranch: prSourceBranch,
+		PRTargetBranch: prTargetBranch,
+		PRReadyState:   prReadyState,
+		Tag:            tag,
+	})
+}
+
+// FirstMatchingTargetWithParams returns the pipeline and workflow of the first enabled trigger item matching the params.
+func (triggerMap TriggerMapModel) FirstMatchingTargetWithParams(params TriggerParams) (string, string, error) {
 	for _, item := range triggerMap {
-		match, err := item.MatchWithParams(pushBranch, prSourceBranch, prTargetBranch, prReadyState, tag)
+		if !item.IsEnabled() {
+			continue
+		}
+
+		match, err := item.Match(params)
 		if err != nil {
 			return "", "", err
 		}
@@ -47,7 +64,66 @@ func (triggerMap TriggerMapModel) FirstMatchingTarget(pushBranch, prSourceBranch
 		}
 	}
 
-	return "", "", fmt.Errorf("no matching pipeline & workflow found with trigger params: push-branch: %s, pr-source-branch: %s, pr-target-branch: %s, tag: %s", pushBranch, prSourceBranch, prTargetBranch, tag)
+	return "", "", fmt.Errorf("no matching pipeline & workflow found with trigger params: push-branch: %s, pr-source-branch: %s, pr-target-branch: %s, tag: %s", params.PushBranch, params.PRSourceBranch, params.PRTargetBranch, params.Tag)
+}
+
+// TriggerItemEvaluation describes why a trigger item matched or didn't match the trigger params.
+type TriggerItemEvaluation struct {
+	Item       int    `json:"item"`
+	Conditions string `json:"conditions"`
+	PipelineID string `json:"pipeline,omitempty"`
+	WorkflowID string `json:"workflow,omitempty"`
+	Match      bool   `json:"match"`
+	Reason     string `json:"reason"`
+}
+
+// Explain evaluates the trigger items in order until the first match, like FirstMatchingTargetWithParams.
+func (triggerMap TriggerMapModel) Explain(params TriggerParams) ([]TriggerItemEvaluation, error) {
+	var evaluations []TriggerItemEvaluation
+	for idx, item := range triggerMap {
+		evaluation := TriggerItemEvaluation{
+			Item:       idx + 1,
+			Conditions: item.conditionsString(),
+			PipelineID: item.PipelineID,
+			WorkflowID: item.WorkflowID,
+		}
+
+		if !item.IsEnabled() {
+			evaluation.Reason = "disabled (enabled: false)"
+		} else {
+			var err error
+			evaluation.Match, evaluation.Reason, err = item.evaluate(params)
+			if err != nil {
+				return evaluations, err
+			}
+		}
+
+		evaluations = append(evaluations, evaluation)
+		if evaluation.Match {
+			break
+		}
+	}
+	return evaluations, nil
+}
+
+// checkUnreachableTriggerMapItems warns about the disabled trigger items
+// and the items which are shadowed by an earlier item, as these never trigger a build.
+func (triggerMap TriggerMapModel) checkUnreachableTriggerMapItems() []string {
+	var warnings []string
+	for idx, item := range triggerMap {
+		if !item.IsEnabled() {
+			warnings = append(warnings, fmt.Sprintf("trigger item #%d: disabled (enabled: false), it never triggers a build", idx+1))
+			continue
+		}
+
+		for previousIdx, previousItem := range triggerMap[:idx] {
+			if previousItem.IsEnabled() && previousItem.shadows(item) {
+				warnings = append(warnings, fmt.Sprintf("trigger item #%d: never matches, every event it matches is matched by the %d. trigger item", idx+1, previousIdx+1))
+				break
+			}
+		}
+	}
+	return warnings
 }
 
 func (triggerMap TriggerMapModel) checkDuplicatedTriggerMapItems() error {
@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/bitrise-io/go-utils/sliceutil"
@@ -65,10 +66,43 @@ type TriggerMapItemModel struct {
 	IsPullRequestAllowed bool   `json:"is_pull_request_allowed,omitempty" yaml:"is_pull_request_allowed,omitempty"`
 }
 
+// TriggerParams describes the git event the trigger map items are matched against.
+type TriggerParams struct {
+	PushBranch     string
+	PRSourceBranch string
+	PRTargetBranch string
+	PRReadyState   PullRequestReadyState
+	Tag            string
+
+	CommitMessage string
+	ChangedFiles  []string
+	PRLabels      []string
+	PRComment     string
+}
+
 func (item TriggerMapItemModel) MatchWithParams(pushBranch, prSourceBranch, prTargetBranch string, prReadyState PullRequestReadyState, tag string) (bool, error) {
-	paramsEventType, err := triggerEventType(pushBranch, prSourceBranch, prTargetBranch, tag)
+	return item.Match(TriggerParams{
+		PushBranch:     pushBranch,
+		PRSourceBranch: prSourceBranch,
+		PRTargetBranch: prTargetBranch,
+		PRReadyState:   prReadyState,
+		Tag:            tag,
+	})
+}
+
+// Match evaluates every condition of the trigger item against the params.
+// String literal conditions are glob patterns, regex conditions match if the regex matches any part of the value.
+// The changed_files and pull_request_label conditions match if any of the changed files or labels matches.
+func (item TriggerMapItemModel) Match(params TriggerParams) (bool, error) {
+	match, _, err := item.evaluate(params)
+	return match, err
+}
+
+// evaluate is Match, which also describes why the trigger item matched or didn't match the params.
+func (item TriggerMapItemModel) evaluate(params TriggerParams) (bool, string, error) {
+	paramsEventType, err := triggerEventType(params.PushBranch, params.PRSourceBranch, params.PRTargetBranch, params.Tag)
 	if err != nil {
-		return false, err
+		return false, "", err
 	}
 
 	migratedTriggerItems := []TriggerMapItemModel{item}
@@ -76,61 +110,77 @@ func (item TriggerMapItemModel) MatchWithParams(pushBranch, prSourceBranch, prTa
 		migratedTriggerItems = migrateDeprecatedTriggerItem(item)
 	}
 
+	var itemEventTypes []string
 	for _, migratedTriggerItem := range migratedTriggerItems {
-		itemEventType, err := triggerEventType(stringFromTriggerCondition(migratedTriggerItem.PushBranch),
-			stringFromTriggerCondition(migratedTriggerItem.PullRequestSourceBranch),
-			stringFromTriggerCondition(migratedTriggerItem.PullRequestTargetBranch),
-			stringFromTriggerCondition(migratedTriggerItem.Tag))
+		itemEventType, err := migratedTriggerItem.eventType()
 		if err != nil {
-			return false, err
+			return false, "", err
 		}
 
 		if paramsEventType != itemEventType {
+			itemEventTypes = append(itemEventTypes, string(itemEventType))
 			continue
 		}
 
 		switch itemEventType {
 		case TriggerEventTypeCodePush:
-			match := glob.Glob(stringFromTriggerCondition(migratedTriggerItem.PushBranch), pushBranch)
-			return match, nil
+			return evaluateTriggerConditions([]triggerCondition{
+				{field: "push_branch", condition: migratedTriggerItem.PushBranch, values: []string{params.PushBranch}},
+				{field: "commit_message", condition: migratedTriggerItem.CommitMessage, values: []string{params.CommitMessage}},
+				{field: "changed_files", condition: migratedTriggerItem.ChangedFiles, values: params.ChangedFiles},
+			})
 		case TriggerEventTypePullRequest:
-			sourceMatch := false
-			if stringFromTriggerCondition(migratedTriggerItem.PullRequestSourceBranch) == "" {
-				sourceMatch = true
-			} else {
-				sourceMatch = glob.Glob(stringFromTriggerCondition(migratedTriggerItem.PullRequestSourceBranch), prSourceBranch)
-			}
-
-			targetMatch := false
-			if stringFromTriggerCondition(migratedTriggerItem.PullRequestTargetBranch) == "" {
-				targetMatch = true
-			} else {
-				targetMatch = glob.Glob(stringFromTriggerCondition(migratedTriggerItem.PullRequestTargetBranch), prTargetBranch)
-			}
-
 			// When a PR is converted to ready for review:
 			// - if draft PR trigger is enabled, this event is just a status change on the PR
 			// 	 and the given status of the code base already triggered a build.
 			// - if draft PR trigger is disabled, the given status of the code base didn't trigger a build yet.
-			stateMismatch := false
 			if migratedTriggerItem.IsDraftPullRequestEnabled() {
-				if prReadyState == PullRequestReadyStateConvertedToReadyForReview {
-					stateMismatch = true
+				if params.PRReadyState == PullRequestReadyStateConvertedToReadyForReview {
+					return false, "draft pull requests already trigger builds, converting to ready for review doesn't", nil
 				}
 			} else {
-				if prReadyState == PullRequestReadyStateDraft {
-					stateMismatch = true
+				if params.PRReadyState == PullRequestReadyStateDraft {
+					return false, "draft pull requests are disabled (draft_pull_request_enabled: false)", nil
 				}
 			}
 
-			return sourceMatch && targetMatch && !stateMismatch, nil
+			return evaluateTriggerConditions([]triggerCondition{
+				{field: "pull_request_source_branch", condition: migratedTriggerItem.PullRequestSourceBranch, values: []string{params.PRSourceBranch}},
+				{field: "pull_request_target_branch", condition: migratedTriggerItem.PullRequestTargetBranch, values: []string{params.PRTargetBranch}},
+				{field: "commit_message", condition: migratedTriggerItem.CommitMessage, values: []string{params.CommitMessage}},
+				{field: "changed_files", condition: migratedTriggerItem.ChangedFiles, values: params.ChangedFiles},
+				{field: "pull_request_label", condition: migratedTriggerItem.PullRequestLabel, values: params.PRLabels},
+				{field: "pull_request_comment", condition: migratedTriggerItem.PullRequestComment, values: []string{params.PRComment}},
+			})
 		case TriggerEventTypeTag:
-			match := glob.Glob(stringFromTriggerCondition(migratedTriggerItem.Tag), tag)
-			return match, nil
+			return evaluateTriggerConditions([]triggerCondition{
+				{field: "tag", condition: migratedTriggerItem.Tag, values: []string{params.Tag}},
+			})
 		}
 	}
 
-	return false, nil
+	return false, fmt.Sprintf("%s trigger item, but the params describe a %s event", strings.Join(itemEventTypes, " and "), paramsEventType), nil
+}
+
+func (item TriggerMapItemModel) eventType() (TriggerEventType, error) {
+	switch item.getType() {
+	case CodePushType:
+		return TriggerEventTypeCodePush, nil
+	case PullRequestType:
+		return TriggerEventTypePullRequest, nil
+	case TagPushType:
+		return TriggerEventTypeTag, nil
+	default:
+		return triggerEventType(stringLiteralOrRegex(item.PushBranch),
+			stringLiteralOrRegex(item.PullRequestSourceBranch),
+			stringLiteralOrRegex(item.PullRequestTargetBranch),
+			stringLiteralOrRegex(item.Tag))
+	}
+}
+
+// IsEnabled returns false if the trigger item is turned off (enabled: false).
+func (item TriggerMapItemModel) IsEnabled() bool {
+	return item.Enabled == nil || *item.Enabled
 }
 
 func (item TriggerMapItemModel) IsDraftPullRequestEnabled() bool {
@@ -498,6 +548,104 @@ func (item TriggerMapItemModel) conditionsString() string {
 	return str
 }
 
+// shadows returns true if the trigger item matches every event the other trigger item matches.
+// The check is conservative, false is returned if the conditions can't be compared.
+func (item TriggerMapItemModel) shadows(other TriggerMapItemModel) bool {
+	if item.Pattern != "" || other.Pattern != "" {
+		return false
+	}
+
+	itemType := item.getType()
+	if itemType == "" || itemType != other.getType() {
+		return false
+	}
+
+	var conditions [][2]interface{}
+	switch itemType {
+	case CodePushType:
+		conditions = [][2]interface{}{
+			{item.PushBranch, other.PushBranch},
+			{item.CommitMessage, other.CommitMessage},
+			{item.ChangedFiles, other.ChangedFiles},
+		}
+	case PullRequestType:
+		if item.IsDraftPullRequestEnabled() != other.IsDraftPullRequestEnabled() {
+			return false
+		}
+		conditions = [][2]interface{}{
+			{item.PullRequestSourceBranch, other.PullRequestSourceBranch},
+			{item.PullRequestTargetBranch, other.PullRequestTargetBranch},
+			{item.CommitMessage, other.CommitMessage},
+			{item.ChangedFiles, other.ChangedFiles},
+			{item.PullRequestLabel, other.PullRequestLabel},
+			{item.PullRequestComment, other.PullRequestComment},
+		}
+	case TagPushType:
+		conditions = [][2]interface{}{
+			{item.Tag, other.Tag},
+		}
+	}
+
+	for _, condition := range conditions {
+		if !conditionCovers(condition[0], condition[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// conditionCovers returns true if every value matching the other condition also matches the condition.
+func conditionCovers(condition, other interface{}) bool {
+	if !isStringLiteralOrRegexSet(condition) || matchesEverything(condition) {
+		return true
+	}
+	if !isStringLiteralOrRegexSet(other) {
+		return false
+	}
+
+	pattern, isGlob := condition.(string)
+	otherPattern, isOtherGlob := other.(string)
+	switch {
+	case isGlob && isOtherGlob:
+		// The wildcards of the other pattern can only be matched by wildcards,
+		// so the pattern covers the other one if it matches the other pattern itself.
+		return glob.Glob(pattern, otherPattern)
+	case !isGlob && !isOtherGlob:
+		return stringLiteralOrRegex(condition) == stringLiteralOrRegex(other)
+	case !isGlob && !strings.Contains(otherPattern, "*"):
+		match, err := matchTriggerCondition(condition, otherPattern)
+		return err == nil && match
+	}
+	return false
+}
+
+func matchesEverything(condition interface{}) bool {
+	if pattern, ok := condition.(string); ok {
+		return pattern != "" && strings.Trim(pattern, "*") == ""
+	}
+	return sliceutil.IsStringInSlice(stringLiteralOrRegex(condition), []string{".*", "^.*", "^.*$"})
+}
+
+func valuesString(values []string) string {
+	var quoted []string
+	for _, value := range values {
+		if value != "" {
+			quoted = append(quoted, fmt.Sprintf("%q", value))
+		}
+	}
+	if len(quoted) == 0 {
+		return "an empty value"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func conditionString(condition interface{}) string {
+	if pattern, ok := condition.(string); ok {
+		return pattern
+	}
+	return "regex: " + stringLiteralOrRegex(condition)
+}
+
 func validateStringOrRegexType(idx int, field string, value interface{}) error {
 	if value == nil {
 		return nil
@@ -513,12 +661,17 @@ func validateStringOrRegexType(idx int, field string, value interface{}) error {
 			return fmt.Errorf("trigger item #%d: single 'regex' key is expected for regex condition in %s field", idx+1, field)
 		}
 
-		_, ok := valueMap["regex"]
+		regex, ok := valueMap["regex"]
 		if !ok {
 			return fmt.Errorf("trigger item #%d: 'regex' key is expected for regex condition in %s field", idx+1, field)
 		}
 
-		return nil
+		_, ok = regex.(string)
+		if !ok {
+			return fmt.Errorf("trigger item #%d: 'regex' key is expected to have a string value in %s field", idx+1, field)
+		}
+
+		return validateRegex(idx, field, value)
 	}
 
 	valueInterfaceMap, ok := value.(map[string]interface{})
@@ -537,7 +690,7 @@ func validateStringOrRegexType(idx int, field string, value interface{}) error {
 			return fmt.Errorf("trigger item #%d: 'regex' key is expected to have a string value in %s field", idx+1, field)
 		}
 
-		return nil
+		return validateRegex(idx, field, value)
 	}
 
 	valueStringMap, ok := value.(map[string]string)
@@ -551,17 +704,62 @@ func validateStringOrRegexType(idx int, field string, value interface{}) error {
 			return fmt.Errorf("trigger item #%d: 'regex' key is expected for regex condition in %s field", idx+1, field)
 		}
 
-		return nil
+		return validateRegex(idx, field, value)
 	}
 
 	return fmt.Errorf("trigger item #%d: string literal or regex value is expected for %s field", idx+1, field)
 }
 
-func stringFromTriggerCondition(value interface{}) string {
-	if value == nil {
-		return ""
+func validateRegex(idx int, field string, value interface{}) error {
+	if _, err := regexp.Compile(stringLiteralOrRegex(value)); err != nil {
+		return fmt.Errorf("trigger item #%d: invalid regex in %s field: %s", idx+1, field, err)
+	}
+	return nil
+}
+
+type triggerCondition struct {
+	field     string
+	condition interface{}
+	values    []string
+}
+
+// evaluateTriggerConditions returns true if every set condition matches any of its values,
+// otherwise it describes the first condition which doesn't match.
+func evaluateTriggerConditions(conditions []triggerCondition) (bool, string, error) {
+	for _, condition := range conditions {
+		if !isStringLiteralOrRegexSet(condition.condition) {
+			continue
+		}
+
+		match := false
+		for _, value := range condition.values {
+			var err error
+			match, err = matchTriggerCondition(condition.condition, value)
+			if err != nil {
+				return false, "", fmt.Errorf("%s: %w", condition.field, err)
+			}
+			if match {
+				break
+			}
+		}
+		if !match {
+			return false, fmt.Sprintf("%s condition (%s) doesn't match %s", condition.field, conditionString(condition.condition), valuesString(condition.values)), nil
+		}
+	}
+	return true, "all conditions match", nil
+}
+
+// matchTriggerCondition matches a string literal (glob pattern) or regex condition against the value.
+func matchTriggerCondition(condition interface{}, value string) (bool, error) {
+	if pattern, ok := condition.(string); ok {
+		return glob.Glob(pattern, value), nil
+	}
+
+	re, err := regexp.Compile(stringLiteralOrRegex(condition))
+	if err != nil {
+		return false, fmt.Errorf("invalid regex: %w", err)
 	}
-	return value.(string)
+	return re.MatchString(value), nil
 }
 
 func stringLiteralOrRegex(value interface{}) string {
@@ -338,6 +338,123 @@ func TestTriggerMapItemModel_MatchWithParams_TagParams(t *testing.T) {
 	}
 }
 
+func TestTriggerMapItemModel_Match(t *testing.T) {
+	tests := []struct {
+		name           string
+		triggerMapItem TriggerMapItemModel
+		params         TriggerParams
+		want           bool
+		wantErr        string
+	}{
+		{
+			name:           "push branch regex - MATCH",
+			triggerMapItem: TriggerMapItemModel{PushBranch: map[string]interface{}{"regex": "^release/[0-9.]+$"}, WorkflowID: "deploy"},
+			params:         TriggerParams{PushBranch: "release/1.2"},
+			want:           true,
+		},
+		{
+			name:           "push branch regex - NOT MATCH",
+			triggerMapItem: TriggerMapItemModel{PushBranch: map[string]interface{}{"regex": "^release/[0-9.]+$"}, WorkflowID: "deploy"},
+			params:         TriggerParams{PushBranch: "release/next"},
+			want:           false,
+		},
+		{
+			name:           "push type item without conditions - MATCH",
+			triggerMapItem: TriggerMapItemModel{Type: CodePushType, WorkflowID: "primary"},
+			params:         TriggerParams{PushBranch: "feature/login"},
+			want:           true,
+		},
+		{
+			name:           "commit message glob - MATCH",
+			triggerMapItem: TriggerMapItemModel{PushBranch: "main", CommitMessage: "*[deploy]*", WorkflowID: "deploy"},
+			params:         TriggerParams{PushBranch: "main", CommitMessage: "Bump version [deploy]"},
+			want:           true,
+		},
+		{
+			name:           "commit message regex - NOT MATCH",
+			triggerMapItem: TriggerMapItemModel{PushBranch: "main", CommitMessage: map[interface{}]interface{}{"regex": `\[deploy\]`}, WorkflowID: "deploy"},
+			params:         TriggerParams{PushBranch: "main", CommitMessage: "Bump version"},
+			want:           false,
+		},
+		{
+			name:           "commit message condition without commit message - NOT MATCH",
+			triggerMapItem: TriggerMapItemModel{PushBranch: "main", CommitMessage: map[string]string{"regex": "deploy"}, WorkflowID: "deploy"},
+			params:         TriggerParams{PushBranch: "main"},
+			want:           false,
+		},
+		{
+			name:           "changed files - MATCH (any of the files)",
+			triggerMapItem: TriggerMapItemModel{Type: CodePushType, ChangedFiles: map[string]interface{}{"regex": "^ios/"}, WorkflowID: "ios"},
+			params:         TriggerParams{PushBranch: "main", ChangedFiles: []string{"README.md", "ios/Podfile"}},
+			want:           true,
+		},
+		{
+			name:           "changed files - NOT MATCH",
+			triggerMapItem: TriggerMapItemModel{Type: CodePushType, ChangedFiles: "android/*", WorkflowID: "android"},
+			params:         TriggerParams{PushBranch: "main", ChangedFiles: []string{"README.md", "ios/Podfile"}},
+			want:           false,
+		},
+		{
+			name: "pull request label and comment - MATCH",
+			triggerMapItem: TriggerMapItemModel{
+				PullRequestTargetBranch: "main",
+				PullRequestLabel:        "ci:*",
+				PullRequestComment:      map[string]interface{}{"regex": "^/bitrise run"},
+				WorkflowID:              "primary",
+			},
+			params: TriggerParams{PRSourceBranch: "feature", PRTargetBranch: "main", PRLabels: []string{"bug", "ci:full"}, PRComment: "/bitrise run please"},
+			want:   true,
+		},
+		{
+			name:           "pull request label - NOT MATCH",
+			triggerMapItem: TriggerMapItemModel{Type: PullRequestType, PullRequestLabel: "ci:*", WorkflowID: "primary"},
+			params:         TriggerParams{PRSourceBranch: "feature", PRLabels: []string{"bug"}},
+			want:           false,
+		},
+		{
+			name:           "pull request comment - NOT MATCH",
+			triggerMapItem: TriggerMapItemModel{Type: PullRequestType, PullRequestComment: "/bitrise run", WorkflowID: "primary"},
+			params:         TriggerParams{PRSourceBranch: "feature", PRComment: "LGTM"},
+			want:           false,
+		},
+		{
+			name:           "pull request source branch regex - MATCH",
+			triggerMapItem: TriggerMapItemModel{PullRequestSourceBranch: map[string]interface{}{"regex": "^(feature|bugfix)/"}, WorkflowID: "primary"},
+			params:         TriggerParams{PRSourceBranch: "bugfix/crash", PRTargetBranch: "main"},
+			want:           true,
+		},
+		{
+			name:           "tag regex - MATCH",
+			triggerMapItem: TriggerMapItemModel{Tag: map[string]interface{}{"regex": `^v\d+\.\d+\.\d+$`}, WorkflowID: "deploy"},
+			params:         TriggerParams{Tag: "v1.10.0"},
+			want:           true,
+		},
+		{
+			name:           "tag regex - NOT MATCH",
+			triggerMapItem: TriggerMapItemModel{Tag: map[string]interface{}{"regex": `^v\d+\.\d+\.\d+$`}, WorkflowID: "deploy"},
+			params:         TriggerParams{Tag: "v1.10.0-beta"},
+			want:           false,
+		},
+		{
+			name:           "invalid regex",
+			triggerMapItem: TriggerMapItemModel{PushBranch: map[string]interface{}{"regex": "release/(.*"}, WorkflowID: "deploy"},
+			params:         TriggerParams{PushBranch: "release/1.0"},
+			wantErr:        "push_branch: invalid regex: error parsing regexp: missing closing ): `release/(.*`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.triggerMapItem.Match(tt.params)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestTriggerMapItemModel_String(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -589,6 +706,15 @@ func TestTriggerMapItemModel_Validate_CodePushItem(t *testing.T) {
 			workflows: []string{"primary"},
 			wantErr:   "trigger item #1: no type or relevant trigger condition defined",
 		},
+		{
+			name: "regex has to be valid",
+			triggerMapItem: TriggerMapItemModel{
+				PushBranch: map[string]interface{}{"regex": "release/(.*"},
+				WorkflowID: "primary",
+			},
+			workflows: []string{"primary"},
+			wantErr:   "trigger item #1: invalid regex in push_branch field: error parsing regexp: missing closing ): `release/(.*`",
+		},
 		{
 			name: "type is required, when no push_branch defined (commit_message)",
 			triggerMapItem: TriggerMapItemModel{
@@ -191,6 +191,42 @@ func TestTriggerMapModel_Validate(t *testing.T) {
 			workflows: []string{"ci", "release"},
 			wantErr:   "the 2. trigger item duplicates the 1. trigger item",
 		},
+		{
+			name: "Shadowed trigger items",
+			triggerMap: TriggerMapModel{
+				TriggerMapItemModel{PushBranch: "release/*", WorkflowID: "release"},
+				TriggerMapItemModel{PushBranch: "release/1.*", WorkflowID: "ci"},
+				TriggerMapItemModel{PushBranch: "release/1.0", CommitMessage: "*[skip]*", WorkflowID: "ci"},
+				TriggerMapItemModel{PushBranch: map[string]interface{}{"regex": "^feature/"}, WorkflowID: "ci"},
+				TriggerMapItemModel{PushBranch: "feature/login", WorkflowID: "release"},
+				TriggerMapItemModel{Type: PullRequestType, WorkflowID: "ci"},
+				TriggerMapItemModel{PullRequestTargetBranch: "main", PullRequestLabel: "ci:full", WorkflowID: "release"},
+				TriggerMapItemModel{PullRequestTargetBranch: "main", DraftPullRequestEnabled: pointers.NewBoolPtr(false), WorkflowID: "release"},
+				TriggerMapItemModel{Tag: "*", WorkflowID: "release"},
+				TriggerMapItemModel{Tag: map[string]interface{}{"regex": "^v"}, WorkflowID: "ci"},
+			},
+			workflows: []string{"ci", "release"},
+			wantWarnings: []string{
+				"trigger item #2: never matches, every event it matches is matched by the 1. trigger item",
+				"trigger item #3: never matches, every event it matches is matched by the 1. trigger item",
+				"trigger item #5: never matches, every event it matches is matched by the 4. trigger item",
+				"trigger item #7: never matches, every event it matches is matched by the 6. trigger item",
+				"trigger item #10: never matches, every event it matches is matched by the 9. trigger item",
+			},
+		},
+		{
+			name: "Items are not shadowed by narrower or disabled items",
+			triggerMap: TriggerMapModel{
+				TriggerMapItemModel{PushBranch: "main", Enabled: pointers.NewBoolPtr(false), WorkflowID: "release"},
+				TriggerMapItemModel{PushBranch: "main", CommitMessage: "*[deploy]*", WorkflowID: "release"},
+				TriggerMapItemModel{PushBranch: "ma*", WorkflowID: "ci"},
+				TriggerMapItemModel{PushBranch: "*-main", WorkflowID: "ci"},
+				TriggerMapItemModel{PushBranch: map[string]interface{}{"regex": "^release/"}, WorkflowID: "release"},
+				TriggerMapItemModel{PushBranch: "release/*", WorkflowID: "release"},
+			},
+			workflows:    []string{"ci", "release"},
+			wantWarnings: []string{"trigger item #1: disabled (enabled: false), it never triggers a build"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -372,3 +408,29 @@ func TestTriggerMapModel_FirstMatchingTarget_PRTriggerMapWithDraftPRDisabledAndE
 		})
 	}
 }
+
+func TestTriggerMapModel_Explain(t *testing.T) {
+	triggerMap := TriggerMapModel{
+		TriggerMapItemModel{PushBranch: "main", Enabled: pointers.NewBoolPtr(false), WorkflowID: "deploy"},
+		TriggerMapItemModel{Tag: "*", PipelineID: "release"},
+		TriggerMapItemModel{PushBranch: "main", CommitMessage: "*[deploy]*", WorkflowID: "deploy"},
+		TriggerMapItemModel{PushBranch: "main", ChangedFiles: map[string]interface{}{"regex": "^ios/"}, WorkflowID: "ios"},
+		TriggerMapItemModel{PushBranch: "*", WorkflowID: "ci"},
+		TriggerMapItemModel{PushBranch: "main", WorkflowID: "unreachable"},
+	}
+
+	evaluations, err := triggerMap.Explain(TriggerParams{PushBranch: "main", CommitMessage: "Fix crash", ChangedFiles: []string{"android/Fix.kt"}})
+	require.NoError(t, err)
+	require.Equal(t, []TriggerItemEvaluation{
+		{Item: 1, Conditions: "type: push & push_branch: main", WorkflowID: "deploy", Reason: "disabled (enabled: false)"},
+		{Item: 2, Conditions: "type: tag & tag: *", PipelineID: "release", Reason: "tag trigger item, but the params describe a code-push event"},
+		{Item: 3, Conditions: "type: push & push_branch: main & commit_message: *[deploy]*", WorkflowID: "deploy", Reason: `commit_message condition (*[deploy]*) doesn't match "Fix crash"`},
+		{Item: 4, Conditions: "type: push & push_branch: main & changed_files: map[regex:^ios/]", WorkflowID: "ios", Reason: `changed_files condition (regex: ^ios/) doesn't match "android/Fix.kt"`},
+		{Item: 5, Conditions: "type: push & push_branch: *", WorkflowID: "ci", Match: true, Reason: "all conditions match"},
+	}, evaluations)
+
+	pipelineID, workflowID, err := triggerMap.FirstMatchingTargetWithParams(TriggerParams{PushBranch: "main", CommitMessage: "Fix crash"})
+	require.NoError(t, err)
+	require.Equal(t, "", pipelineID)
+	require.Equal(t, "ci", workflowID)
+}
@@ -24,6 +24,8 @@ type StepExecutionPlan struct {
 	StepID string `json:"step_id"`
 
 	Step stepmanModels.StepModel `json:"-"`
+	// Retry is the retry policy of the step, nil if the step has none.
+	Retry *StepRetryModel `json:"-"`
 	// With (container) group
 	WithGroupUUID string   `json:"-"`
 	ContainerID   string   `json:"-"`
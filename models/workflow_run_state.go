@@ -0,0 +1,25 @@
+package models
+
+import (
+	envmanModels "github.com/bitrise-io/envman/models"
+)
+
+// StepRunStateModel is the persisted result of a step, which doesn't need to run again when a failed build is resumed.
+type StepRunStateModel struct {
+	WorkflowID string `json:"workflow_id"`
+	StepID     string `json:"step_id"`
+	// Results holds the step's original run results.
+	Results StepRunResultsModel `json:"results"`
+	// OutputEnvironments are the step outputs, added to the environments of the subsequent steps.
+	OutputEnvironments []envmanModels.EnvironmentItemModel `json:"output_environments,omitempty"`
+}
+
+// WorkflowRunStateModel is the persisted progress of a workflow run, used by `bitrise run --resume`.
+type WorkflowRunStateModel struct {
+	WorkflowID string          `json:"workflow_id"`
+	Plan       WorkflowRunPlan `json:"plan"`
+	// CompletedSteps are the steps finished before the first failed step, in execution order.
+	CompletedSteps []StepRunStateModel `json:"completed_steps,omitempty"`
+	// FailedStep is the step the build failed with, the resumed build starts with this step.
+	FailedStep *StepRunStateModel `json:"failed_step,omitempty"`
+}
@@ -0,0 +1,93 @@
+package report
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+// JSONReport is the JSON report of a workflow build, or the builds of a pipeline's workflows.
+type JSONReport struct {
+	Status    string               `json:"status"`
+	ExitCode  int                  `json:"exit_code"`
+	RunTime   int64                `json:"run_time_in_ms"`
+	Workflows []JSONWorkflowReport `json:"workflows"`
+}
+
+// JSONWorkflowReport ...
+type JSONWorkflowReport struct {
+	WorkflowID string           `json:"workflow_id"`
+	Status     string           `json:"status"`
+	ExitCode   int              `json:"exit_code"`
+	StartTime  time.Time        `json:"start_time"`
+	RunTime    int64            `json:"run_time_in_ms"`
+	Steps      []JSONStepReport `json:"steps"`
+}
+
+// JSONStepReport ...
+type JSONStepReport struct {
+	Title        string             `json:"title"`
+	ID           string             `json:"id"`
+	Version      string             `json:"version,omitempty"`
+	Status       string             `json:"status"`
+	StatusReason string             `json:"status_reason,omitempty"`
+	StartTime    time.Time          `json:"start_time"`
+	RunTime      int64              `json:"run_time_in_ms"`
+	ExitCode     int                `json:"exit_code"`
+	Error        string             `json:"error,omitempty"`
+	Errors       []models.StepError `json:"errors,omitempty"`
+	LogPath      string             `json:"log_path,omitempty"`
+}
+
+// NewJSONReport ...
+func NewJSONReport(builds []models.BuildRunResultsModel) JSONReport {
+	report := JSONReport{
+		Status:    models.BuildRunStatusSuccess,
+		Workflows: []JSONWorkflowReport{},
+	}
+
+	for _, build := range builds {
+		workflow := JSONWorkflowReport{
+			WorkflowID: build.WorkflowID,
+			Status:     build.BuildRunStatus(),
+			ExitCode:   build.ExitCode(),
+			StartTime:  build.StartTime,
+			RunTime:    buildRunTime(build).Milliseconds(),
+			Steps:      []JSONStepReport{},
+		}
+
+		for _, step := range build.OrderedResults() {
+			statusReason, stepErrors := step.StatusReasonAndErrors()
+			workflow.Steps = append(workflow.Steps, JSONStepReport{
+				Title:        stepTitle(step),
+				ID:           step.StepInfo.ID,
+				Version:      step.StepInfo.Version,
+				Status:       step.Status.String(),
+				StatusReason: statusReason,
+				StartTime:    step.StartTime,
+				RunTime:      step.RunTime.Milliseconds(),
+				ExitCode:     step.ExitCode,
+				Error:        step.ErrorStr,
+				Errors:       stepErrors,
+				LogPath:      step.LogPath,
+			})
+		}
+
+		report.RunTime += workflow.RunTime
+		if report.ExitCode == 0 {
+			report.ExitCode = workflow.ExitCode
+		}
+		if workflow.Status == models.BuildRunStatusAborted || report.Status == models.BuildRunStatusSuccess {
+			report.Status = workflow.Status
+		}
+		report.Workflows = append(report.Workflows, workflow)
+	}
+
+	return report
+}
+
+// Marshal ...
+func (r JSONReport) Marshal() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSONReport(t *testing.T) {
+	report := NewJSONReport(testBuilds())
+
+	require.Equal(t, models.BuildRunStatusFailed, report.Status)
+	require.Equal(t, 1, report.ExitCode)
+	require.Equal(t, int64(4500), report.RunTime)
+	require.Equal(t, 1, len(report.Workflows))
+
+	steps := report.Workflows[0].Steps
+	require.Equal(t, 3, len(steps))
+	require.Equal(t, "Git Clone", steps[0].Title)
+	require.Equal(t, "success", steps[0].Status)
+	require.Equal(t, JSONStepReport{
+		Title:    "Test",
+		ID:       "script",
+		Version:  "1.0.0",
+		Status:   "failed",
+		RunTime:  1500,
+		ExitCode: 2,
+		Error:    "tests failed",
+		Errors:   []models.StepError{{Code: 2, Message: "tests failed"}},
+	}, steps[1])
+	require.Equal(t, "skipped", steps[2].Status)
+	require.Contains(t, steps[2].StatusReason, "This Step was skipped")
+}
+
+func TestNewJSONReport_NoBuilds(t *testing.T) {
+	report := NewJSONReport(nil)
+	require.Equal(t, models.BuildRunStatusSuccess, report.Status)
+	require.Equal(t, []JSONWorkflowReport{}, report.Workflows)
+}
@@ -0,0 +1,154 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+// JUnitTestSuites is the JUnit XML report of the builds, every workflow is a test suite and every step is a test case.
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite ...
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase ...
+type JUnitTestCase struct {
+	Name       string          `xml:"name,attr"`
+	ClassName  string          `xml:"classname,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []JUnitProperty `xml:"properties>property,omitempty"`
+	Failure    *JUnitFailure   `xml:"failure,omitempty"`
+	Skipped    *JUnitSkipped   `xml:"skipped,omitempty"`
+	SystemErr  string          `xml:"system-err,omitempty"`
+}
+
+// JUnitProperty ...
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// JUnitFailure ...
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+// JUnitSkipped ...
+type JUnitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// NewJUnitReport ...
+func NewJUnitReport(builds []models.BuildRunResultsModel) JUnitTestSuites {
+	report := JUnitTestSuites{Name: "bitrise"}
+
+	var totalTime time.Duration
+	for _, build := range builds {
+		suite := JUnitTestSuite{
+			Name: build.WorkflowID,
+			Time: formatJUnitTime(buildRunTime(build)),
+		}
+		if !build.StartTime.IsZero() {
+			suite.Timestamp = build.StartTime.UTC().Format("2006-01-02T15:04:05")
+		}
+
+		for _, step := range build.OrderedResults() {
+			testCase := newJUnitTestCase(build.WorkflowID, step)
+			if testCase.Failure != nil {
+				suite.Failures++
+			}
+			if testCase.Skipped != nil {
+				suite.Skipped++
+			}
+			suite.Tests++
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		totalTime += buildRunTime(build)
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = formatJUnitTime(totalTime)
+
+	return report
+}
+
+func newJUnitTestCase(workflowID string, step models.StepRunResultsModel) JUnitTestCase {
+	testCase := JUnitTestCase{
+		Name:      stepTitle(step),
+		ClassName: workflowID,
+		Time:      formatJUnitTime(step.RunTime),
+		Properties: []JUnitProperty{
+			{Name: "step_id", Value: step.StepInfo.ID},
+			{Name: "status", Value: step.Status.String()},
+			{Name: "exit_code", Value: strconv.Itoa(step.ExitCode)},
+		},
+	}
+	if step.StepInfo.Version != "" {
+		testCase.Properties = append(testCase.Properties, JUnitProperty{Name: "version", Value: step.StepInfo.Version})
+	}
+	if step.LogPath != "" {
+		testCase.Properties = append(testCase.Properties, JUnitProperty{Name: "log_path", Value: step.LogPath})
+	}
+
+	statusReason, stepErrors := step.StatusReasonAndErrors()
+	var messages []string
+	for _, stepError := range stepErrors {
+		messages = append(messages, stepError.Message)
+	}
+	message := strings.Join(messages, "\n")
+
+	switch {
+	case isStepFailed(step.Status):
+		testCase.Failure = &JUnitFailure{
+			Message: message,
+			Type:    step.Status.String(),
+			Content: fmt.Sprintf("Exit code: %d\n%s", step.ExitCode, step.ErrorStr),
+		}
+	case isStepSkipped(step.Status):
+		testCase.Skipped = &JUnitSkipped{Message: statusReason}
+	case step.Status == models.StepRunStatusCodeFailedSkippable:
+		// The build continued, so the step is reported as passed with its error
+		testCase.SystemErr = strings.TrimSpace(statusReason + "\n" + message)
+	}
+
+	return testCase
+}
+
+// Marshal ...
+func (r JUnitTestSuites) Marshal() ([]byte, error) {
+	content, err := xml.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), content...), nil
+}
+
+func formatJUnitTime(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
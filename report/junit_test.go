@@ -0,0 +1,52 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJUnitReport(t *testing.T) {
+	report := NewJUnitReport(testBuilds())
+
+	require.Equal(t, 3, report.Tests)
+	require.Equal(t, 1, report.Failures)
+	require.Equal(t, 1, report.Skipped)
+	require.Equal(t, "4.500", report.Time)
+
+	suite := report.Suites[0]
+	require.Equal(t, "primary", suite.Name)
+	require.Equal(t, "2024-05-01T10:00:00", suite.Timestamp)
+
+	passed := suite.TestCases[0]
+	require.Equal(t, "Git Clone", passed.Name)
+	require.Equal(t, "primary", passed.ClassName)
+	require.Equal(t, "1.500", passed.Time)
+	require.Nil(t, passed.Failure)
+	require.Nil(t, passed.Skipped)
+
+	failed := suite.TestCases[1]
+	require.Equal(t, &JUnitFailure{Message: "tests failed", Type: "failed", Content: "Exit code: 2\ntests failed"}, failed.Failure)
+	require.Contains(t, failed.Properties, JUnitProperty{Name: "exit_code", Value: "2"})
+	require.Contains(t, failed.Properties, JUnitProperty{Name: "status", Value: "failed"})
+
+	skipped := suite.TestCases[2]
+	require.NotNil(t, skipped.Skipped)
+	require.Contains(t, skipped.Skipped.Message, "This Step was skipped")
+}
+
+func TestNewJUnitReport_FailedSkippable(t *testing.T) {
+	builds := []models.BuildRunResultsModel{{
+		WorkflowID:           "primary",
+		FailedSkippableSteps: []models.StepRunResultsModel{newStepResults(0, "script", "Lint", models.StepRunStatusCodeFailedSkippable, 1, "lint failed")},
+	}}
+
+	report := NewJUnitReport(builds)
+	require.Equal(t, 0, report.Failures)
+
+	testCase := report.Suites[0].TestCases[0]
+	require.Nil(t, testCase.Failure)
+	require.Equal(t, "This Step failed, but it was marked as \"is_skippable\", so the build continued.\nlint failed", testCase.SystemErr)
+	require.Equal(t, "", report.Suites[0].Timestamp)
+}
@@ -0,0 +1,92 @@
+// Package report exports the results of the builds in machine-readable formats.
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+const (
+	FormatJUnit = "junit"
+	FormatJSON  = "json"
+)
+
+// Target is a report to export, parsed from a `<format>=<path>` flag value.
+type Target struct {
+	Format string
+	Path   string
+}
+
+// ParseTarget parses a `<format>=<path>` report definition.
+func ParseTarget(value string) (Target, error) {
+	format, pth, found := strings.Cut(value, "=")
+	if !found || pth == "" {
+		return Target{}, fmt.Errorf("invalid report (%s): should be in <format>=<path> form", value)
+	}
+	if format != FormatJUnit && format != FormatJSON {
+		return Target{}, fmt.Errorf("invalid report format (%s): accepted values are %s and %s", format, FormatJUnit, FormatJSON)
+	}
+	return Target{Format: format, Path: pth}, nil
+}
+
+// Write exports the report of the builds to the target path.
+func Write(target Target, builds []models.BuildRunResultsModel) error {
+	var content []byte
+	var err error
+	switch target.Format {
+	case FormatJUnit:
+		content, err = NewJUnitReport(builds).Marshal()
+	case FormatJSON:
+		content, err = NewJSONReport(builds).Marshal()
+	default:
+		return fmt.Errorf("unknown report format: %s", target.Format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s report: %w", target.Format, err)
+	}
+
+	if dir := filepath.Dir(target.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(target.Path, content, 0644)
+}
+
+func stepTitle(step models.StepRunResultsModel) string {
+	if step.StepInfo.Step.Title != nil && *step.StepInfo.Step.Title != "" {
+		return *step.StepInfo.Step.Title
+	}
+	return step.StepInfo.ID
+}
+
+func buildRunTime(build models.BuildRunResultsModel) time.Duration {
+	var runTime time.Duration
+	for _, step := range build.OrderedResults() {
+		runTime += step.RunTime
+	}
+	return runTime
+}
+
+func isStepFailed(status models.StepRunStatus) bool {
+	switch status {
+	case models.StepRunStatusCodeFailed,
+		models.StepRunStatusCodePreparationFailed,
+		models.StepRunStatusAbortedWithCustomTimeout,
+		models.StepRunStatusAbortedWithNoOutputTimeout,
+		models.StepRunStatusAbortedWithRunTimeout,
+		models.StepRunStatusAborted:
+		return true
+	default:
+		return false
+	}
+}
+
+func isStepSkipped(status models.StepRunStatus) bool {
+	return status == models.StepRunStatusCodeSkipped || status == models.StepRunStatusCodeSkippedWithRunIf
+}
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+func newStepResults(idx int, id, title string, status models.StepRunStatus, exitCode int, errStr string) models.StepRunResultsModel {
+	return models.StepRunResultsModel{
+		StepInfo: stepmanModels.StepInfoModel{
+			ID:      id,
+			Version: "1.0.0",
+			Step:    stepmanModels.StepModel{Title: pointers.NewStringPtr(title)},
+		},
+		Status:   status,
+		Idx:      idx,
+		RunTime:  1500 * time.Millisecond,
+		ErrorStr: errStr,
+		ExitCode: exitCode,
+	}
+}
+
+func testBuilds() []models.BuildRunResultsModel {
+	return []models.BuildRunResultsModel{
+		{
+			WorkflowID:   "primary",
+			StartTime:    time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			SuccessSteps: []models.StepRunResultsModel{newStepResults(0, "git-clone", "Git Clone", models.StepRunStatusCodeSuccess, 0, "")},
+			FailedSteps:  []models.StepRunResultsModel{newStepResults(1, "script", "Test", models.StepRunStatusCodeFailed, 2, "tests failed")},
+			SkippedSteps: []models.StepRunResultsModel{newStepResults(2, "deploy-to-bitrise-io", "Deploy", models.StepRunStatusCodeSkipped, 0, "")},
+		},
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Target
+		wantErr string
+	}{
+		{value: "junit=reports/bitrise.xml", want: Target{Format: FormatJUnit, Path: "reports/bitrise.xml"}},
+		{value: "json=report.json", want: Target{Format: FormatJSON, Path: "report.json"}},
+		{value: "report.json", wantErr: "invalid report (report.json): should be in <format>=<path> form"},
+		{value: "json=", wantErr: "invalid report (json=): should be in <format>=<path> form"},
+		{value: "html=report.html", wantErr: "invalid report format (html): accepted values are junit and json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseTarget(tt.value)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPth := filepath.Join(dir, "reports", "bitrise.json")
+	require.NoError(t, Write(Target{Format: FormatJSON, Path: jsonPth}, testBuilds()))
+	content, err := os.ReadFile(jsonPth)
+	require.NoError(t, err)
+	var jsonReport JSONReport
+	require.NoError(t, json.Unmarshal(content, &jsonReport))
+	require.Equal(t, "failed", jsonReport.Status)
+
+	junitPth := filepath.Join(dir, "bitrise.xml")
+	require.NoError(t, Write(Target{Format: FormatJUnit, Path: junitPth}, testBuilds()))
+	content, err = os.ReadFile(junitPth)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `<testsuites name="bitrise" tests="3" failures="1" skipped="1" time="4.500">`)
+}
@@ -0,0 +1,219 @@
+// Package schema generates the JSON Schema of the bitrise.yml and validates configs against it.
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise/configmerge"
+	"github.com/bitrise-io/bitrise/models"
+	envmanModels "github.com/bitrise-io/envman/models"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+)
+
+const draft07 = "http://json-schema.org/draft-07/schema#"
+
+// Schema is the subset of JSON Schema (draft-07) used to describe the bitrise.yml.
+type Schema struct {
+	Schema      string   `json:"$schema,omitempty"`
+	Ref         string   `json:"$ref,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Format      string   `json:"format,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+
+	Properties        map[string]*Schema `json:"properties,omitempty"`
+	PatternProperties map[string]*Schema `json:"patternProperties,omitempty"`
+	// AdditionalProperties is either false or a *Schema.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+	Required             []string    `json:"required,omitempty"`
+	MinProperties        *int        `json:"minProperties,omitempty"`
+	MaxProperties        *int        `json:"maxProperties,omitempty"`
+
+	Items *Schema   `json:"items,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+
+	Definitions map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// Marshal ...
+func (s *Schema) Marshal() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// Generate returns the JSON Schema of the bitrise.yml, generated from models.BitriseDataModel.
+func Generate() *Schema {
+	g := generator{definitions: map[string]*Schema{}}
+
+	root := g.structSchema(reflect.TypeOf(models.BitriseDataModel{}))
+	root.Schema = draft07
+	root.Title = "bitrise.yml"
+	root.Required = []string{"format_version"}
+	// format_version: 11 is parsed as a number
+	root.Properties["format_version"] = &Schema{AnyOf: []*Schema{{Type: "string"}, {Type: "number"}}}
+	// Modular configs include other config modules
+	root.Properties["include"] = &Schema{Type: "array", Items: g.schema(reflect.TypeOf(configmerge.ConfigReference{}))}
+	root.Properties[configmerge.ParamsKey] = &Schema{Type: "object", AdditionalProperties: g.schema(reflect.TypeOf(configmerge.ConfigParam{}))}
+	root.Definitions = g.definitions
+
+	return root
+}
+
+const environmentOptionsKey = "opts"
+
+var (
+	stepListItemType     = reflect.TypeOf(models.StepListItemModel{})
+	stepListStepItemType = reflect.TypeOf(models.StepListStepItemModel{})
+	stepType             = reflect.TypeOf(stepmanModels.StepModel{})
+	stepRetryType        = reflect.TypeOf(models.StepRetryModel{})
+	environmentItemType  = reflect.TypeOf(envmanModels.EnvironmentItemModel{})
+	triggerMapItemType   = reflect.TypeOf(models.TriggerMapItemModel{})
+	triggerItemTypeType  = reflect.TypeOf(models.TriggerItemType(""))
+	timeType             = reflect.TypeOf(time.Time{})
+)
+
+type generator struct {
+	definitions map[string]*Schema
+}
+
+func (g generator) schema(t reflect.Type) *Schema {
+	switch t {
+	case stepListItemType:
+		return g.definition("StepListItem", g.stepListItemSchema)
+	case stepListStepItemType:
+		return g.definition("StepListStepItem", func() *Schema {
+			return singlePropertyObject(&Schema{AdditionalProperties: g.schema(stepType)})
+		})
+	case stepType:
+		return g.definition(stepType.Name(), g.stepSchema)
+	case environmentItemType:
+		return g.definition("EnvironmentItem", g.environmentItemSchema)
+	case triggerItemTypeType:
+		return &Schema{Type: "string", Enum: []string{string(models.CodePushType), string(models.PullRequestType), string(models.TagPushType)}}
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.Struct:
+		return g.definition(t.Name(), func() *Schema { return g.structSchema(t) })
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return &Schema{Type: "object"}
+		}
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Slice:
+		items := g.schema(t.Elem())
+		if t.Elem().Kind() == reflect.Map && items.Ref == "" {
+			// List items like the stages of a pipeline are single key maps
+			items = singlePropertyObject(items)
+		}
+		return &Schema{Type: "array", Items: items}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	}
+	return &Schema{}
+}
+
+// definition registers the schema in the definitions and returns a reference to it.
+func (g generator) definition(name string, create func() *Schema) *Schema {
+	if _, ok := g.definitions[name]; !ok {
+		// Register before creating the schema to support recursive types
+		g.definitions[name] = &Schema{}
+		*g.definitions[name] = *create()
+	}
+	return &Schema{Ref: "#/definitions/" + name}
+}
+
+func (g generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		if t == triggerMapItemType && field.Type.Kind() == reflect.Interface {
+			s.Properties[name] = g.definition("StringOrRegex", stringOrRegexSchema)
+			continue
+		}
+		s.Properties[name] = g.schema(field.Type)
+	}
+	return s
+}
+
+// stepListItemSchema describes a step, a with group or a step bundle reference.
+func (g generator) stepListItemSchema() *Schema {
+	return singlePropertyObject(&Schema{
+		Properties: map[string]*Schema{
+			models.StepListItemWithKey: g.schema(reflect.TypeOf(models.WithModel{})),
+		},
+		PatternProperties: map[string]*Schema{
+			"^" + models.StepListItemStepBundleKeyPrefix: g.schema(reflect.TypeOf(models.StepBundleListItemModel{})),
+		},
+		AdditionalProperties: g.schema(stepType),
+	})
+}
+
+// stepSchema describes a step of a step list, which can have a retry policy besides the step.yml properties.
+func (g generator) stepSchema() *Schema {
+	s := g.structSchema(stepType)
+	s.Properties["retry"] = g.schema(stepRetryType)
+	return s
+}
+
+// environmentItemSchema describes an env var, which is a single key-value pair with optional options.
+func (g generator) environmentItemSchema() *Schema {
+	minProperties, maxProperties := 1, 2
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			environmentOptionsKey: g.schema(reflect.TypeOf(envmanModels.EnvironmentItemOptionsModel{})),
+		},
+		AdditionalProperties: &Schema{AnyOf: []*Schema{{Type: "string"}, {Type: "number"}, {Type: "boolean"}, {Type: "null"}}},
+		MinProperties:        &minProperties,
+		MaxProperties:        &maxProperties,
+	}
+}
+
+func stringOrRegexSchema() *Schema {
+	return &Schema{
+		Description: "String literal (glob pattern) or regex",
+		AnyOf: []*Schema{
+			{Type: "string"},
+			{
+				Type:                 "object",
+				Properties:           map[string]*Schema{"regex": {Type: "string"}},
+				Required:             []string{"regex"},
+				AdditionalProperties: false,
+			},
+		},
+	}
+}
+
+func singlePropertyObject(s *Schema) *Schema {
+	one := 1
+	s.Type = "object"
+	s.MinProperties = &one
+	s.MaxProperties = &one
+	return s
+}
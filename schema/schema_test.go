@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	s := Generate()
+
+	require.Equal(t, draft07, s.Schema)
+	require.Equal(t, []string{"format_version"}, s.Required)
+	require.Equal(t, false, s.AdditionalProperties)
+	require.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/definitions/ConfigReference"}}, s.Properties["include"])
+	require.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Ref: "#/definitions/ConfigParam"}}, s.Properties["params"])
+	require.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, s.Definitions["ConfigReference"].Properties["with"])
+
+	workflow := s.Definitions["WorkflowModel"]
+	require.NotNil(t, workflow)
+	require.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/definitions/StepListItem"}}, workflow.Properties["steps"])
+	require.Equal(t, &Schema{Type: "integer"}, workflow.Properties["timeout"])
+
+	stepListItem := s.Definitions["StepListItem"]
+	require.Equal(t, &Schema{Ref: "#/definitions/WithModel"}, stepListItem.Properties["with"])
+	require.Equal(t, &Schema{Ref: "#/definitions/StepBundleListItemModel"}, stepListItem.PatternProperties["^bundle::"])
+	require.Equal(t, &Schema{Ref: "#/definitions/StepModel"}, stepListItem.AdditionalProperties)
+	require.Equal(t, 1, *stepListItem.MinProperties)
+	require.Equal(t, 1, *stepListItem.MaxProperties)
+	require.Equal(t, &Schema{Ref: "#/definitions/StepRetryModel"}, s.Definitions["StepModel"].Properties["retry"])
+	require.Equal(t, false, s.Definitions["StepModel"].AdditionalProperties)
+
+	pipeline := s.Definitions["PipelineModel"]
+	stages := pipeline.Properties["stages"]
+	require.Equal(t, "array", stages.Type)
+	require.Equal(t, &Schema{Ref: "#/definitions/StageModel"}, stages.Items.AdditionalProperties)
+
+	triggerItem := s.Definitions["TriggerMapItemModel"]
+	require.Equal(t, &Schema{Ref: "#/definitions/StringOrRegex"}, triggerItem.Properties["push_branch"])
+	require.Equal(t, []string{"push", "pull_request", "tag"}, triggerItem.Properties["type"].Enum)
+
+	for _, name := range []string{"Container", "DockerCredentials", "EnvironmentItem", "EnvironmentItemOptionsModel", "StageModel", "StepBundleModel"} {
+		require.Contains(t, s.Definitions, name)
+	}
+
+	content, err := s.Marshal()
+	require.NoError(t, err)
+	require.True(t, json.Valid(content))
+}
@@ -0,0 +1,281 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError is a schema violation with its position in the YAML document.
+type ValidationError struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+// Error ...
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// Validate validates the YAML content against the schema and returns the violations ordered by their position.
+// Like the bitrise.yml parser, it accepts any scalar for a string and null for any value.
+func Validate(s *Schema, content []byte) ([]ValidationError, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return []ValidationError{{Line: 1, Column: 1, Message: "empty document"}}, nil
+	}
+
+	v := validator{definitions: s.Definitions}
+	errs := v.validate(s, document.Content[0], "")
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+		return errs[i].Column < errs[j].Column
+	})
+	return errs, nil
+}
+
+// yaml.v3 follows YAML 1.2, but the bitrise.yml is parsed with YAML 1.1 booleans.
+var yaml11Booleans = map[string]bool{
+	"y": true, "yes": true, "on": true,
+	"n": true, "no": true, "off": true,
+}
+
+type validator struct {
+	definitions map[string]*Schema
+}
+
+func (v validator) resolve(s *Schema) *Schema {
+	for s.Ref != "" {
+		s = v.definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+	}
+	return s
+}
+
+func (v validator) validate(s *Schema, node *yaml.Node, path string) []ValidationError {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	s = v.resolve(s)
+
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+
+	if len(s.AnyOf) > 0 {
+		return v.validateAnyOf(s, node, path)
+	}
+
+	var errs []ValidationError
+	switch s.Type {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			return []ValidationError{newError(node, path, "expected %s, got %s", typeDescription(s.Type), nodeDescription(node))}
+		}
+		errs = v.validateObject(s, node, path)
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			return []ValidationError{newError(node, path, "expected %s, got %s", typeDescription(s.Type), nodeDescription(node))}
+		}
+		if s.Items != nil {
+			for i, item := range node.Content {
+				errs = append(errs, v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case "string", "boolean", "integer", "number", "null":
+		if !scalarMatches(s.Type, node) {
+			return []ValidationError{newError(node, path, "expected %s, got %s", typeDescription(s.Type), nodeDescription(node))}
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, node.Value) {
+			return []ValidationError{newError(node, path, "invalid value (%s), valid values are: %s", node.Value, strings.Join(s.Enum, ", "))}
+		}
+	}
+	return errs
+}
+
+// validateAnyOf reports the errors of the alternative matching the node's type, as that is most likely the intended one.
+func (v validator) validateAnyOf(s *Schema, node *yaml.Node, path string) []ValidationError {
+	var types []string
+	for _, alternative := range s.AnyOf {
+		alternative = v.resolve(alternative)
+		errs := v.validate(alternative, node, path)
+		if len(errs) == 0 {
+			return nil
+		}
+		if kindMatches(alternative.Type, node) {
+			return errs
+		}
+		types = append(types, typeDescription(alternative.Type))
+	}
+	return []ValidationError{newError(node, path, "expected %s, got %s", joinDescriptions(types), nodeDescription(node))}
+}
+
+func (v validator) validateObject(s *Schema, node *yaml.Node, path string) []ValidationError {
+	var errs []ValidationError
+	keys := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "<<" {
+			// YAML merge key
+			continue
+		}
+		keys[key.Value] = true
+
+		valuePath := joinPath(path, key.Value)
+		if property, ok := s.Properties[key.Value]; ok {
+			errs = append(errs, v.validate(property, value, valuePath)...)
+			continue
+		}
+		if property := matchingPatternProperty(s, key.Value); property != nil {
+			errs = append(errs, v.validate(property, value, valuePath)...)
+			continue
+		}
+		switch additional := s.AdditionalProperties.(type) {
+		case *Schema:
+			errs = append(errs, v.validate(additional, value, valuePath)...)
+		case bool:
+			if !additional {
+				errs = append(errs, newError(key, path, "unknown key (%s)", key.Value))
+			}
+		}
+	}
+
+	for _, required := range s.Required {
+		if !keys[required] {
+			errs = append(errs, newError(node, path, "missing required key (%s)", required))
+		}
+	}
+
+	count := len(keys)
+	switch {
+	case s.MinProperties != nil && s.MaxProperties != nil && *s.MinProperties == *s.MaxProperties && count != *s.MinProperties:
+		errs = append(errs, newError(node, path, "expected exactly %d key(s), got %d", *s.MinProperties, count))
+	case s.MinProperties != nil && count < *s.MinProperties:
+		errs = append(errs, newError(node, path, "expected at least %d key(s), got %d", *s.MinProperties, count))
+	case s.MaxProperties != nil && count > *s.MaxProperties:
+		errs = append(errs, newError(node, path, "expected at most %d key(s), got %d", *s.MaxProperties, count))
+	}
+
+	return errs
+}
+
+func matchingPatternProperty(s *Schema, key string) *Schema {
+	patterns := make([]string, 0, len(s.PatternProperties))
+	for pattern := range s.PatternProperties {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if matched, err := regexp.MatchString(pattern, key); err == nil && matched {
+			return s.PatternProperties[pattern]
+		}
+	}
+	return nil
+}
+
+func scalarMatches(schemaType string, node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+	switch schemaType {
+	case "string":
+		return true
+	case "boolean":
+		return node.Tag == "!!bool" || (node.Style == 0 && yaml11Booleans[strings.ToLower(node.Value)])
+	case "integer":
+		return node.Tag == "!!int"
+	case "number":
+		return node.Tag == "!!int" || node.Tag == "!!float"
+	}
+	return false
+}
+
+func kindMatches(schemaType string, node *yaml.Node) bool {
+	switch schemaType {
+	case "object":
+		return node.Kind == yaml.MappingNode
+	case "array":
+		return node.Kind == yaml.SequenceNode
+	case "":
+		return true
+	}
+	return scalarMatches(schemaType, node)
+}
+
+func typeDescription(schemaType string) string {
+	switch schemaType {
+	case "object":
+		return "a map"
+	case "array":
+		return "a list"
+	case "string":
+		return "a string"
+	case "boolean":
+		return "a boolean"
+	case "integer":
+		return "an integer"
+	case "number":
+		return "a number"
+	case "null":
+		return "null"
+	}
+	return "any value"
+}
+
+func nodeDescription(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a map"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	switch node.Tag {
+	case "!!bool":
+		return fmt.Sprintf("a boolean (%s)", node.Value)
+	case "!!int":
+		return fmt.Sprintf("an integer (%s)", node.Value)
+	case "!!float":
+		return fmt.Sprintf("a number (%s)", node.Value)
+	}
+	return fmt.Sprintf("a string (%s)", node.Value)
+}
+
+func joinDescriptions(descriptions []string) string {
+	if len(descriptions) < 2 {
+		return strings.Join(descriptions, "")
+	}
+	return strings.Join(descriptions[:len(descriptions)-1], ", ") + " or " + descriptions[len(descriptions)-1]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func newError(node *yaml.Node, path, format string, args ...interface{}) ValidationError {
+	return ValidationError{Line: node.Line, Column: node.Column, Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "Valid config",
+			config: `format_version: 17
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+app:
+  envs:
+  - PROJECT: app.xcodeproj
+    opts:
+      is_expand: no
+  - RETRIES: 3
+containers:
+  ruby:
+    image: ruby:3.2
+trigger_map:
+- push_branch:
+    regex: ^release/.*
+  pipeline: release
+- tag: "*"
+  workflow: deploy
+pipelines:
+  release:
+    stages:
+    - build: {}
+stages:
+  build:
+    workflows:
+    - deploy: {}
+workflows:
+  deploy:
+    envs:
+    - &empty EMPTY:
+    steps:
+    - script@1:
+        title: Deploy
+        is_always_run: true
+        retry:
+          attempts: 3
+          backoff: 10
+          statuses: [aborted_with_no_output]
+        inputs:
+        - content: echo "deploy"
+    - with:
+        container: ruby
+        steps:
+        - git-clone:
+            retry:
+              attempts: 2
+              exit_codes: [128]
+    - bundle::setup:
+        envs:
+        - CACHE: "true"
+step_bundles:
+  setup:
+    steps:
+    - activate-ssh-key: {}
+`,
+		},
+		{
+			name: "Invalid config",
+			config: `format_version: "17"
+trigger_map:
+- type: merge
+  tag: [v1]
+workflows:
+  primary:
+    timeout: 1h
+    steps:
+    - script@1:
+        titel: Test
+        inputs: echo
+    - bundle::setup:
+        envs: A
+    - git-clone: {}
+      cache-pull: {}
+    - script@1:
+        retry:
+          attempts: many
+    envs:
+    - A: [b]
+`,
+			want: []string{
+				"line 3, column 9: trigger_map[0].type: invalid value (merge), valid values are: push, pull_request, tag",
+				"line 4, column 8: trigger_map[0].tag: expected a string or a map, got a list",
+				"line 7, column 14: workflows.primary.timeout: expected an integer, got a string (1h)",
+				"line 10, column 9: workflows.primary.steps[0].script@1: unknown key (titel)",
+				"line 11, column 17: workflows.primary.steps[0].script@1.inputs: expected a list, got a string (echo)",
+				"line 13, column 15: workflows.primary.steps[1].bundle::setup.envs: expected a list, got a string (A)",
+				"line 14, column 7: workflows.primary.steps[2]: expected exactly 1 key(s), got 2",
+				"line 18, column 21: workflows.primary.steps[3].script@1.retry.attempts: expected an integer, got a string (many)",
+				"line 20, column 10: workflows.primary.envs[0].A: expected a string, a number, a boolean or null, got a list",
+			},
+		},
+		{
+			name:   "Missing format version",
+			config: `workflows: {}`,
+			want:   []string{"line 1, column 1: missing required key (format_version)"},
+		},
+		{
+			name:    "Invalid YAML",
+			config:  "workflows: [",
+			wantErr: "yaml: line 1: did not find expected node content",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := Validate(Generate(), []byte(tt.config))
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var got []string
+			for _, e := range errs {
+				got = append(got, e.Error())
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/bitrise-io/bitrise/stepruncmd/timeoutcmd"
@@ -30,6 +31,11 @@ func New(name string, args []string, workDir string, envs, secrets []string, tim
 	return Cmd{cmd: cmd, stdout: outWriter, logger: logger}
 }
 
+// SetCancel aborts the command when the cancel channel is closed, see timeoutcmd.Command.SetCancel.
+func (c *Cmd) SetCancel(cancel <-chan struct{}, gracePeriod time.Duration) {
+	c.cmd.SetCancel(cancel, gracePeriod)
+}
+
 func (c *Cmd) Run() (int, error) {
 	cmdErr := c.cmd.Start()
 
@@ -37,6 +43,8 @@ func (c *Cmd) Run() (int, error) {
 		c.logger.Warnf("Failed to close command output writer: %s", err)
 	}
 
+	c.reportRunningProcesses()
+
 	if cmdErr == nil {
 		return 0, nil
 	}
@@ -56,3 +64,21 @@ func (c *Cmd) Run() (int, error) {
 
 	return exitCode, exitErr
 }
+
+// reportRunningProcesses warns about the processes started by the step which are still running after the step finished.
+func (c *Cmd) reportRunningProcesses() {
+	processes, err := c.cmd.RunningProcesses()
+	if err != nil {
+		c.logger.Debugf("Failed to check the processes left running by the step: %s", err)
+		return
+	}
+	if len(processes) == 0 {
+		return
+	}
+
+	var names []string
+	for _, process := range processes {
+		names = append(names, process.String())
+	}
+	c.logger.Warnf("The step left %d process(es) running: %s", len(processes), strings.Join(names, ", "))
+}
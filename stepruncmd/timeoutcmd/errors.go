@@ -32,3 +32,14 @@ func NewNoOutputTimeout(timeout time.Duration) NoOutputTimeoutError {
 func (e NoOutputTimeoutError) Error() string {
 	return fmt.Sprintf("timed out, as no output was received for %s", e.Timeout)
 }
+
+// AbortedError is returned if the command was terminated by the build cancellation.
+type AbortedError struct{}
+
+func NewAbortedError() AbortedError {
+	return AbortedError{}
+}
+
+func (e AbortedError) Error() string {
+	return "aborted, as the build was cancelled"
+}
@@ -0,0 +1,77 @@
+package timeoutcmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Process is a process of the command's process group.
+type Process struct {
+	PID     int
+	Command string
+}
+
+func (p Process) String() string {
+	return fmt.Sprintf("%s (pid: %d)", p.Command, p.PID)
+}
+
+// signalProcessGroup sends the signal to every process of the group, a group without processes is not an error.
+func signalProcessGroup(pgid int, sig syscall.Signal) error {
+	if err := syscall.Kill(-pgid, sig); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+// RunningProcesses returns the processes of the command's process group which are still running,
+// after the command finished.
+func (c *Command) RunningProcesses() ([]Process, error) {
+	if c.cmd.Process == nil {
+		return nil, nil
+	}
+
+	pgid := c.cmd.Process.Pid
+	if err := syscall.Kill(-pgid, 0); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	out, err := exec.Command("ps", "-A", "-o", "pid=,pgid=,stat=,comm=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	return parseProcessGroup(string(out), pgid), nil
+}
+
+func parseProcessGroup(psOutput string, pgid int) []Process {
+	var processes []Process
+	for _, line := range strings.Split(psOutput, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		processGroupID, err := strconv.Atoi(fields[1])
+		if err != nil || processGroupID != pgid {
+			continue
+		}
+		// Exited processes, which are not reaped yet
+		if strings.HasPrefix(fields[2], "Z") {
+			continue
+		}
+
+		processes = append(processes, Process{PID: pid, Command: strings.Join(fields[3:], " ")})
+	}
+	return processes
+}
@@ -2,28 +2,38 @@ package timeoutcmd
 
 import (
 	"io"
+	"os"
 	"os/exec"
 	"syscall"
 	"time"
 
 	"github.com/bitrise-io/bitrise/log"
 	"github.com/bitrise-io/bitrise/stepruncmd/hangdetector"
+	"golang.org/x/term"
 )
 
+// defaultGracePeriod is the time the command has to exit after a SIGTERM, before it gets killed.
+const defaultGracePeriod = 10 * time.Second
+
 // Command controls the command run.
+// The command is started in its own process group, so that the whole process tree can be terminated.
 type Command struct {
 	cmd          *exec.Cmd
 	timeout      time.Duration
 	hangTimeout  time.Duration
 	hangDetector hangdetector.HangDetector
+	cancel       <-chan struct{}
+	gracePeriod  time.Duration
 }
 
 // New creates a command model.
 func New(dir, name string, args ...string) Command {
 	c := Command{
-		cmd: exec.Command(name, args...),
+		cmd:         exec.Command(name, args...),
+		gracePeriod: defaultGracePeriod,
 	}
 	c.cmd.Dir = dir
+	c.cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
 	return c
 }
@@ -41,13 +51,26 @@ func (c *Command) SetHangTimeout(timeout time.Duration) {
 	}
 }
 
+// SetCancel sets the channel which aborts the command when closed.
+// The gracePeriod overrides the time the command has to exit after a SIGTERM, before it gets killed.
+func (c *Command) SetCancel(cancel <-chan struct{}, gracePeriod time.Duration) {
+	c.cancel = cancel
+	c.gracePeriod = gracePeriod
+}
+
 // SetEnv sets the command's env list.
 func (c *Command) SetEnv(env []string) {
 	c.cmd.Env = env
 }
 
 // SetStandardIO sets the input and outputs of the command.
+// The command's process group is not the terminal's foreground group, and reading the terminal would stop it (SIGTTIN),
+// so a terminal input is replaced by the null device.
 func (c *Command) SetStandardIO(in io.Reader, out, err io.Writer) {
+	if isTerminal(in) {
+		in = nil
+	}
+
 	if c.hangDetector == nil {
 		c.cmd.Stdin, c.cmd.Stdout, c.cmd.Stderr = in, out, err
 		return
@@ -95,22 +118,46 @@ func (c *Command) Start() error {
 	// exiting the method for the two supported cases: finish/error or timeout
 	select {
 	case <-timeoutChan:
-		if err := c.cmd.Process.Kill(); err != nil {
-			log.Warnf("Failed to kill process: %s", err)
-		}
+		c.terminate(done)
 
 		return NewTimeoutError(c.timeout)
 	case <-hanged:
-		if err := c.cmd.Process.Kill(); err != nil {
-			log.Warnf("Failed to kill process: %s", err)
-		}
+		c.terminate(done)
 
 		return NewNoOutputTimeout(c.hangTimeout)
+	case <-c.cancel:
+		c.terminate(done)
+
+		return NewAbortedError()
 	case err := <-done:
 		return err
 	}
 }
 
+// terminate sends a SIGTERM to the process group, and kills the remaining processes of the group
+// when the process exits or the grace period elapses.
+func (c *Command) terminate(done <-chan error) {
+	pgid := c.cmd.Process.Pid
+	if err := signalProcessGroup(pgid, syscall.SIGTERM); err != nil {
+		log.Warnf("Failed to terminate process group: %s", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(c.gracePeriod):
+		log.Warnf("Process did not exit in %s, killing it", c.gracePeriod)
+	}
+
+	if err := signalProcessGroup(pgid, syscall.SIGKILL); err != nil {
+		log.Warnf("Failed to kill process group: %s", err)
+	}
+}
+
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
 // ExitStatus returns the error's exit status
 // if the error is an exec.ExitError
 // if the error is nil it return 0
@@ -0,0 +1,43 @@
+package timeoutcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestCommand_ReadsTerminalStdin(t *testing.T) {
+	terminal := openTerminal(t)
+
+	dir := t.TempDir()
+	cmd := New(dir, "bash", "-c", "cat > stdin.txt")
+	cmd.SetTimeout(10 * time.Second)
+	cmd.SetStandardIO(terminal, os.Stdout, os.Stderr)
+
+	require.NoError(t, cmd.Start())
+	content, err := os.ReadFile(filepath.Join(dir, "stdin.txt"))
+	require.NoError(t, err)
+	require.Empty(t, content)
+}
+
+// openTerminal opens the terminal side of a new pseudoterminal.
+func openTerminal(t *testing.T) *os.File {
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, ptmx.Close()) })
+
+	require.NoError(t, unix.IoctlSetPointerInt(int(ptmx.Fd()), unix.TIOCSPTLCK, 0))
+	ptyNumber, err := unix.IoctlGetInt(int(ptmx.Fd()), unix.TIOCGPTN)
+	require.NoError(t, err)
+
+	terminal, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", ptyNumber), os.O_RDWR|unix.O_NOCTTY, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, terminal.Close()) })
+
+	return terminal
+}
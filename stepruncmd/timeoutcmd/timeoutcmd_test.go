@@ -0,0 +1,106 @@
+package timeoutcmd
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand_Cancel(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		gracePeriod time.Duration
+		maxRunTime  time.Duration
+	}{
+		{
+			name:        "Command exits on SIGTERM",
+			script:      `sleep 30`,
+			gracePeriod: 30 * time.Second,
+			maxRunTime:  10 * time.Second,
+		},
+		{
+			name:        "Command ignoring SIGTERM is killed after the grace period",
+			script:      `trap "" TERM; while true; do sleep 0.1; done`,
+			gracePeriod: 500 * time.Millisecond,
+			maxRunTime:  10 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cancel := make(chan struct{})
+			cmd := New("", "bash", "-c", tt.script)
+			cmd.SetCancel(cancel, tt.gracePeriod)
+
+			time.AfterFunc(200*time.Millisecond, func() { close(cancel) })
+
+			startTime := time.Now()
+			err := cmd.Start()
+			require.Equal(t, NewAbortedError(), err)
+			require.Less(t, time.Since(startTime), tt.maxRunTime)
+		})
+	}
+}
+
+func TestCommand_NotCancelled(t *testing.T) {
+	cmd := New("", "bash", "-c", "exit 0")
+	cmd.SetCancel(make(chan struct{}), time.Second)
+	require.NoError(t, cmd.Start())
+}
+
+func TestCommand_TimeoutTerminatesProcessGroup(t *testing.T) {
+	cmd := New("", "bash", "-c", `sleep 30 & sleep 30 & wait`)
+	cmd.SetTimeout(500 * time.Millisecond)
+
+	err := cmd.Start()
+	require.Equal(t, NewTimeoutError(500*time.Millisecond), err)
+
+	processes, err := cmd.RunningProcesses()
+	require.NoError(t, err)
+	require.Empty(t, processes)
+}
+
+func TestCommand_RunningProcesses(t *testing.T) {
+	cmd := New("", "bash", "-c", `sleep 30 &`)
+	require.NoError(t, cmd.Start())
+	defer func() {
+		require.NoError(t, signalProcessGroup(cmd.cmd.Process.Pid, syscall.SIGKILL))
+	}()
+
+	processes, err := cmd.RunningProcesses()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(processes))
+	require.Equal(t, "sleep", processes[0].Command)
+}
+
+func TestCommand_ReadsStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	_, err = w.WriteString("input")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	dir := t.TempDir()
+	cmd := New(dir, "bash", "-c", "cat > stdin.txt")
+	cmd.SetStandardIO(r, os.Stdout, os.Stderr)
+
+	require.NoError(t, cmd.Start())
+	content, err := os.ReadFile(filepath.Join(dir, "stdin.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "input", string(content))
+}
+
+func TestParseProcessGroup(t *testing.T) {
+	psOutput := `    1     1 Ss   init
+  120   118 S    bash
+  121   118 Sl   gradle daemon
+  122   118 Z    sleep
+  130   130 S    sleep
+`
+	require.Equal(t, []Process{{PID: 120, Command: "bash"}, {PID: 121, Command: "gradle daemon"}}, parseProcessGroup(psOutput, 118))
+	require.Nil(t, parseProcessGroup(psOutput, 200))
+}
@@ -0,0 +1,190 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/bitrise/log"
+	"github.com/bitrise-io/bitrise/version"
+)
+
+const (
+	tracesPath    = "/v1/traces"
+	serviceName   = "bitrise"
+	exportTimeout = 10 * time.Second
+
+	spanKindInternal = 1
+	statusCodeOK     = 1
+	statusCodeError  = 2
+)
+
+// Exporter sends the spans to an OpenTelemetry collector over OTLP/HTTP, JSON encoded.
+type Exporter struct {
+	url    string
+	client *http.Client
+}
+
+// NewExporter creates an exporter for the given collector endpoint (like http://localhost:4318),
+// the spans are sent to its /v1/traces path.
+func NewExporter(endpoint string) Exporter {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, tracesPath) {
+		url += tracesPath
+	}
+	return Exporter{url: url, client: &http.Client{Timeout: exportTimeout}}
+}
+
+// Export sends the spans to the collector.
+func (e Exporter) Export(spans []Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(newExportTraceServiceRequest(spans))
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send spans to %s: %w", e.url, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Debugf("Failed to close response body: %s", err)
+		}
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to send spans to %s: status code %d: %s", e.url, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// ExportTraceServiceRequest is the JSON encoded OTLP trace export request.
+type ExportTraceServiceRequest struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+// ResourceSpans ...
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+// Resource ...
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+// ScopeSpans ...
+type ScopeSpans struct {
+	Scope Scope      `json:"scope"`
+	Spans []OTLPSpan `json:"spans"`
+}
+
+// Scope ...
+type Scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// OTLPSpan ...
+type OTLPSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []KeyValue `json:"attributes,omitempty"`
+	Status            Status     `json:"status"`
+}
+
+// Status ...
+type Status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// KeyValue ...
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue ...
+type AnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func newExportTraceServiceRequest(spans []Span) ExportTraceServiceRequest {
+	otlpSpans := make([]OTLPSpan, 0, len(spans))
+	for _, span := range spans {
+		status := Status{Code: statusCodeOK}
+		if span.Failed {
+			status = Status{Code: statusCodeError, Message: span.ErrorMessage}
+		}
+
+		otlpSpans = append(otlpSpans, OTLPSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentSpanID,
+			Name:              span.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+			Attributes:        newKeyValues(span.Attributes),
+			Status:            status,
+		})
+	}
+
+	return ExportTraceServiceRequest{
+		ResourceSpans: []ResourceSpans{{
+			Resource: Resource{Attributes: newKeyValues(map[string]interface{}{
+				"service.name":    serviceName,
+				"service.version": version.VERSION,
+			})},
+			ScopeSpans: []ScopeSpans{{
+				Scope: Scope{Name: serviceName, Version: version.VERSION},
+				Spans: otlpSpans,
+			}},
+		}},
+	}
+}
+
+func newKeyValues(attributes map[string]interface{}) []KeyValue {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	keyValues := make([]KeyValue, 0, len(keys))
+	for _, key := range keys {
+		var value AnyValue
+		switch v := attributes[key].(type) {
+		case int:
+			s := strconv.Itoa(v)
+			value.IntValue = &s
+		case bool:
+			value.BoolValue = &v
+		default:
+			s := fmt.Sprint(v)
+			value.StringValue = &s
+		}
+		keyValues = append(keyValues, KeyValue{Key: key, Value: value})
+	}
+	return keyValues
+}
@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExporter(t *testing.T) {
+	require.Equal(t, "http://localhost:4318/v1/traces", NewExporter("http://localhost:4318").url)
+	require.Equal(t, "http://localhost:4318/v1/traces", NewExporter("http://localhost:4318/").url)
+	require.Equal(t, "http://localhost:4318/v1/traces", NewExporter("http://localhost:4318/v1/traces").url)
+}
+
+func TestExporter_Export(t *testing.T) {
+	var requests []ExportTraceServiceRequest
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/v1/traces", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var request ExportTraceServiceRequest
+		require.NoError(t, json.Unmarshal(body, &request))
+		requests = append(requests, request)
+	}))
+	defer receiver.Close()
+
+	startTime := time.Unix(1700000000, 0)
+	spans := []Span{
+		{
+			TraceID:    "1f36e5a65c3a4b0a9a532a8d3c3c6e01",
+			SpanID:     "1f36e5a65c3a4b0a",
+			Name:       "bitrise run primary",
+			StartTime:  startTime,
+			EndTime:    startTime.Add(time.Second),
+			Attributes: map[string]interface{}{BuildStatusAttribute: "success"},
+		},
+		{
+			TraceID:      "1f36e5a65c3a4b0a9a532a8d3c3c6e01",
+			SpanID:       "c3b9f1a42d6e4b8f",
+			ParentSpanID: "1f36e5a65c3a4b0a",
+			Name:         "script",
+			StartTime:    startTime,
+			EndTime:      startTime.Add(time.Second),
+			Attributes:   map[string]interface{}{StepIDAttribute: "script", StepExitCodeAttribute: 2},
+			Failed:       true,
+			ErrorMessage: "exit status 2",
+		},
+	}
+
+	require.NoError(t, NewExporter(receiver.URL).Export(spans))
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].ResourceSpans, 1)
+	require.Len(t, requests[0].ResourceSpans[0].ScopeSpans, 1)
+
+	otlpSpans := requests[0].ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, otlpSpans, 2)
+
+	require.Equal(t, "1700000000000000000", otlpSpans[0].StartTimeUnixNano)
+	require.Equal(t, "1700000001000000000", otlpSpans[0].EndTimeUnixNano)
+	require.Equal(t, "", otlpSpans[0].ParentSpanID)
+	require.Equal(t, Status{Code: statusCodeOK}, otlpSpans[0].Status)
+
+	require.Equal(t, "1f36e5a65c3a4b0a", otlpSpans[1].ParentSpanID)
+	require.Equal(t, Status{Code: statusCodeError, Message: "exit status 2"}, otlpSpans[1].Status)
+	require.Equal(t, "bitrise.step.exit_code", otlpSpans[1].Attributes[0].Key)
+	require.Equal(t, "2", *otlpSpans[1].Attributes[0].Value.IntValue)
+	require.Equal(t, "bitrise.step.id", otlpSpans[1].Attributes[1].Key)
+	require.Equal(t, "script", *otlpSpans[1].Attributes[1].Value.StringValue)
+
+	t.Log("nothing is sent without spans")
+	require.NoError(t, NewExporter(receiver.URL).Export(nil))
+	require.Len(t, requests, 1)
+}
+
+func TestExporter_Export_Error(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+	}))
+	defer receiver.Close()
+
+	err := NewExporter(receiver.URL).Export([]Span{{Name: "build"}})
+	require.EqualError(t, err, "failed to send spans to "+receiver.URL+"/v1/traces: status code 400: invalid request")
+}
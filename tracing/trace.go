@@ -0,0 +1,227 @@
+// Package tracing exports the timings of the builds as OpenTelemetry traces.
+package tracing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	BuildExecutionIDAttribute    = "bitrise.build_execution_id"
+	WorkflowExecutionIDAttribute = "bitrise.workflow_execution_id"
+	StepExecutionIDAttribute     = "bitrise.step_execution_id"
+	WorkflowIDAttribute          = "bitrise.workflow.id"
+	WorkflowTitleAttribute       = "bitrise.workflow.title"
+	BuildStatusAttribute         = "bitrise.build.status"
+	StepIDAttribute              = "bitrise.step.id"
+	StepTitleAttribute           = "bitrise.step.title"
+	StepVersionAttribute         = "bitrise.step.version"
+	StepStatusAttribute          = "bitrise.step.status"
+	StepExitCodeAttribute        = "bitrise.step.exit_code"
+	StepTimeoutAttribute         = "bitrise.step.timeout"
+	StepNoOutputTimeoutAttribute = "bitrise.step.no_output_timeout"
+)
+
+// Span is a finished operation of the build.
+type Span struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   map[string]interface{}
+	Failed       bool
+	ErrorMessage string
+}
+
+// BuildTrace collects the spans of a build: the root span of the build,
+// a child span for every workflow execution plan and a grandchild span for every step.
+// The trace and span IDs are derived from the build, workflow and step execution IDs.
+// A nil BuildTrace is valid and records nothing.
+type BuildTrace struct {
+	mu        sync.Mutex
+	root      Span
+	workflows map[string]*Span
+	spans     []Span
+}
+
+// NewBuildTrace starts the trace of the build identified by buildExecutionID.
+func NewBuildTrace(buildExecutionID, workflowID string, startTime time.Time) *BuildTrace {
+	traceID := traceIDFromExecutionID(buildExecutionID)
+	return &BuildTrace{
+		root: Span{
+			TraceID:   traceID,
+			SpanID:    spanIDFromExecutionID(buildExecutionID),
+			Name:      "bitrise run " + workflowID,
+			StartTime: startTime,
+			Attributes: map[string]interface{}{
+				BuildExecutionIDAttribute: buildExecutionID,
+				WorkflowIDAttribute:       workflowID,
+			},
+		},
+		workflows: map[string]*Span{},
+	}
+}
+
+// StartWorkflow starts the span of a workflow execution plan.
+func (t *BuildTrace) StartWorkflow(plan models.WorkflowExecutionPlan, startTime time.Time) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.workflows[plan.UUID] = &Span{
+		TraceID:      t.root.TraceID,
+		SpanID:       spanIDFromExecutionID(plan.UUID),
+		ParentSpanID: t.root.SpanID,
+		Name:         plan.WorkflowID,
+		StartTime:    startTime,
+		Attributes: map[string]interface{}{
+			WorkflowExecutionIDAttribute: plan.UUID,
+			WorkflowIDAttribute:          plan.WorkflowID,
+			WorkflowTitleAttribute:       plan.WorkflowTitle,
+		},
+	}
+}
+
+// FinishWorkflow finishes the span of a workflow execution plan.
+func (t *BuildTrace) FinishWorkflow(workflowExecutionID string, endTime time.Time, failed bool) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span, ok := t.workflows[workflowExecutionID]
+	if !ok {
+		return
+	}
+	delete(t.workflows, workflowExecutionID)
+
+	span.EndTime = endTime
+	span.Failed = failed
+	t.spans = append(t.spans, *span)
+}
+
+// AddStep records the span of a finished step of the given workflow execution plan.
+func (t *BuildTrace) AddStep(workflowExecutionID, stepExecutionID string, result models.StepRunResultsModel) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parentSpanID := t.root.SpanID
+	if workflow, ok := t.workflows[workflowExecutionID]; ok {
+		parentSpanID = workflow.SpanID
+	}
+
+	name := result.StepInfo.ID
+	attributes := map[string]interface{}{
+		StepExecutionIDAttribute: stepExecutionID,
+		StepIDAttribute:          result.StepInfo.ID,
+		StepStatusAttribute:      result.Status.String(),
+		StepExitCodeAttribute:    result.ExitCode,
+	}
+	if title := result.StepInfo.Step.Title; title != nil && *title != "" {
+		name = *title
+		attributes[StepTitleAttribute] = *title
+	}
+	if result.StepInfo.Version != "" {
+		attributes[StepVersionAttribute] = result.StepInfo.Version
+	}
+	if timeout := stepTimeoutSeconds(result.Timeout, result.StepInfo.Step.Timeout); timeout > 0 {
+		attributes[StepTimeoutAttribute] = timeout
+	}
+	if timeout := stepTimeoutSeconds(result.NoOutputTimeout, result.StepInfo.Step.NoOutputTimeout); timeout > 0 {
+		attributes[StepNoOutputTimeoutAttribute] = timeout
+	}
+
+	failed := isStepFailed(result.Status)
+	errorMessage := ""
+	if failed {
+		errorMessage = result.ErrorStr
+	}
+
+	t.spans = append(t.spans, Span{
+		TraceID:      t.root.TraceID,
+		SpanID:       spanIDFromExecutionID(stepExecutionID),
+		ParentSpanID: parentSpanID,
+		Name:         name,
+		StartTime:    result.StartTime,
+		EndTime:      result.StartTime.Add(result.RunTime),
+		Attributes:   attributes,
+		Failed:       failed,
+		ErrorMessage: errorMessage,
+	})
+}
+
+// Finish finishes the root span of the build and returns every recorded span, the root span first.
+func (t *BuildTrace) Finish(endTime time.Time, buildStatus string) []Span {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	root := t.root
+	root.EndTime = endTime
+	root.Attributes[BuildStatusAttribute] = buildStatus
+	root.Failed = buildStatus != models.BuildRunStatusSuccess
+
+	return append([]Span{root}, t.spans...)
+}
+
+// traceIDFromExecutionID returns the 16 bytes of the execution ID (UUID) in hex form.
+func traceIDFromExecutionID(executionID string) string {
+	return hex.EncodeToString(executionIDBytes(executionID))
+}
+
+// spanIDFromExecutionID returns the first 8 bytes of the execution ID (UUID) in hex form.
+func spanIDFromExecutionID(executionID string) string {
+	return hex.EncodeToString(executionIDBytes(executionID)[:8])
+}
+
+func executionIDBytes(executionID string) []byte {
+	if id, err := uuid.FromString(executionID); err == nil {
+		return id.Bytes()
+	}
+	// Not an UUID, derive a stable ID from it
+	sum := sha256.Sum256([]byte(executionID))
+	return sum[:16]
+}
+
+func stepTimeoutSeconds(applied time.Duration, configured *int) int {
+	if applied > 0 {
+		return int(applied.Seconds())
+	}
+	if configured != nil && *configured > 0 {
+		return *configured
+	}
+	return 0
+}
+
+func isStepFailed(status models.StepRunStatus) bool {
+	switch status {
+	case models.StepRunStatusCodeFailed,
+		models.StepRunStatusCodePreparationFailed,
+		models.StepRunStatusAbortedWithCustomTimeout,
+		models.StepRunStatusAbortedWithNoOutputTimeout,
+		models.StepRunStatusAbortedWithRunTimeout,
+		models.StepRunStatusAborted:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/bitrise-io/go-utils/pointers"
+	stepmanModels "github.com/bitrise-io/stepman/models"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	buildExecutionID    = "1f36e5a6-5c3a-4b0a-9a53-2a8d3c3c6e01"
+	workflowExecutionID = "7c1e0d5b-8f0e-4f4e-a1a8-1d7f0a6f3b02"
+	stepExecutionID     = "c3b9f1a4-2d6e-4b8f-9e1d-5a7c8b9d0e03"
+)
+
+func TestBuildTrace(t *testing.T) {
+	startTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	trace := NewBuildTrace(buildExecutionID, "primary", startTime)
+	trace.StartWorkflow(models.WorkflowExecutionPlan{UUID: workflowExecutionID, WorkflowID: "primary", WorkflowTitle: "Primary"}, startTime)
+	trace.AddStep(workflowExecutionID, stepExecutionID, models.StepRunResultsModel{
+		StepInfo: stepmanModels.StepInfoModel{
+			ID:      "script",
+			Version: "1.2.3",
+			Step:    stepmanModels.StepModel{Title: pointers.NewStringPtr("Test"), NoOutputTimeout: pointers.NewIntPtr(60)},
+		},
+		Status:    models.StepRunStatusAbortedWithCustomTimeout,
+		StartTime: startTime.Add(time.Second),
+		RunTime:   10 * time.Second,
+		ExitCode:  1,
+		ErrorStr:  "timed out",
+		Timeout:   10 * time.Second,
+	})
+	trace.FinishWorkflow(workflowExecutionID, startTime.Add(12*time.Second), true)
+	spans := trace.Finish(startTime.Add(15*time.Second), models.BuildRunStatusFailed)
+
+	require.Equal(t, []Span{
+		{
+			TraceID:   "1f36e5a65c3a4b0a9a532a8d3c3c6e01",
+			SpanID:    "1f36e5a65c3a4b0a",
+			Name:      "bitrise run primary",
+			StartTime: startTime,
+			EndTime:   startTime.Add(15 * time.Second),
+			Attributes: map[string]interface{}{
+				BuildExecutionIDAttribute: buildExecutionID,
+				WorkflowIDAttribute:       "primary",
+				BuildStatusAttribute:      models.BuildRunStatusFailed,
+			},
+			Failed: true,
+		},
+		{
+			TraceID:      "1f36e5a65c3a4b0a9a532a8d3c3c6e01",
+			SpanID:       "c3b9f1a42d6e4b8f",
+			ParentSpanID: "7c1e0d5b8f0e4f4e",
+			Name:         "Test",
+			StartTime:    startTime.Add(time.Second),
+			EndTime:      startTime.Add(11 * time.Second),
+			Attributes: map[string]interface{}{
+				StepExecutionIDAttribute:     stepExecutionID,
+				StepIDAttribute:              "script",
+				StepTitleAttribute:           "Test",
+				StepVersionAttribute:         "1.2.3",
+				StepStatusAttribute:          "aborted_with_custom_timeout",
+				StepExitCodeAttribute:        1,
+				StepTimeoutAttribute:         10,
+				StepNoOutputTimeoutAttribute: 60,
+			},
+			Failed:       true,
+			ErrorMessage: "timed out",
+		},
+		{
+			TraceID:      "1f36e5a65c3a4b0a9a532a8d3c3c6e01",
+			SpanID:       "7c1e0d5b8f0e4f4e",
+			ParentSpanID: "1f36e5a65c3a4b0a",
+			Name:         "primary",
+			StartTime:    startTime,
+			EndTime:      startTime.Add(12 * time.Second),
+			Attributes: map[string]interface{}{
+				WorkflowExecutionIDAttribute: workflowExecutionID,
+				WorkflowIDAttribute:          "primary",
+				WorkflowTitleAttribute:       "Primary",
+			},
+			Failed: true,
+		},
+	}, spans)
+}
+
+func TestBuildTrace_Nil(t *testing.T) {
+	var trace *BuildTrace
+	trace.StartWorkflow(models.WorkflowExecutionPlan{UUID: workflowExecutionID}, time.Now())
+	trace.AddStep(workflowExecutionID, stepExecutionID, models.StepRunResultsModel{})
+	trace.FinishWorkflow(workflowExecutionID, time.Now(), false)
+	require.Nil(t, trace.Finish(time.Now(), models.BuildRunStatusSuccess))
+}
+
+func TestSpanIDFromExecutionID(t *testing.T) {
+	require.Equal(t, "1f36e5a65c3a4b0a", spanIDFromExecutionID(buildExecutionID))
+	require.Equal(t, "1f36e5a65c3a4b0a9a532a8d3c3c6e01", traceIDFromExecutionID(buildExecutionID))
+
+	t.Log("IDs which are not UUIDs get a stable derived ID")
+	require.Len(t, spanIDFromExecutionID("my-id"), 16)
+	require.Equal(t, spanIDFromExecutionID("my-id"), spanIDFromExecutionID("my-id"))
+	require.NotEqual(t, spanIDFromExecutionID("my-id"), spanIDFromExecutionID("other-id"))
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+type bitbucketPayload struct {
+	// repo:push event
+	Push *struct {
+		Changes []struct {
+			New *struct {
+				Type   string `json:"type"`
+				Name   string `json:"name"`
+				Target struct {
+					Message string `json:"message"`
+				} `json:"target"`
+			} `json:"new"`
+		} `json:"changes"`
+	} `json:"push"`
+
+	// pullrequest:* events
+	PullRequest *struct {
+		Draft  bool `json:"draft"`
+		Source struct {
+			Branch struct {
+				Name string `json:"name"`
+			} `json:"branch"`
+		} `json:"source"`
+		Destination struct {
+			Branch struct {
+				Name string `json:"name"`
+			} `json:"branch"`
+		} `json:"destination"`
+	} `json:"pullrequest"`
+	Comment *struct {
+		Content struct {
+			Raw string `json:"raw"`
+		} `json:"content"`
+	} `json:"comment"`
+}
+
+// parseBitbucketPayload supports the (Bitbucket Cloud) repo:push, pullrequest:created, pullrequest:updated
+// and pullrequest:comment_created events. Bitbucket payloads don't contain the changed files nor labels.
+func parseBitbucketPayload(payload []byte) (models.TriggerParams, error) {
+	var event bitbucketPayload
+	if err := unmarshalPayload(payload, &event); err != nil {
+		return models.TriggerParams{}, err
+	}
+
+	var params models.TriggerParams
+	switch {
+	case event.PullRequest != nil:
+		params.PRSourceBranch = event.PullRequest.Source.Branch.Name
+		params.PRTargetBranch = event.PullRequest.Destination.Branch.Name
+		params.PRReadyState = pullRequestReadyState(event.PullRequest.Draft, false)
+		if event.Comment != nil {
+			params.PRComment = event.Comment.Content.Raw
+		}
+	case event.Push != nil:
+		if len(event.Push.Changes) == 0 || event.Push.Changes[0].New == nil {
+			return models.TriggerParams{}, errors.New("deleted refs don't trigger builds")
+		}
+
+		change := event.Push.Changes[0].New
+		switch change.Type {
+		case "branch", "named_branch":
+			params.PushBranch = change.Name
+		case "tag", "annotated_tag":
+			params.Tag = change.Name
+		default:
+			return models.TriggerParams{}, fmt.Errorf("unsupported push change type: %s", change.Type)
+		}
+		params.CommitMessage = change.Target.Message
+	default:
+		return models.TriggerParams{}, errors.New("unsupported event: only repo:push and pullrequest events are supported")
+	}
+
+	return params, nil
+}
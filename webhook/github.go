@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+type gitHubCommit struct {
+	Message  string   `json:"message"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+type gitHubLabel struct {
+	Name string `json:"name"`
+}
+
+type gitHubPullRequest struct {
+	Draft bool `json:"draft"`
+	Head  struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	Labels []gitHubLabel `json:"labels"`
+}
+
+// gitHubPullRequestActions are the actions of the pull_request event which trigger a build,
+// others (like closed or labeled) don't change the code to build.
+var gitHubPullRequestActions = []string{"opened", "reopened", "synchronize", "edited", "ready_for_review"}
+
+type gitHubPayload struct {
+	// push event
+	Ref        string         `json:"ref"`
+	Deleted    bool           `json:"deleted"`
+	HeadCommit *gitHubCommit  `json:"head_commit"`
+	Commits    []gitHubCommit `json:"commits"`
+
+	// pull_request, pull_request_review_comment and issue_comment events
+	Action      string             `json:"action"`
+	PullRequest *gitHubPullRequest `json:"pull_request"`
+	Issue       *struct {
+		PullRequest *struct{}     `json:"pull_request"`
+		Labels      []gitHubLabel `json:"labels"`
+	} `json:"issue"`
+	Comment *struct {
+		Body string `json:"body"`
+	} `json:"comment"`
+}
+
+// parseGitHubPayload supports the push, pull_request, pull_request_review_comment and issue_comment events.
+// The issue_comment event payload doesn't contain the pull request branches, they have to be set by the caller.
+func parseGitHubPayload(payload []byte) (models.TriggerParams, error) {
+	var event gitHubPayload
+	if err := unmarshalPayload(payload, &event); err != nil {
+		return models.TriggerParams{}, err
+	}
+
+	var params models.TriggerParams
+	switch {
+	case event.PullRequest != nil:
+		if event.Comment == nil && !isBuildTriggeringPullRequestAction(event.Action) {
+			return models.TriggerParams{}, fmt.Errorf("pull request action (%s) doesn't trigger builds", event.Action)
+		}
+
+		params.PRSourceBranch = event.PullRequest.Head.Ref
+		params.PRTargetBranch = event.PullRequest.Base.Ref
+		params.PRReadyState = pullRequestReadyState(event.PullRequest.Draft, event.Action == "ready_for_review")
+		for _, label := range event.PullRequest.Labels {
+			params.PRLabels = append(params.PRLabels, label.Name)
+		}
+		if event.Comment != nil {
+			params.PRComment = event.Comment.Body
+		}
+	case event.Issue != nil:
+		if event.Issue.PullRequest == nil || event.Comment == nil {
+			return models.TriggerParams{}, errors.New("issue comment is not on a pull request")
+		}
+		params.PRComment = event.Comment.Body
+		for _, label := range event.Issue.Labels {
+			params.PRLabels = append(params.PRLabels, label.Name)
+		}
+	case event.Ref != "":
+		if event.Deleted {
+			return models.TriggerParams{}, errors.New("deleted refs don't trigger builds")
+		}
+
+		branch, tag, err := parseRef(event.Ref)
+		if err != nil {
+			return models.TriggerParams{}, err
+		}
+		params.PushBranch = branch
+		params.Tag = tag
+
+		if event.HeadCommit != nil {
+			params.CommitMessage = event.HeadCommit.Message
+		}
+		var fileLists [][]string
+		for _, commit := range event.Commits {
+			fileLists = append(fileLists, commit.Added, commit.Removed, commit.Modified)
+		}
+		params.ChangedFiles = changedFiles(fileLists...)
+	default:
+		return models.TriggerParams{}, errors.New("unsupported event: only push, pull_request, pull_request_review_comment and issue_comment events are supported")
+	}
+
+	return params, nil
+}
+
+func isBuildTriggeringPullRequestAction(action string) bool {
+	for _, triggeringAction := range gitHubPullRequestActions {
+		if action == triggeringAction {
+			return true
+		}
+	}
+	return false
+}
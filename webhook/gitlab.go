@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+type gitLabCommit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+type gitLabLabel struct {
+	Title string `json:"title"`
+}
+
+type gitLabMergeRequest struct {
+	SourceBranch   string `json:"source_branch"`
+	TargetBranch   string `json:"target_branch"`
+	Draft          bool   `json:"draft"`
+	WorkInProgress bool   `json:"work_in_progress"`
+	Action         string `json:"action"`
+	LastCommit     struct {
+		Message string `json:"message"`
+	} `json:"last_commit"`
+}
+
+type gitLabPayload struct {
+	ObjectKind string `json:"object_kind"`
+
+	// push and tag_push events
+	Ref         string         `json:"ref"`
+	CheckoutSHA *string        `json:"checkout_sha"`
+	Commits     []gitLabCommit `json:"commits"`
+
+	// merge_request and note events
+	ObjectAttributes struct {
+		gitLabMergeRequest
+		Note         string `json:"note"`
+		NoteableType string `json:"noteable_type"`
+	} `json:"object_attributes"`
+	MergeRequest *gitLabMergeRequest `json:"merge_request"`
+	Labels       []gitLabLabel       `json:"labels"`
+	Changes      struct {
+		Draft *struct {
+			Previous bool `json:"previous"`
+			Current  bool `json:"current"`
+		} `json:"draft"`
+	} `json:"changes"`
+}
+
+// parseGitLabPayload supports the push, tag_push, merge_request and (merge request) note events.
+func parseGitLabPayload(payload []byte) (models.TriggerParams, error) {
+	var event gitLabPayload
+	if err := unmarshalPayload(payload, &event); err != nil {
+		return models.TriggerParams{}, err
+	}
+
+	var params models.TriggerParams
+	switch event.ObjectKind {
+	case "push", "tag_push":
+		if event.CheckoutSHA == nil {
+			return models.TriggerParams{}, errors.New("deleted refs don't trigger builds")
+		}
+
+		branch, tag, err := parseRef(event.Ref)
+		if err != nil {
+			return models.TriggerParams{}, err
+		}
+		params.PushBranch = branch
+		params.Tag = tag
+
+		var fileLists [][]string
+		for _, commit := range event.Commits {
+			if commit.ID == *event.CheckoutSHA {
+				params.CommitMessage = commit.Message
+			}
+			fileLists = append(fileLists, commit.Added, commit.Removed, commit.Modified)
+		}
+		params.ChangedFiles = changedFiles(fileLists...)
+	case "merge_request":
+		mergeRequest := event.ObjectAttributes.gitLabMergeRequest
+		convertedToReady := event.Changes.Draft != nil && event.Changes.Draft.Previous && !event.Changes.Draft.Current
+
+		params.PRSourceBranch = mergeRequest.SourceBranch
+		params.PRTargetBranch = mergeRequest.TargetBranch
+		params.PRReadyState = pullRequestReadyState(mergeRequest.Draft || mergeRequest.WorkInProgress, convertedToReady)
+		params.CommitMessage = mergeRequest.LastCommit.Message
+		params.PRLabels = gitLabLabelTitles(event.Labels)
+	case "note":
+		if event.ObjectAttributes.NoteableType != "MergeRequest" || event.MergeRequest == nil {
+			return models.TriggerParams{}, errors.New("note is not on a merge request")
+		}
+
+		params.PRSourceBranch = event.MergeRequest.SourceBranch
+		params.PRTargetBranch = event.MergeRequest.TargetBranch
+		params.PRReadyState = pullRequestReadyState(event.MergeRequest.Draft || event.MergeRequest.WorkInProgress, false)
+		params.CommitMessage = event.MergeRequest.LastCommit.Message
+		params.PRComment = event.ObjectAttributes.Note
+		params.PRLabels = gitLabLabelTitles(event.Labels)
+	default:
+		return models.TriggerParams{}, fmt.Errorf("unsupported event (%s): only push, tag_push, merge_request and note events are supported", event.ObjectKind)
+	}
+
+	return params, nil
+}
+
+func gitLabLabelTitles(labels []gitLabLabel) []string {
+	var titles []string
+	for _, label := range labels {
+		titles = append(titles, label.Title)
+	}
+	return titles
+}
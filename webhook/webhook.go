@@ -0,0 +1,87 @@
+// Package webhook derives the trigger params from the webhook payloads of the git providers.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/bitrise/models"
+)
+
+const (
+	ProviderGitHub    = "github"
+	ProviderGitLab    = "gitlab"
+	ProviderBitbucket = "bitbucket"
+)
+
+const (
+	branchRefPrefix = "refs/heads/"
+	tagRefPrefix    = "refs/tags/"
+)
+
+// ParsePayload returns the trigger params of the raw webhook payload sent by the git provider.
+// The event type is detected from the payload.
+func ParsePayload(provider string, payload []byte) (models.TriggerParams, error) {
+	var params models.TriggerParams
+	var err error
+	switch provider {
+	case ProviderGitHub:
+		params, err = parseGitHubPayload(payload)
+	case ProviderGitLab:
+		params, err = parseGitLabPayload(payload)
+	case ProviderBitbucket:
+		params, err = parseBitbucketPayload(payload)
+	default:
+		return models.TriggerParams{}, fmt.Errorf("invalid provider (%s): accepted values are %s, %s and %s", provider, ProviderGitHub, ProviderGitLab, ProviderBitbucket)
+	}
+	if err != nil {
+		return models.TriggerParams{}, fmt.Errorf("failed to parse %s webhook payload: %w", provider, err)
+	}
+	return params, nil
+}
+
+func unmarshalPayload(payload []byte, v interface{}) error {
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
+
+// parseRef returns the branch or tag name of a git ref.
+func parseRef(ref string) (branch string, tag string, err error) {
+	switch {
+	case strings.HasPrefix(ref, branchRefPrefix):
+		return strings.TrimPrefix(ref, branchRefPrefix), "", nil
+	case strings.HasPrefix(ref, tagRefPrefix):
+		return "", strings.TrimPrefix(ref, tagRefPrefix), nil
+	default:
+		return "", "", fmt.Errorf("unsupported ref: %s", ref)
+	}
+}
+
+// changedFiles returns the distinct files changed by the commits.
+func changedFiles(fileLists ...[]string) []string {
+	var files []string
+	seen := map[string]bool{}
+	for _, fileList := range fileLists {
+		for _, file := range fileList {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+func pullRequestReadyState(draft, convertedToReady bool) models.PullRequestReadyState {
+	switch {
+	case draft:
+		return models.PullRequestReadyStateDraft
+	case convertedToReady:
+		return models.PullRequestReadyStateConvertedToReadyForReview
+	default:
+		return models.PullRequestReadyStateReadyForReview
+	}
+}
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/bitrise/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		payload  string
+		want     models.TriggerParams
+	}{
+		{
+			name:     "GitHub push",
+			provider: ProviderGitHub,
+			payload:  "github_push.json",
+			want: models.TriggerParams{
+				PushBranch:    "release/1.2",
+				CommitMessage: "Bump version [deploy]",
+				ChangedFiles:  []string{"ios/Podfile", "CHANGELOG.md", "version.txt"},
+			},
+		},
+		{
+			name:     "GitHub tag push",
+			provider: ProviderGitHub,
+			payload:  "github_tag_push.json",
+			want: models.TriggerParams{
+				Tag:           "v1.2.0",
+				CommitMessage: "Bump version [deploy]",
+			},
+		},
+		{
+			name:     "GitHub pull request",
+			provider: ProviderGitHub,
+			payload:  "github_pull_request.json",
+			want: models.TriggerParams{
+				PRSourceBranch: "feature/login",
+				PRTargetBranch: "main",
+				PRReadyState:   models.PullRequestReadyStateReadyForReview,
+				PRLabels:       []string{"ci:full", "ios"},
+			},
+		},
+		{
+			name:     "GitHub pull request comment",
+			provider: ProviderGitHub,
+			payload:  "github_issue_comment.json",
+			want: models.TriggerParams{
+				PRLabels:  []string{"ci:full"},
+				PRComment: "/bitrise run e2e",
+			},
+		},
+		{
+			name:     "GitLab push",
+			provider: ProviderGitLab,
+			payload:  "gitlab_push.json",
+			want: models.TriggerParams{
+				PushBranch:    "main",
+				CommitMessage: "Fix crash on launch",
+				ChangedFiles:  []string{"android/gradle/wrapper/gradle-wrapper.properties", "android/app/src/main/Fix.kt", "android/app/src/main/Old.kt"},
+			},
+		},
+		{
+			name:     "GitLab merge request marked as ready",
+			provider: ProviderGitLab,
+			payload:  "gitlab_merge_request.json",
+			want: models.TriggerParams{
+				PRSourceBranch: "feature/payments",
+				PRTargetBranch: "develop",
+				PRReadyState:   models.PullRequestReadyStateConvertedToReadyForReview,
+				CommitMessage:  "Add payments",
+				PRLabels:       []string{"ci:full"},
+			},
+		},
+		{
+			name:     "GitLab merge request note",
+			provider: ProviderGitLab,
+			payload:  "gitlab_note.json",
+			want: models.TriggerParams{
+				PRSourceBranch: "feature/payments",
+				PRTargetBranch: "develop",
+				PRReadyState:   models.PullRequestReadyStateDraft,
+				CommitMessage:  "Add payments",
+				PRComment:      "/bitrise run e2e",
+			},
+		},
+		{
+			name:     "Bitbucket tag push",
+			provider: ProviderBitbucket,
+			payload:  "bitbucket_push.json",
+			want: models.TriggerParams{
+				Tag:           "2.0.0",
+				CommitMessage: "Release 2.0.0\n",
+			},
+		},
+		{
+			name:     "Bitbucket pull request comment",
+			provider: ProviderBitbucket,
+			payload:  "bitbucket_pull_request_comment.json",
+			want: models.TriggerParams{
+				PRSourceBranch: "feature/settings",
+				PRTargetBranch: "main",
+				PRReadyState:   models.PullRequestReadyStateReadyForReview,
+				PRComment:      "/bitrise run e2e",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := os.ReadFile(filepath.Join("testdata", tt.payload))
+			require.NoError(t, err)
+
+			got, err := ParsePayload(tt.provider, payload)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePayload_GitHubPullRequestReadyState(t *testing.T) {
+	params, err := ParsePayload(ProviderGitHub, []byte(`{"action": "opened", "pull_request": {"draft": true, "head": {"ref": "feature"}, "base": {"ref": "main"}}}`))
+	require.NoError(t, err)
+	require.Equal(t, models.PullRequestReadyStateDraft, params.PRReadyState)
+
+	params, err = ParsePayload(ProviderGitHub, []byte(`{"action": "ready_for_review", "pull_request": {"draft": false, "head": {"ref": "feature"}, "base": {"ref": "main"}}}`))
+	require.NoError(t, err)
+	require.Equal(t, models.PullRequestReadyStateConvertedToReadyForReview, params.PRReadyState)
+}
+
+func TestParsePayload_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		payload  string
+		wantErr  string
+	}{
+		{
+			name:     "invalid provider",
+			provider: "gitea",
+			payload:  `{}`,
+			wantErr:  "invalid provider (gitea): accepted values are github, gitlab and bitbucket",
+		},
+		{
+			name:     "invalid JSON",
+			provider: ProviderGitHub,
+			payload:  `{`,
+			wantErr:  "failed to parse github webhook payload: invalid JSON: unexpected end of JSON input",
+		},
+		{
+			name:     "GitHub branch deletion",
+			provider: ProviderGitHub,
+			payload:  `{"ref": "refs/heads/feature", "deleted": true}`,
+			wantErr:  "failed to parse github webhook payload: deleted refs don't trigger builds",
+		},
+		{
+			name:     "GitHub closed pull request",
+			provider: ProviderGitHub,
+			payload:  `{"action": "closed", "pull_request": {"head": {"ref": "feature"}, "base": {"ref": "main"}}}`,
+			wantErr:  "failed to parse github webhook payload: pull request action (closed) doesn't trigger builds",
+		},
+		{
+			name:     "GitHub labeled pull request",
+			provider: ProviderGitHub,
+			payload:  `{"action": "labeled", "pull_request": {"head": {"ref": "feature"}, "base": {"ref": "main"}}}`,
+			wantErr:  "failed to parse github webhook payload: pull request action (labeled) doesn't trigger builds",
+		},
+		{
+			name:     "GitHub issue comment",
+			provider: ProviderGitHub,
+			payload:  `{"issue": {}, "comment": {"body": "LGTM"}}`,
+			wantErr:  "failed to parse github webhook payload: issue comment is not on a pull request",
+		},
+		{
+			name:     "GitHub unsupported event",
+			provider: ProviderGitHub,
+			payload:  `{"action": "starred"}`,
+			wantErr:  "failed to parse github webhook payload: unsupported event: only push, pull_request, pull_request_review_comment and issue_comment events are supported",
+		},
+		{
+			name:     "GitLab unsupported event",
+			provider: ProviderGitLab,
+			payload:  `{"object_kind": "pipeline"}`,
+			wantErr:  "failed to parse gitlab webhook payload: unsupported event (pipeline): only push, tag_push, merge_request and note events are supported",
+		},
+		{
+			name:     "Bitbucket branch deletion",
+			provider: ProviderBitbucket,
+			payload:  `{"push": {"changes": [{"new": null}]}}`,
+			wantErr:  "failed to parse bitbucket webhook payload: deleted refs don't trigger builds",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePayload(tt.provider, []byte(tt.payload))
+			require.EqualError(t, err, tt.wantErr)
+		})
+	}
+}